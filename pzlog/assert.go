@@ -0,0 +1,74 @@
+package pzlog
+
+import (
+	"fmt"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// TestingT AssertLogged使用的测试接口，*testing.T和*testing.B均满足
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// AssertLogged 断言observer记录的日志中存在级别、消息均匹配且包含wantFields中所有字段的日志，
+// 字段值按reflect.DeepEqual比较，不相等时再按fmt.Sprint的结果比较(例如int与int64)。
+// 不存在时通过t.Errorf报告同级别同消息的日志中不匹配的字段，返回是否匹配
+func AssertLogged(t TestingT, logs *observer.ObservedLogs, level zapcore.Level, msg string, wantFields map[string]interface{}) bool {
+	t.Helper()
+	var mismatches []string
+	for _, entry := range logs.All() {
+		if entry.Level != level || entry.Message != msg {
+			continue
+		}
+		diff := fieldsDiff(entry.ContextMap(), wantFields)
+		if len(diff) == 0 {
+			return true
+		}
+		mismatches = append(mismatches, strings.Join(diff, "; "))
+	}
+	if len(mismatches) == 0 {
+		t.Errorf("pzlog: no %s entry with message %q was logged, got %d entries:\n%s", level, msg, logs.Len(), describeEntries(logs.All()))
+		return false
+	}
+	var sb strings.Builder
+	for i, m := range mismatches {
+		fmt.Fprintf(&sb, "  entry %d: %s\n", i+1, m)
+	}
+	t.Errorf("pzlog: %d %s entries with message %q were logged, but none has the wanted fields:\n%s", len(mismatches), level, msg, sb.String())
+	return false
+}
+
+// fieldsDiff 返回got中缺少或不等于want的字段描述，按字段名排序
+func fieldsDiff(got, want map[string]interface{}) []string {
+	keys := make([]string, 0, len(want))
+	for k := range want {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var diff []string
+	for _, k := range keys {
+		g, ok := got[k]
+		if !ok {
+			diff = append(diff, fmt.Sprintf("field %q is missing", k))
+			continue
+		}
+		if !reflect.DeepEqual(g, want[k]) && fmt.Sprint(g) != fmt.Sprint(want[k]) {
+			diff = append(diff, fmt.Sprintf("field %q = %v, want %v", k, g, want[k]))
+		}
+	}
+	return diff
+}
+
+// describeEntries 列出日志的级别、消息和字段，用于失败信息
+func describeEntries(entries []observer.LoggedEntry) string {
+	var sb strings.Builder
+	for _, e := range entries {
+		fmt.Fprintf(&sb, "  %s %q %v\n", e.Level, e.Message, e.ContextMap())
+	}
+	return sb.String()
+}
@@ -0,0 +1,63 @@
+package pzlog
+
+import (
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"strings"
+	"testing"
+)
+
+// recordingT 记录AssertLogged报告的失败信息
+type recordingT struct {
+	errors []string
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertLogged(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(core)
+	logger.Info("created", zap.String("user", "bob"), zap.Int("id", 7))
+	logger.Info("created", zap.String("user", "alice"), zap.Int("id", 8))
+
+	if !AssertLogged(t, logs, zapcore.InfoLevel, "created", map[string]interface{}{"user": "alice", "id": 8}) {
+		t.Error("AssertLogged did not match the second entry")
+	}
+
+	tests := []struct {
+		name  string
+		level zapcore.Level
+		msg   string
+		want  map[string]interface{}
+		error []string
+	}{
+		{"field mismatch", zapcore.InfoLevel, "created", map[string]interface{}{"user": "carol"},
+			[]string{"2 info entries", `entry 1: field "user" = bob, want carol`, `entry 2: field "user" = alice, want carol`}},
+		{"missing field", zapcore.InfoLevel, "created", map[string]interface{}{"role": "admin"},
+			[]string{`field "role" is missing`}},
+		{"no such entry", zapcore.WarnLevel, "created", nil,
+			[]string{`no warn entry with message "created"`, "got 2 entries", `info "created"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recordingT{}
+			if AssertLogged(rec, logs, tt.level, tt.msg, tt.want) {
+				t.Fatal("AssertLogged matched, want failure")
+			}
+			if len(rec.errors) != 1 {
+				t.Fatalf("got %d errors, want 1: %q", len(rec.errors), rec.errors)
+			}
+			for _, want := range tt.error {
+				if !strings.Contains(rec.errors[0], want) {
+					t.Errorf("error %q does not contain %q", rec.errors[0], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package pzlog
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultAsyncBufferSize = 1024
+
+// 异步缓冲区满时的处理策略
+const (
+	// BackpressureDropNewest 丢弃新写入的日志
+	BackpressureDropNewest = "drop-newest"
+	// BackpressureDropOldest 丢弃缓冲区中最旧的日志，为新日志腾出空间
+	BackpressureDropOldest = "drop-oldest"
+	// BackpressureBlock 阻塞写入方直到缓冲区有空间
+	BackpressureBlock = "block"
+)
+
+// droppedEntries 所有异步写入器因缓冲区满而丢弃的日志条数
+var droppedEntries atomic.Int64
+
+// DroppedEntries 返回异步模式下因缓冲区满而丢弃的日志条数
+func DroppedEntries() int64 {
+	return droppedEntries.Load()
+}
+
+// asyncWriteSyncer 将日志放入缓冲队列，由后台goroutine写入底层WriteSyncer
+type asyncWriteSyncer struct {
+	ws       zapcore.WriteSyncer
+	queue    chan []byte
+	strategy string
+	// enc 用于编码丢弃警告
+	enc     zapcore.Encoder
+	dropped atomic.Int64
+
+	// enqueued 已放入队列的条数，done 已写入或被挤出队列的条数，用于Sync等待
+	enqueued atomic.Uint64
+	mu       sync.Mutex
+	cond     *sync.Cond
+	done     uint64
+
+	// closed 关闭后Write直接写入底层WriteSyncer，stop通知后台goroutine退出，stopped在其退出后关闭
+	closed    atomic.Bool
+	closeOnce sync.Once
+	stop      chan struct{}
+	stopped   chan struct{}
+}
+
+func newAsyncWriteSyncer(ws zapcore.WriteSyncer, enc zapcore.Encoder, size int, strategy string, reportInterval time.Duration) *asyncWriteSyncer {
+	if size <= 0 {
+		size = defaultAsyncBufferSize
+	}
+	w := &asyncWriteSyncer{
+		ws:       ws,
+		queue:    make(chan []byte, size),
+		strategy: strategy,
+		enc:      enc.Clone(),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	w.cond = sync.NewCond(&w.mu)
+	go w.run(reportInterval)
+	return w
+}
+
+func (w *asyncWriteSyncer) Write(p []byte) (int, error) {
+	if w.closed.Load() {
+		return w.ws.Write(p)
+	}
+	data := make([]byte, len(p))
+	copy(data, p)
+	switch w.strategy {
+	case BackpressureBlock:
+		w.queue <- data
+		w.enqueued.Add(1)
+	case BackpressureDropOldest:
+		for {
+			select {
+			case w.queue <- data:
+				w.enqueued.Add(1)
+				return len(p), nil
+			default:
+			}
+			select {
+			case <-w.queue:
+				w.drop()
+				w.markDone()
+			default:
+			}
+		}
+	default:
+		select {
+		case w.queue <- data:
+			w.enqueued.Add(1)
+		default:
+			w.drop()
+		}
+	}
+	return len(p), nil
+}
+
+func (w *asyncWriteSyncer) drop() {
+	w.dropped.Add(1)
+	droppedEntries.Add(1)
+}
+
+func (w *asyncWriteSyncer) markDone() {
+	w.mu.Lock()
+	w.done++
+	w.cond.Broadcast()
+	w.mu.Unlock()
+}
+
+// Sync 等待缓冲区中已有的日志写完后刷新底层WriteSyncer
+func (w *asyncWriteSyncer) Sync() error {
+	target := w.enqueued.Load()
+	w.mu.Lock()
+	for w.done < target && !w.closed.Load() {
+		w.cond.Wait()
+	}
+	w.mu.Unlock()
+	return w.ws.Sync()
+}
+
+// Close 写完缓冲区中的日志后停止后台goroutine，之后的写入直接写入底层WriteSyncer
+func (w *asyncWriteSyncer) Close() error {
+	w.closeOnce.Do(func() {
+		w.closed.Store(true)
+		close(w.stop)
+		<-w.stopped
+		// 唤醒与Close并发的Sync
+		w.mu.Lock()
+		w.cond.Broadcast()
+		w.mu.Unlock()
+	})
+	return w.ws.Sync()
+}
+
+func (w *asyncWriteSyncer) run(reportInterval time.Duration) {
+	defer close(w.stopped)
+	var tick <-chan time.Time
+	if reportInterval > 0 {
+		ticker := time.NewTicker(reportInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	var reported int64
+	for {
+		select {
+		case data := <-w.queue:
+			_, _ = w.ws.Write(data)
+			w.markDone()
+		case <-tick:
+			dropped := w.dropped.Load()
+			if dropped > reported {
+				w.reportDropped(dropped-reported, dropped)
+				reported = dropped
+			}
+		case <-w.stop:
+			for {
+				select {
+				case data := <-w.queue:
+					_, _ = w.ws.Write(data)
+					w.markDone()
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// reportDropped 直接向底层WriteSyncer写入一条丢弃日志的警告
+func (w *asyncWriteSyncer) reportDropped(n, total int64) {
+	entry := zapcore.Entry{
+		Level:   zapcore.WarnLevel,
+		Time:    time.Now(),
+		Message: "pzlog: async buffer full, log entries dropped",
+	}
+	buf, err := w.enc.EncodeEntry(entry, []zapcore.Field{
+		zap.Int64("dropped", n),
+		zap.Int64("dropped_total", total),
+	})
+	if err != nil {
+		return
+	}
+	_, _ = w.ws.Write(buf.Bytes())
+	buf.Free()
+}
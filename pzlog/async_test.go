@@ -0,0 +1,196 @@
+package pzlog
+
+import (
+	"bytes"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingWriter 在release关闭前阻塞所有写入
+type blockingWriter struct {
+	release chan struct{}
+	mu      sync.Mutex
+	buf     bytes.Buffer
+}
+
+func newBlockingWriter() *blockingWriter {
+	return &blockingWriter{release: make(chan struct{})}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *blockingWriter) Sync() error { return nil }
+
+func (w *blockingWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func testJSONEncoder() zapcore.Encoder {
+	return zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+}
+
+func TestAsyncDroppedEntries(t *testing.T) {
+	bw := newBlockingWriter()
+	w := newAsyncWriteSyncer(bw, testJSONEncoder(), 2, BackpressureDropNewest, 10*time.Millisecond)
+	defer w.Close()
+
+	before := DroppedEntries()
+	// 第一条被后台goroutine取出后阻塞，随后两条填满缓冲区，其余被丢弃
+	_, _ = w.Write([]byte("first\n"))
+	time.Sleep(10 * time.Millisecond)
+	for i := 0; i < 5; i++ {
+		_, _ = w.Write([]byte("entry\n"))
+	}
+	if got := w.dropped.Load(); got != 3 {
+		t.Errorf("dropped = %d, want 3", got)
+	}
+	if got := DroppedEntries() - before; got != 3 {
+		t.Errorf("DroppedEntries increased by %d, want 3", got)
+	}
+
+	close(bw.release)
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(bw.String(), "entries dropped") {
+		if time.Now().After(deadline) {
+			t.Fatalf("no drop summary written, output %q", bw.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if out := bw.String(); !strings.Contains(out, `"dropped":3`) {
+		t.Errorf("summary should report 3 dropped entries: %q", out)
+	}
+}
+
+func TestAsyncDropOldest(t *testing.T) {
+	bw := newBlockingWriter()
+	w := newAsyncWriteSyncer(bw, testJSONEncoder(), 2, BackpressureDropOldest, 0)
+	_, _ = w.Write([]byte("first\n"))
+	time.Sleep(10 * time.Millisecond)
+	for _, s := range []string{"a\n", "b\n", "c\n", "d\n"} {
+		_, _ = w.Write([]byte(s))
+	}
+	close(bw.release)
+	if err := w.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if got := bw.String(); got != "first\nc\nd\n" {
+		t.Errorf("output = %q, want oldest entries dropped", got)
+	}
+	_ = w.Close()
+}
+
+func TestAsyncClose(t *testing.T) {
+	var buf bytes.Buffer
+	w := newAsyncWriteSyncer(zapcore.AddSync(&buf), testJSONEncoder(), 16, BackpressureBlock, time.Millisecond)
+	for i := 0; i < 10; i++ {
+		_, _ = w.Write([]byte("x\n"))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-w.stopped:
+	default:
+		t.Fatal("background goroutine still running after Close")
+	}
+	if n := strings.Count(buf.String(), "x\n"); n != 10 {
+		t.Errorf("%d entries written before Close, want 10", n)
+	}
+	// 关闭后直接写入
+	_, _ = w.Write([]byte("late\n"))
+	if !strings.HasSuffix(buf.String(), "late\n") {
+		t.Error("write after Close was lost")
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
+
+func TestAsyncClosedOnReconfigure(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.Async = true
+	logger := GetLogger(config)
+	logger.Info("before")
+
+	currentSwapMu.Lock()
+	old := currentSwap.root.Load()
+	currentSwapMu.Unlock()
+	var async *asyncWriteSyncer
+	for _, s := range old.sinks {
+		if s.async != nil {
+			async = s.async
+		}
+	}
+	if async == nil {
+		t.Fatal("no async sink")
+	}
+	next, _ := newCapturedConfig()
+	next.Async = true
+	if err := Reconfigure(next); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-async.stopped:
+	case <-time.After(time.Second):
+		t.Fatal("old async goroutine not stopped by Reconfigure")
+	}
+	if lines := out.Lines(); len(lines) != 1 {
+		t.Errorf("entry written before Reconfigure lost: %q", lines)
+	}
+}
+
+func TestAsyncBlock(t *testing.T) {
+	bw := newBlockingWriter()
+	w := newAsyncWriteSyncer(bw, testJSONEncoder(), 1, BackpressureBlock, 0)
+	defer w.Close()
+	_, _ = w.Write([]byte("first\n"))
+	time.Sleep(10 * time.Millisecond)
+	_, _ = w.Write([]byte("second\n"))
+
+	written := make(chan struct{})
+	go func() {
+		_, _ = w.Write([]byte("third\n"))
+		close(written)
+	}()
+	select {
+	case <-written:
+		t.Fatal("write did not block with a full buffer")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(bw.release)
+	select {
+	case <-written:
+	case <-time.After(time.Second):
+		t.Fatal("write still blocked after the buffer drained")
+	}
+	if err := w.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if got := bw.String(); got != "first\nsecond\nthird\n" {
+		t.Errorf("output = %q, want all entries retained", got)
+	}
+	if w.dropped.Load() != 0 {
+		t.Errorf("block strategy dropped %d entries", w.dropped.Load())
+	}
+}
+
+func TestAsyncBackpressureInvalid(t *testing.T) {
+	config := NewDefaultConfig()
+	config.Output = "none"
+	config.Async = true
+	config.BackpressureStrategy = "spill"
+	if _, err := GetLoggerE(config); err == nil {
+		t.Error("expected error for unknown backpressurestrategy")
+	}
+}
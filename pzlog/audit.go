@@ -0,0 +1,109 @@
+package pzlog
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	defaultAuditFilename = "./logs/audit.log"
+	auditFileMode        = 0600
+)
+
+var (
+	auditLogger   *AuditLogger
+	auditLoggerMu sync.RWMutex
+)
+
+// AuditLogger 审计日志，使用独立的配置写入单独的文件(权限0600)，不会与应用日志混合
+type AuditLogger struct {
+	logger *zap.Logger
+}
+
+// NewAuditLogger 根据配置创建审计日志。审计日志只写入文件，忽略Output、PrintConsole和ReplaceGlobals，
+// Filename为空或与默认应用日志相同时使用./logs/audit.log。AuditConsole为true时同时以console格式输出到标准错误
+func NewAuditLogger(config *PzlogConfig) (*AuditLogger, error) {
+	if config == nil {
+		config = NewDefaultConfig()
+	}
+	config = MergeConfig(config, nil)
+	if config.Filename == "" || config.Filename == NewDefaultConfig().Filename {
+		config.Filename = defaultAuditFilename
+	}
+	config.Output = "file"
+	config.PrintConsole = false
+	config.ReplaceGlobals = false
+	setDefaultValue(config)
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+	if err := prepareAuditFile(config.Filename); err != nil {
+		return nil, err
+	}
+	root, level := newCore(config)
+	core := root.core
+	if config.AuditConsole {
+		console := zapcore.NewCore(getEncoder("console", timeFormatter(config), durationEncoder(config), consoleLevelEncoder(config)), zapcore.Lock(os.Stderr), level)
+		core = zapcore.NewTee(core, console)
+	}
+	logger := zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1))
+	return &AuditLogger{logger: logger}, nil
+}
+
+// prepareAuditFile 预先创建审计日志文件并限制权限，lumberjack轮转时会沿用已有文件的权限
+func prepareAuditFile(filename string) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, auditFileMode)
+	if err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Chmod(filename, auditFileMode)
+}
+
+// Log 记录一条审计日志，action为审计动作
+func (a *AuditLogger) Log(action string, fields ...zap.Field) {
+	a.logger.Info(action, auditFields(fields)...)
+}
+
+// auditFields 返回附加了audit标记的新字段切片，不修改调用者的切片
+func auditFields(fields []zap.Field) []zap.Field {
+	all := make([]zap.Field, len(fields)+1)
+	copy(all, fields)
+	all[len(fields)] = zap.Bool("audit", true)
+	return all
+}
+
+// Sync 刷新审计日志
+func (a *AuditLogger) Sync() error {
+	return a.logger.Sync()
+}
+
+// InitAudit 根据配置创建包级别的审计日志，供Audit使用
+func InitAudit(config *PzlogConfig) error {
+	a, err := NewAuditLogger(config)
+	if err != nil {
+		return err
+	}
+	auditLoggerMu.Lock()
+	auditLogger = a
+	auditLoggerMu.Unlock()
+	return nil
+}
+
+// Audit 使用InitAudit创建的审计日志记录一条审计日志，未初始化时不记录
+func Audit(action string, fields ...zap.Field) {
+	auditLoggerMu.RLock()
+	a := auditLogger
+	auditLoggerMu.RUnlock()
+	if a != nil {
+		a.logger.Info(action, auditFields(fields)...)
+	}
+}
@@ -0,0 +1,114 @@
+package pzlog
+
+import (
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditLoggerIsolated(t *testing.T) {
+	dir := t.TempDir()
+	appConfig := NewDefaultConfig()
+	appConfig.Filename = filepath.Join(dir, "app.log")
+	app := GetLogger(appConfig)
+	defer func() { _ = Close() }()
+
+	auditConfig := NewDefaultConfig()
+	auditConfig.Filename = filepath.Join(dir, "audit", "audit.log")
+	auditConfig.PrintConsole = true
+	audit, err := NewAuditLogger(auditConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.Info("app entry")
+	audit.Log("user.delete", zap.String("target", "u1"))
+	_ = app.Sync()
+	_ = audit.Sync()
+
+	appLog := readFile(t, appConfig.Filename)
+	auditLog := readFile(t, auditConfig.Filename)
+	if strings.Contains(appLog, "user.delete") {
+		t.Error("audit entry written to the app log")
+	}
+	if strings.Contains(auditLog, "app entry") {
+		t.Error("app entry written to the audit log")
+	}
+	if !strings.Contains(auditLog, `"msg":"user.delete"`) || !strings.Contains(auditLog, `"audit":true`) {
+		t.Errorf("audit log = %q", auditLog)
+	}
+	info, err := os.Stat(auditConfig.Filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != auditFileMode {
+		t.Errorf("audit file mode = %v, want %v", perm, os.FileMode(auditFileMode))
+	}
+	// 不修改传入的配置
+	if !auditConfig.PrintConsole {
+		t.Error("NewAuditLogger modified the config")
+	}
+}
+
+func TestAuditWithoutInit(t *testing.T) {
+	auditLoggerMu.Lock()
+	prev := auditLogger
+	auditLogger = nil
+	auditLoggerMu.Unlock()
+	defer func() {
+		auditLoggerMu.Lock()
+		auditLogger = prev
+		auditLoggerMu.Unlock()
+	}()
+	Audit("ignored")
+}
+
+func TestAuditConsole(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := os.Stderr
+	os.Stderr = f
+	t.Cleanup(func() {
+		os.Stderr = prev
+		_ = f.Close()
+	})
+
+	config := NewDefaultConfig()
+	config.Filename = filepath.Join(t.TempDir(), "audit.log")
+	config.AuditConsole = true
+	audit, err := NewAuditLogger(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	audit.Log("user.delete", zap.String("target", "u1"))
+	_ = audit.Sync()
+
+	if file := readFile(t, config.Filename); !strings.Contains(file, `"msg":"user.delete"`) {
+		t.Errorf("audit file = %q, want a json entry", file)
+	}
+	console := readFile(t, f.Name())
+	if strings.HasPrefix(console, "{") || !strings.Contains(console, "user.delete") || !strings.Contains(console, `"target": "u1"`) {
+		t.Errorf("stderr = %q, want a console line", console)
+	}
+}
+
+func TestAuditKeepsCallerFields(t *testing.T) {
+	config := NewDefaultConfig()
+	config.Output = "none"
+	audit, err := NewAuditLogger(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 有剩余容量的切片，附加audit标记时不能写入调用者的底层数组
+	fields := make([]zap.Field, 1, 2)
+	fields[0] = zap.String("target", "u1")
+	spare := fields[:2]
+	spare[1] = zap.String("next", "kept")
+	audit.Log("user.delete", fields...)
+	if spare[1].Key != "next" {
+		t.Errorf("caller's slice overwritten with %q", spare[1].Key)
+	}
+}
@@ -0,0 +1,48 @@
+package pzlog
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"sync/atomic"
+)
+
+// BaggageFunc 从context中读取baggage成员的值，不存在时返回空字符串。
+// 使用OpenTelemetry时可以这样实现，pzlog本身不依赖OpenTelemetry：
+//
+//	func(ctx context.Context, key string) string {
+//		return baggage.FromContext(ctx).Member(key).Value()
+//	}
+type BaggageFunc func(ctx context.Context, key string) string
+
+// baggageConfig 需要记录的baggage成员及读取函数
+type baggageConfig struct {
+	keys []string
+	get  BaggageFunc
+}
+
+// currentBaggage 最近一次GetLogger配置的baggage读取，未配置时为nil
+var currentBaggage atomic.Pointer[baggageConfig]
+
+// setBaggage 设置FromContext读取的baggage成员，keys为空或get为nil时不读取
+func setBaggage(keys []string, get BaggageFunc) {
+	if len(keys) == 0 || get == nil {
+		currentBaggage.Store(nil)
+		return
+	}
+	currentBaggage.Store(&baggageConfig{keys: append([]string(nil), keys...), get: get})
+}
+
+// baggageFields 返回context中配置的baggage成员字段，字段名为成员名，值为空的成员不记录
+func baggageFields(ctx context.Context) []zap.Field {
+	b := currentBaggage.Load()
+	if b == nil {
+		return nil
+	}
+	var fields []zap.Field
+	for _, key := range b.keys {
+		if v := b.get(ctx, key); v != "" {
+			fields = append(fields, zap.String(key, v))
+		}
+	}
+	return fields
+}
@@ -0,0 +1,65 @@
+package pzlog
+
+import (
+	"go.uber.org/zap/zapcore"
+	"os"
+)
+
+// isTerminal 判断文件是否为终端
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// bellCore 控制台输出error及以上级别的日志后，向终端输出响铃字符(\a)并调用通知函数
+type bellCore struct {
+	zapcore.Core
+	// out 不为nil时输出响铃字符
+	out    zapcore.WriteSyncer
+	notify func(zapcore.Entry)
+}
+
+// newConsoleBellCore 为输出到控制台文件f的core添加响铃，f不是终端时只调用通知函数
+func newConsoleBellCore(core zapcore.Core, f *os.File, notify func(zapcore.Entry)) zapcore.Core {
+	var out zapcore.WriteSyncer
+	if isTerminal(f) {
+		out = zapcore.Lock(f)
+	}
+	return newBellCore(core, out, notify)
+}
+
+func newBellCore(core zapcore.Core, out zapcore.WriteSyncer, notify func(zapcore.Entry)) zapcore.Core {
+	return &bellCore{Core: core, out: out, notify: notify}
+}
+
+func (c *bellCore) With(fields []zapcore.Field) zapcore.Core {
+	return &bellCore{Core: c.Core.With(fields), out: c.out, notify: c.notify}
+}
+
+func (c *bellCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *bellCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if err := c.Core.Write(entry, fields); err != nil {
+		return err
+	}
+	if entry.Level < zapcore.ErrorLevel {
+		return nil
+	}
+	if c.out != nil {
+		if _, err := c.out.Write([]byte("\a")); err != nil {
+			return err
+		}
+	}
+	if c.notify != nil {
+		c.notify(entry)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package pzlog
+
+import (
+	"bytes"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBellCore(t *testing.T) {
+	var out bytes.Buffer
+	var notified []string
+	ws := zapcore.AddSync(&out)
+	core := zapcore.NewCore(zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig()), ws, zapcore.DebugLevel)
+	logger := zap.New(newBellCore(core, ws, func(e zapcore.Entry) { notified = append(notified, e.Message) }))
+
+	logger.Warn("warning")
+	if strings.Contains(out.String(), "\a") {
+		t.Error("bell emitted for a warning")
+	}
+	logger.Error("failure")
+	if !strings.HasSuffix(out.String(), "\n\a") {
+		t.Errorf("no bell after error entry: %q", out.String())
+	}
+	if len(notified) != 1 || notified[0] != "failure" {
+		t.Errorf("notified = %v, want [failure]", notified)
+	}
+}
+
+func TestConsoleBellNotTerminal(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "console"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	notified := 0
+	core := zapcore.NewCore(zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig()), f, zapcore.DebugLevel)
+	logger := zap.New(newConsoleBellCore(core, f, func(zapcore.Entry) { notified++ }))
+	logger.Error("failure")
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("\a")) {
+		t.Error("bell emitted to a file that is not a terminal")
+	}
+	if notified != 1 {
+		t.Errorf("notify called %d times, want 1", notified)
+	}
+}
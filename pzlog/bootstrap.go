@@ -0,0 +1,118 @@
+package pzlog
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"os"
+	"sync"
+	"time"
+)
+
+// bootstrapBufferSize 启动阶段最多缓存的日志条数，超出的日志被丢弃
+const bootstrapBufferSize = 1024
+
+type bufferedEntry struct {
+	entry  zapcore.Entry
+	fields []zapcore.Field
+}
+
+// bootstrapBuffer 缓存GetLogger之前记录的日志
+type bootstrapBuffer struct {
+	mu      sync.Mutex
+	entries []bufferedEntry
+}
+
+var (
+	bootstrapBuf   = &bootstrapBuffer{}
+	bootstrapState = newSwapState(&rootCore{core: newBufferCore(bootstrapBuf, zapcore.Lock(os.Stderr))})
+	bootstrapOnce  sync.Once
+)
+
+// Bootstrap 返回启动阶段使用的Logger。在第一次GetLogger之前，通过它记录的日志会被缓存(最多1024条)，
+// GetLogger创建Logger后按顺序重放到新的Logger中，之后它直接写入最近一次GetLogger创建的Logger。
+// DPanic及以上级别的日志之后程序可能退出，这类日志连同已缓存的日志立即以console格式输出到标准错误，不再重放。
+// 可以在程序启动时通过zap.ReplaceGlobals(pzlog.Bootstrap())让zap.L()也被缓存。
+func Bootstrap() *zap.Logger {
+	return zap.New(newSwapCore(bootstrapState), zap.AddCaller())
+}
+
+// attachBootstrap 将启动阶段的Logger指向state，并重放缓存的日志
+func attachBootstrap(state *swapState) {
+	bootstrapState.swap(&rootCore{core: newSwapCore(state)})
+	bootstrapOnce.Do(func() {
+		bootstrapBuf.mu.Lock()
+		entries := bootstrapBuf.entries
+		bootstrapBuf.entries = nil
+		bootstrapBuf.mu.Unlock()
+		core := newSwapCore(state)
+		for _, e := range entries {
+			if ce := core.Check(e.entry, nil); ce != nil {
+				ce.Write(e.fields...)
+			}
+		}
+	})
+}
+
+// bufferCore 将日志缓存到bootstrapBuffer，DPanic及以上级别的日志写入out
+type bufferCore struct {
+	buf    *bootstrapBuffer
+	out    zapcore.Core
+	fields []zapcore.Field
+}
+
+func newBufferCore(buf *bootstrapBuffer, out zapcore.WriteSyncer) *bufferCore {
+	enc := getEncoder("console", func(t time.Time) string { return t.Format(logTmFmt) }, zapcore.StringDurationEncoder, cEncodeLevel)
+	return &bufferCore{buf: buf, out: zapcore.NewCore(enc, out, zapcore.DebugLevel)}
+}
+
+func (c *bufferCore) Enabled(zapcore.Level) bool {
+	return true
+}
+
+func (c *bufferCore) With(fields []zapcore.Field) zapcore.Core {
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+	all = append(all, fields...)
+	return &bufferCore{buf: c.buf, out: c.out, fields: all}
+}
+
+func (c *bufferCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(entry, c)
+}
+
+func (c *bufferCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+	all = append(all, fields...)
+	if entry.Level >= zapcore.DPanicLevel {
+		return c.flush(bufferedEntry{entry: entry, fields: all})
+	}
+	c.buf.mu.Lock()
+	defer c.buf.mu.Unlock()
+	if len(c.buf.entries) < bootstrapBufferSize {
+		c.buf.entries = append(c.buf.entries, bufferedEntry{entry: entry, fields: all})
+	}
+	return nil
+}
+
+// flush 按顺序将已缓存的日志和last写入out并刷新，已写入的日志不再重放
+func (c *bufferCore) flush(last bufferedEntry) error {
+	c.buf.mu.Lock()
+	entries := append(c.buf.entries, last)
+	c.buf.entries = nil
+	c.buf.mu.Unlock()
+	var err error
+	for _, e := range entries {
+		if writeErr := c.out.Write(e.entry, e.fields); err == nil {
+			err = writeErr
+		}
+	}
+	if syncErr := c.out.Sync(); err == nil {
+		err = syncErr
+	}
+	return err
+}
+
+func (c *bufferCore) Sync() error {
+	return nil
+}
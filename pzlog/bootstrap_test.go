@@ -0,0 +1,68 @@
+package pzlog
+
+import (
+	"bytes"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// resetBootstrap 恢复启动阶段的缓存状态，返回DPanic及以上级别日志的输出
+func resetBootstrap(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	out := &bytes.Buffer{}
+	bootstrapBuf.mu.Lock()
+	bootstrapBuf.entries = nil
+	bootstrapBuf.mu.Unlock()
+	bootstrapState.swap(&rootCore{core: newBufferCore(bootstrapBuf, zapcore.AddSync(out))})
+	bootstrapOnce = sync.Once{}
+	return out
+}
+
+func TestBootstrapReplay(t *testing.T) {
+	resetBootstrap(t)
+	early := Bootstrap().With(zap.String("phase", "init"))
+	early.Debug("loading config")
+	early.Info("config loaded", zap.Int("keys", 3))
+
+	config, out := newCapturedConfig()
+	config.LogLevel = "debug"
+	GetLogger(config)
+	early.Info("after init")
+
+	entries := out.Entries(t)
+	var msgs []string
+	for _, e := range entries {
+		msgs = append(msgs, e["msg"].(string))
+		if e["phase"] != "init" {
+			t.Errorf("entry %q lost its fields: %v", e["msg"], e)
+		}
+	}
+	if got := strings.Join(msgs, ","); got != "loading config,config loaded,after init" {
+		t.Errorf("messages = %s", got)
+	}
+}
+
+func TestBootstrapFatalNotBuffered(t *testing.T) {
+	stderr := resetBootstrap(t)
+	early := Bootstrap()
+	early.Info("starting")
+	early.DPanic("cannot continue")
+
+	out := stderr.String()
+	if !strings.Contains(out, "starting") || !strings.Contains(out, "cannot continue") {
+		t.Fatalf("entries not written before a possible exit: %q", out)
+	}
+	if strings.Index(out, "starting") > strings.Index(out, "cannot continue") {
+		t.Errorf("buffered entries should be written first: %q", out)
+	}
+
+	// 已经输出的日志不再重放
+	config, captured := newCapturedConfig()
+	GetLogger(config)
+	if lines := captured.Lines(); len(lines) != 0 {
+		t.Errorf("flushed entries replayed: %q", lines)
+	}
+}
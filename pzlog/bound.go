@@ -0,0 +1,86 @@
+package pzlog
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"reflect"
+	"strings"
+)
+
+// boundKey gin上下文中保存已绑定结构体的键
+const boundKey = "pzlog_bound"
+
+// SetBound 保存c.ShouldBindQuery等绑定得到的结构体，GinConfig.LogBound开启时由日志中间件记录为bound对象
+func SetBound(c *gin.Context, v interface{}) {
+	c.Set(boundKey, v)
+}
+
+// StructObject 将结构体的导出字段转换为日志对象，可用于zap.Object。
+// 字段名依次取log标签、form标签和字段名；log标签为"-"时跳过该字段，
+// 带有redact选项(例如`log:"token,redact"`)或字段名属于redactKeys(为空时使用默认的password、token等)时值被脱敏
+func StructObject(v interface{}, redactKeys []string) zapcore.ObjectMarshaler {
+	return structObject{v: v, redact: redactKeySet(redactKeys)}
+}
+
+type structObject struct {
+	v      interface{}
+	redact map[string]bool
+}
+
+func (s structObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	rv := reflect.ValueOf(s.v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, redact, ok := structFieldName(f)
+		if !ok {
+			continue
+		}
+		if redact || s.redact[strings.ToLower(name)] {
+			enc.AddString(name, redactedValue)
+			continue
+		}
+		zap.Any(name, rv.Field(i).Interface()).AddTo(enc)
+	}
+	return nil
+}
+
+// structFieldName 解析字段的日志名称及是否脱敏，字段被log:"-"排除时返回false
+func structFieldName(f reflect.StructField) (string, bool, bool) {
+	var name string
+	var redact bool
+	if tag, ok := f.Tag.Lookup("log"); ok {
+		if tag == "-" {
+			return "", false, false
+		}
+		parts := strings.Split(tag, ",")
+		name = parts[0]
+		for _, opt := range parts[1:] {
+			if opt == "redact" {
+				redact = true
+			}
+		}
+	}
+	if name == "" {
+		if form, _, _ := strings.Cut(f.Tag.Get("form"), ","); form != "" && form != "-" {
+			name = form
+		}
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return name, redact, true
+}
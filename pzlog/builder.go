@@ -0,0 +1,123 @@
+package pzlog
+
+import "time"
+
+// Builder 以链式调用的方式构造PzlogConfig
+//
+//	config, err := pzlog.NewBuilder().Level("debug").Encoder("console").File("/var/log/app.log").MaxSize(50).Build()
+type Builder struct {
+	config *PzlogConfig
+}
+
+// NewBuilder 创建Builder，未设置的配置项使用NewDefaultConfig中的默认值
+func NewBuilder() *Builder {
+	return &Builder{config: NewDefaultConfig()}
+}
+
+// Level 设置日志级别
+func (b *Builder) Level(level string) *Builder {
+	b.config.LogLevel = level
+	return b
+}
+
+// Encoder 设置日志编码格式
+func (b *Builder) Encoder(encoder string) *Builder {
+	b.config.Encoder = encoder
+	return b
+}
+
+// File 将日志输出到指定文件
+func (b *Builder) File(filename string) *Builder {
+	b.config.Output = "file"
+	b.config.Filename = filename
+	return b
+}
+
+// Output 设置日志输出位置
+func (b *Builder) Output(output string) *Builder {
+	b.config.Output = output
+	return b
+}
+
+// MaxSize 设置单个日志文件的最大大小(MB)
+func (b *Builder) MaxSize(megabytes int) *Builder {
+	b.config.MaxSize = megabytes
+	return b
+}
+
+// MaxBackups 设置保留的旧日志文件个数
+func (b *Builder) MaxBackups(n int) *Builder {
+	b.config.MaxBackups = n
+	return b
+}
+
+// MaxAge 设置旧日志文件保留的天数
+func (b *Builder) MaxAge(days int) *Builder {
+	b.config.MaxAge = days
+	return b
+}
+
+// Compress 设置是否压缩旧日志文件
+func (b *Builder) Compress(compress bool) *Builder {
+	b.config.Compress = compress
+	return b
+}
+
+// TimeFormat 设置时间格式
+func (b *Builder) TimeFormat(layout string) *Builder {
+	b.config.TimeFormat = layout
+	return b
+}
+
+// TimeZone 设置时间显示使用的时区
+func (b *Builder) TimeZone(name string) *Builder {
+	b.config.TimeZone = name
+	return b
+}
+
+// Service 设置服务名
+func (b *Builder) Service(service string) *Builder {
+	b.config.Service = service
+	return b
+}
+
+// PrintConsole 设置是否同时输出到控制台
+func (b *Builder) PrintConsole(enabled bool) *Builder {
+	b.config.PrintConsole = enabled
+	return b
+}
+
+// ReplaceGlobals 设置是否替换zap的全局Logger
+func (b *Builder) ReplaceGlobals(enabled bool) *Builder {
+	b.config.ReplaceGlobals = enabled
+	return b
+}
+
+// Sampling 设置采样配置
+func (b *Builder) Sampling(tick time.Duration, initial, thereafter int) *Builder {
+	b.config.Sampling = &SamplingConfig{Tick: tick, Initial: initial, Thereafter: thereafter}
+	return b
+}
+
+// Async 开启异步写入并设置缓冲区大小
+func (b *Builder) Async(bufferSize int) *Builder {
+	b.config.Async = true
+	b.config.AsyncBufferSize = bufferSize
+	return b
+}
+
+// Env 设置运行环境
+func (b *Builder) Env(env string) *Builder {
+	b.config.Env = env
+	return b
+}
+
+// Build 返回填充默认值并检查后的配置副本，之后对Builder的修改不影响已返回的配置，配置无效时返回错误
+func (b *Builder) Build() (*PzlogConfig, error) {
+	config := MergeConfig(b.config, nil)
+	setDefaultValue(config)
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
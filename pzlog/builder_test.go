@@ -0,0 +1,69 @@
+package pzlog
+
+import (
+	"gopkg.in/natefinch/lumberjack.v2"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	built, err := NewBuilder().Level("debug").Encoder("console").File("/var/log/app.log").MaxSize(50).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	literal := &PzlogConfig{
+		Logger: lumberjack.Logger{
+			Filename:   "/var/log/app.log",
+			MaxSize:    50,
+			MaxBackups: 10,
+			MaxAge:     30,
+		},
+		LogLevel: "debug",
+		Encoder:  "console",
+		Output:   "file",
+	}
+	setDefaultValue(literal)
+	if !reflect.DeepEqual(built, literal) {
+		t.Errorf("built config\n%+v\nwant\n%+v", built, literal)
+	}
+}
+
+func TestBuilderDefaults(t *testing.T) {
+	config, err := NewBuilder().Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Filename != "./logs/pzlog.log" || config.MaxSize != 100 || config.MaxBackups != 10 || config.MaxAge != 30 {
+		t.Errorf("defaults not applied: %q, %d, %d, %d", config.Filename, config.MaxSize, config.MaxBackups, config.MaxAge)
+	}
+}
+
+func TestBuilderBuildReturnsCopy(t *testing.T) {
+	b := NewBuilder().Level("warn")
+	first, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Level("debug").Encoder("console")
+	if first.LogLevel != "warn" || first.Encoder != "json" {
+		t.Errorf("later builder calls changed a built config: %q, %q", first.LogLevel, first.Encoder)
+	}
+	second, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second || second.LogLevel != "debug" {
+		t.Errorf("second Build = %p %q, first = %p", second, second.LogLevel, first)
+	}
+}
+
+func TestBuilderValidation(t *testing.T) {
+	_, err := NewBuilder().Output("none").PrintConsole(true).Build()
+	if err == nil || !strings.Contains(err.Error(), "printconsole") {
+		t.Errorf("err = %v, want printconsole conflict", err)
+	}
+	if _, err := NewBuilder().Encoder("xml").Build(); err == nil {
+		t.Error("expected error for unknown encoder")
+	}
+}
@@ -0,0 +1,28 @@
+package pzlog
+
+import "go.uber.org/zap/zapcore"
+
+// callbackWriteSyncer 将每条编码后的日志交给回调函数处理
+type callbackWriteSyncer struct {
+	fn func([]byte)
+}
+
+// NewCallbackWriteSyncer 创建由回调函数驱动的WriteSyncer，回调收到的是完整编码的单条日志(含换行)的副本
+func NewCallbackWriteSyncer(fn func([]byte)) zapcore.WriteSyncer {
+	return &callbackWriteSyncer{fn: fn}
+}
+
+func (w *callbackWriteSyncer) Write(p []byte) (int, error) {
+	// OnEncodeError为drop时丢弃的日志编码为空，不交给回调
+	if len(p) == 0 {
+		return 0, nil
+	}
+	line := make([]byte, len(p))
+	copy(line, p)
+	w.fn(line)
+	return len(p), nil
+}
+
+func (w *callbackWriteSyncer) Sync() error {
+	return nil
+}
@@ -0,0 +1,38 @@
+package pzlog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCallbackOutput(t *testing.T) {
+	config, out := newCapturedConfig()
+	logger := GetLogger(config)
+	logger.Info("first")
+	logger.Warn("second")
+	lines := out.Lines()
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	for i, msg := range []string{"first", "second"} {
+		if !strings.Contains(lines[i], `"msg":"`+msg+`"`) {
+			t.Errorf("line %d = %q, want msg %s", i, lines[i], msg)
+		}
+	}
+}
+
+func TestCallbackWriteSyncerCopies(t *testing.T) {
+	var got [][]byte
+	ws := NewCallbackWriteSyncer(func(line []byte) { got = append(got, line) })
+	buf := []byte("line\n")
+	if n, err := ws.Write(buf); n != len(buf) || err != nil {
+		t.Fatalf("Write = %d, %v", n, err)
+	}
+	buf[0] = 'X'
+	if string(got[0]) != "line\n" {
+		t.Errorf("callback received %q, want a copy of the line", got[0])
+	}
+	if err := ws.Sync(); err != nil {
+		t.Error(err)
+	}
+}
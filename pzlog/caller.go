@@ -0,0 +1,117 @@
+package pzlog
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// CallerMarkerKey CallerOnDemand开启时，带有该字段(值为true)的日志才记录调用位置
+const CallerMarkerKey = "with_caller"
+
+// WithCaller 返回要求记录调用位置的标记字段，标记字段本身不会输出
+func WithCaller() zap.Field {
+	return zap.Bool(CallerMarkerKey, true)
+}
+
+// pzlogPkgPrefix 本包函数名的前缀，查找调用位置时跳过
+var pzlogPkgPrefix = reflect.TypeOf(swapCore{}).PkgPath() + "."
+
+// markerCallerCore 只为带有标记字段的日志查找调用位置，避免每条日志都查找调用栈
+type markerCallerCore struct {
+	zapcore.Core
+	// marked With中添加了标记字段
+	marked bool
+}
+
+func (c *markerCallerCore) With(fields []zapcore.Field) zapcore.Core {
+	marked, fields := takeCallerMarker(fields)
+	return &markerCallerCore{Core: c.Core.With(fields), marked: c.marked || marked}
+}
+
+func (c *markerCallerCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *markerCallerCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	marked, fields := takeCallerMarker(fields)
+	if (marked || c.marked) && !entry.Caller.Defined {
+		entry.Caller = lookupCaller()
+	}
+	return c.Core.Write(entry, fields)
+}
+
+// takeCallerMarker 判断字段中是否有值为true的标记字段，并返回去掉标记字段后的字段
+func takeCallerMarker(fields []zapcore.Field) (bool, []zapcore.Field) {
+	idx := -1
+	for i, f := range fields {
+		if f.Key == CallerMarkerKey && f.Type == zapcore.BoolType {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return false, fields
+	}
+	marked := fields[idx].Integer == 1
+	rest := make([]zapcore.Field, 0, len(fields)-1)
+	rest = append(rest, fields[:idx]...)
+	rest = append(rest, fields[idx+1:]...)
+	return marked, rest
+}
+
+// lookupCaller 返回调用栈中第一个不属于zap和pzlog的调用位置
+func lookupCaller() zapcore.EntryCaller {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "go.uber.org/zap") && !strings.HasPrefix(frame.Function, pzlogPkgPrefix) {
+			return zapcore.EntryCaller{
+				Defined:  true,
+				PC:       frame.PC,
+				File:     frame.File,
+				Line:     frame.Line,
+				Function: frame.Function,
+			}
+		}
+		if !more {
+			return zapcore.EntryCaller{}
+		}
+	}
+}
+
+// callerFilterCore 去掉来自指定包的日志的调用位置，函数全名(例如github.com/foo/bar.Func)或源文件路径以任一前缀开头时去掉
+type callerFilterCore struct {
+	zapcore.Core
+	prefixes []string
+}
+
+func (c *callerFilterCore) With(fields []zapcore.Field) zapcore.Core {
+	return &callerFilterCore{Core: c.Core.With(fields), prefixes: c.prefixes}
+}
+
+func (c *callerFilterCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *callerFilterCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if entry.Caller.Defined {
+		for _, prefix := range c.prefixes {
+			if strings.HasPrefix(entry.Caller.Function, prefix) || strings.HasPrefix(entry.Caller.File, prefix) {
+				entry.Caller = zapcore.EntryCaller{}
+				break
+			}
+		}
+	}
+	return c.Core.Write(entry, fields)
+}
@@ -0,0 +1,79 @@
+package pzlog_test
+
+import (
+	"encoding/json"
+	"github.com/Gentleelephant/pzlog/pzlog"
+	"go.uber.org/zap"
+	"strings"
+	"testing"
+)
+
+// 调用位置查找会跳过pzlog包内的函数，因此在外部测试包中从包外调用日志
+func TestCallerOnDemand(t *testing.T) {
+	var lines [][]byte
+	config := pzlog.NewDefaultConfig()
+	config.Output = "callback"
+	config.Callback = func(line []byte) { lines = append(lines, append([]byte(nil), line...)) }
+	config.CallerOnDemand = true
+	logger := pzlog.GetLogger(config)
+	logger.Info("plain")
+	logger.Info("marked", pzlog.WithCaller())
+	logger.With(pzlog.WithCaller()).Info("marked logger")
+
+	if len(lines) != 3 {
+		t.Fatalf("got %d entries, want 3", len(lines))
+	}
+	var entries []map[string]interface{}
+	for _, line := range lines {
+		var e map[string]interface{}
+		if err := json.Unmarshal(line, &e); err != nil {
+			t.Fatalf("invalid json %q: %v", line, err)
+		}
+		entries = append(entries, e)
+	}
+	if c, ok := entries[0]["caller_line"]; ok {
+		t.Errorf("plain entry caller_line = %v, want none", c)
+	}
+	for _, e := range entries[1:] {
+		if c, _ := e["caller_line"].(string); !strings.HasPrefix(c, "pzlog/caller_test.go:") {
+			t.Errorf("%s: caller_line = %q, want the call site", e["msg"], c)
+		}
+		if _, ok := e[pzlog.CallerMarkerKey]; ok {
+			t.Errorf("%s: marker field logged", e["msg"])
+		}
+	}
+}
+
+// noisyLog 模拟来自噪声包的调用位置
+func noisyLog(logger interface{ Info(string, ...zap.Field) }) {
+	logger.Info("noisy")
+}
+
+func TestSuppressCallerPrefixes(t *testing.T) {
+	var lines [][]byte
+	config := pzlog.NewDefaultConfig()
+	config.Output = "callback"
+	config.Callback = func(line []byte) { lines = append(lines, append([]byte(nil), line...)) }
+	config.SuppressCallerPrefixes = []string{"github.com/Gentleelephant/pzlog/pzlog_test.noisy"}
+	logger := pzlog.GetLogger(config)
+	noisyLog(logger)
+	logger.Info("normal")
+
+	if len(lines) != 2 {
+		t.Fatalf("got %d entries, want 2", len(lines))
+	}
+	entries := map[string]map[string]interface{}{}
+	for _, line := range lines {
+		var e map[string]interface{}
+		if err := json.Unmarshal(line, &e); err != nil {
+			t.Fatalf("invalid json %q: %v", line, err)
+		}
+		entries[e["msg"].(string)] = e
+	}
+	if c, ok := entries["noisy"]["caller_line"]; ok {
+		t.Errorf("noisy entry caller_line = %v, want none", c)
+	}
+	if c, _ := entries["normal"]["caller_line"].(string); !strings.HasPrefix(c, "pzlog/caller_test.go:") {
+		t.Errorf("normal entry caller_line = %q, want the call site", c)
+	}
+}
@@ -0,0 +1,30 @@
+package pzlog
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"os"
+)
+
+// NewCLILogger 创建适用于命令行工具的Logger，以console格式输出到stderr，不写文件也不记录调用位置
+func NewCLILogger(level string) *zap.Logger {
+	return newCLILogger(zapcore.Lock(os.Stderr), level)
+}
+
+func newCLILogger(ws zapcore.WriteSyncer, level string) *zap.Logger {
+	lvl, _ := parseLevel(level)
+	encoder := zapcore.NewConsoleEncoder(zapcore.EncoderConfig{
+		TimeKey:        "ts",
+		LevelKey:       "level",
+		NameKey:        "logger",
+		CallerKey:      zapcore.OmitKey,
+		FunctionKey:    zapcore.OmitKey,
+		MessageKey:     "msg",
+		StacktraceKey:  "stacktrace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    cEncodeLevel,
+		EncodeTime:     cEncodeTime,
+		EncodeDuration: zapcore.StringDurationEncoder,
+	})
+	return zap.New(zapcore.NewCore(encoder, ws, lvl))
+}
@@ -0,0 +1,45 @@
+package pzlog
+
+import (
+	"bytes"
+	"go.uber.org/zap/zapcore"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCLILogger(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	var buf bytes.Buffer
+	logger := newCLILogger(zapcore.AddSync(&buf), "warn")
+	logger.Info("hidden")
+	logger.Warn("disk almost full")
+	_ = logger.Sync()
+
+	out := buf.String()
+	if strings.Contains(out, "hidden") {
+		t.Errorf("info entry written at warn level: %q", out)
+	}
+	if !strings.Contains(out, "disk almost full") || !strings.Contains(out, "WARN") {
+		t.Errorf("unexpected output %q", out)
+	}
+	if strings.Contains(out, "cli_test.go") {
+		t.Errorf("caller should not be logged: %q", out)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("CLI logger created files: %v", entries)
+	}
+}
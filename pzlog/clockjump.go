@@ -0,0 +1,73 @@
+package pzlog
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"sync"
+	"time"
+)
+
+// clockJumpCore 比较相邻两次写入日志时的系统时间间隔与单调时钟间隔，差值超过阈值时说明系统时间发生了跳变(例如虚拟机时钟调整)，
+// 在日志中追加clock_jump和clock_jump_ms字段。比较的是写入时读取的系统时间而不是日志时间，LogAt等指定时间的日志不会被误报
+type clockJumpCore struct {
+	zapcore.Core
+	state *clockJumpState
+}
+
+// clockJumpState 同一个core及其With派生的core共享的上一条日志的时间
+type clockJumpState struct {
+	mu        sync.Mutex
+	threshold time.Duration
+	// wall 返回系统时间
+	wall func() time.Time
+	// mono 返回单调时钟读数
+	mono     func() time.Duration
+	lastWall int64
+	lastMono time.Duration
+}
+
+func newClockJumpCore(core zapcore.Core, threshold time.Duration, wall func() time.Time, mono func() time.Duration) zapcore.Core {
+	return &clockJumpCore{Core: core, state: &clockJumpState{threshold: threshold, wall: wall, mono: mono}}
+}
+
+// processMono 返回包初始化以来的单调时钟读数
+func processMono() time.Duration {
+	return time.Since(processStart)
+}
+
+func (c *clockJumpCore) With(fields []zapcore.Field) zapcore.Core {
+	return &clockJumpCore{Core: c.Core.With(fields), state: c.state}
+}
+
+func (c *clockJumpCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *clockJumpCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	jump, ok := c.state.observe()
+	if !ok {
+		return c.Core.Write(entry, fields)
+	}
+	all := make([]zapcore.Field, 0, len(fields)+2)
+	all = append(all, fields...)
+	all = append(all, zap.Bool("clock_jump", true), zap.Float64("clock_jump_ms", durationMs(jump)))
+	return c.Core.Write(entry, all)
+}
+
+// observe 读取当前的系统时间和单调时钟，返回与上一次写入相比系统时间相对单调时钟的跳变量，未超过阈值时ok为false
+func (s *clockJumpState) observe() (jump time.Duration, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wall := s.wall().UnixNano()
+	mono := s.mono()
+	if s.lastWall != 0 {
+		jump = time.Duration(wall-s.lastWall) - (mono - s.lastMono)
+		ok = jump > s.threshold || jump < -s.threshold
+	}
+	s.lastWall = wall
+	s.lastMono = mono
+	return jump, ok
+}
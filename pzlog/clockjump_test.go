@@ -0,0 +1,47 @@
+package pzlog
+
+import (
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"testing"
+	"time"
+)
+
+func TestClockJump(t *testing.T) {
+	wall := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	var mono time.Duration
+	inner, logs := observer.New(zapcore.DebugLevel)
+	core := newClockJumpCore(inner, time.Second, wall.Now, func() time.Duration { return mono })
+	write := func(msg string, entryTime time.Time) {
+		t.Helper()
+		if err := core.Write(zapcore.Entry{Level: zapcore.InfoLevel, Message: msg, Time: entryTime}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	step := func(wallStep, monoStep time.Duration) {
+		wall.Add(wallStep)
+		mono += monoStep
+	}
+
+	write("first", wall.Now())
+	step(time.Minute, time.Minute)
+	write("steady", wall.Now())
+	step(time.Hour+time.Second, time.Second)
+	write("jumped", wall.Now())
+	step(time.Second, time.Second)
+	// 日志时间是历史时间(例如LogAt)，但写入时系统时间没有跳变
+	write("historical", wall.Now().Add(-24*time.Hour))
+
+	want := map[string]float64{"jumped": float64(time.Hour / time.Millisecond)}
+	for _, e := range logs.All() {
+		fields := e.ContextMap()
+		jump, ok := want[e.Message]
+		if _, flagged := fields["clock_jump"]; flagged != ok {
+			t.Errorf("%s: clock_jump present = %v, want %v", e.Message, flagged, ok)
+			continue
+		}
+		if ok && fields["clock_jump_ms"] != jump {
+			t.Errorf("%s: clock_jump_ms = %v, want %v", e.Message, fields["clock_jump_ms"], jump)
+		}
+	}
+}
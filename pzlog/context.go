@@ -0,0 +1,99 @@
+package pzlog
+
+import (
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"sync/atomic"
+)
+
+const defaultTraceIDField = "trace_id"
+
+// traceIDKey 配置的跟踪ID context键及字段名
+type traceIDKey struct {
+	key   interface{}
+	field string
+}
+
+// currentTraceIDKey 最近一次GetLogger配置的跟踪ID context键，未配置时为nil
+var currentTraceIDKey atomic.Pointer[traceIDKey]
+
+type loggerKey struct{}
+
+type labelsKey struct{}
+
+// WithLogger 将Logger保存到context，FromContext会返回该Logger
+func WithLogger(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// WithLabel 在context中添加标签，通过FromContext获取的Logger会带上context中的所有标签，
+// 例如为每个worker的日志添加worker_id而无需传递Logger
+func WithLabel(ctx context.Context, key string, val interface{}) context.Context {
+	old, _ := ctx.Value(labelsKey{}).([]zap.Field)
+	labels := make([]zap.Field, 0, len(old)+1)
+	labels = append(labels, old...)
+	labels = append(labels, zap.Any(key, val))
+	return context.WithValue(ctx, labelsKey{}, labels)
+}
+
+// FromContext 返回context中保存的Logger，没有时返回zap.L()，并附加context中的标签、跟踪ID、baggage成员和Datadog关联字段
+func FromContext(ctx context.Context) *zap.Logger {
+	logger, ok := ctx.Value(loggerKey{}).(*zap.Logger)
+	if !ok || logger == nil {
+		logger = zap.L()
+	}
+	labels, _ := ctx.Value(labelsKey{}).([]zap.Field)
+	if f, ok := traceIDField(ctx); ok {
+		labels = append(labels[:len(labels):len(labels)], f)
+	}
+	if fs := baggageFields(ctx); len(fs) > 0 {
+		labels = append(labels[:len(labels):len(labels)], fs...)
+	}
+	if fs := datadogFields(ctx); len(fs) > 0 {
+		labels = append(labels[:len(labels):len(labels)], fs...)
+	}
+	if len(labels) > 0 {
+		logger = logger.With(labels...)
+	}
+	return logger
+}
+
+// setTraceIDKey 设置FromContext读取跟踪ID的context键，key为nil时不读取
+func setTraceIDKey(key interface{}, field string) {
+	if key == nil {
+		currentTraceIDKey.Store(nil)
+		return
+	}
+	if field == "" {
+		field = defaultTraceIDField
+	}
+	currentTraceIDKey.Store(&traceIDKey{key: key, field: field})
+}
+
+// traceIDField 返回context中的跟踪ID字段，未配置键或context中没有跟踪ID时返回false
+func traceIDField(ctx context.Context) (zap.Field, bool) {
+	k := currentTraceIDKey.Load()
+	if k == nil {
+		return zap.Field{}, false
+	}
+	switch v := ctx.Value(k.key).(type) {
+	case nil:
+		return zap.Field{}, false
+	case string:
+		if v == "" {
+			return zap.Field{}, false
+		}
+		return zap.String(k.field, v), true
+	case fmt.Stringer:
+		return zap.Stringer(k.field, v), true
+	default:
+		return zap.Any(k.field, v), true
+	}
+}
+
+// FromGinContext 返回gin请求context对应的Logger，见FromContext
+func FromGinContext(c *gin.Context) *zap.Logger {
+	return FromContext(c.Request.Context())
+}
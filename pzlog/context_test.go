@@ -0,0 +1,178 @@
+package pzlog
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithLabel(t *testing.T) {
+	logs := observeGlobals(t, zapcore.InfoLevel)
+	ctx := WithLabel(context.Background(), "job", "import")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			FromContext(WithLabel(ctx, "worker_id", id)).Info("working")
+		}(i)
+	}
+	wg.Wait()
+	FromContext(ctx).Info("parent")
+
+	seen := map[int64]bool{}
+	for _, e := range logs.FilterMessage("working").All() {
+		m := e.ContextMap()
+		if m["job"] != "import" {
+			t.Errorf("worker entry missing parent label: %v", m)
+		}
+		seen[m["worker_id"].(int64)] = true
+	}
+	if len(seen) != 3 {
+		t.Errorf("worker ids = %v, want 3 distinct", seen)
+	}
+	parent := logs.FilterMessage("parent").All()
+	if len(parent) != 1 || parent[0].ContextMap()["worker_id"] != nil {
+		t.Errorf("worker label leaked into the parent context: %v", parent)
+	}
+}
+
+func TestFromContextLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	ctx := WithLogger(context.Background(), zap.New(core))
+	ctx = WithLabel(ctx, "worker_id", 7)
+	FromContext(ctx).Info("custom")
+
+	entries := logs.All()
+	if len(entries) != 1 || entries[0].ContextMap()["worker_id"] != int64(7) {
+		t.Errorf("entries = %v, want one entry with worker_id", entries)
+	}
+}
+
+type requestIDKey struct{}
+
+type spanID int
+
+func (s spanID) String() string { return fmt.Sprintf("span-%d", int(s)) }
+
+func TestTraceIDContextKey(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.ReplaceGlobals = true
+	config.TraceIDContextKey = requestIDKey{}
+	config.TraceIDField = "request_id"
+	prev := zap.L()
+	defer zap.ReplaceGlobals(prev)
+	GetLogger(config)
+	defer setTraceIDKey(nil, "")
+
+	FromContext(context.WithValue(context.Background(), requestIDKey{}, "r-1")).Info("string id")
+	FromContext(context.WithValue(context.Background(), requestIDKey{}, spanID(7))).Info("stringer id")
+	FromContext(context.WithValue(context.Background(), requestIDKey{}, "")).Info("empty id")
+	FromContext(context.Background()).Info("no id")
+
+	want := map[string]interface{}{"string id": "r-1", "stringer id": "span-7", "empty id": nil, "no id": nil}
+	for _, e := range out.Entries(t) {
+		msg := e["msg"].(string)
+		if e["request_id"] != want[msg] {
+			t.Errorf("%s: request_id = %v, want %v", msg, e["request_id"], want[msg])
+		}
+		if _, ok := e["trace_id"]; ok {
+			t.Errorf("%s: default field name used", msg)
+		}
+	}
+}
+
+func TestTraceIDContextKeyKeptByOtherLoggers(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.ReplaceGlobals = true
+	config.TraceIDContextKey = requestIDKey{}
+	prev := zap.L()
+	defer zap.ReplaceGlobals(prev)
+	GetLogger(config)
+	defer setTraceIDKey(nil, "")
+
+	// 为其他组件创建的Logger不替换全局设置
+	other := NewDefaultConfig()
+	other.Output = "none"
+	GetLogger(other)
+
+	FromContext(context.WithValue(context.Background(), requestIDKey{}, "r-1")).Info("traced")
+	entries := out.Entries(t)
+	if got := entries[len(entries)-1]["trace_id"]; got != "r-1" {
+		t.Errorf("trace_id = %v, want r-1", got)
+	}
+}
+
+// baggageKey 测试用的baggage在context中的键
+type baggageKey struct{}
+
+func TestBaggageKeys(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.ReplaceGlobals = true
+	config.BaggageKeys = []string{"tenant", "region"}
+	config.Baggage = func(ctx context.Context, key string) string {
+		members, _ := ctx.Value(baggageKey{}).(map[string]string)
+		return members[key]
+	}
+	prev := zap.L()
+	defer zap.ReplaceGlobals(prev)
+	GetLogger(config)
+	defer setBaggage(nil, nil)
+
+	ctx := context.WithValue(context.Background(), baggageKey{}, map[string]string{"tenant": "acme", "plan": "pro"})
+	FromContext(ctx).Info("with baggage")
+	FromContext(context.Background()).Info("without baggage")
+
+	entries := out.Entries(t)
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if entries[0]["tenant"] != "acme" {
+		t.Errorf("tenant = %v, want acme", entries[0]["tenant"])
+	}
+	for _, key := range []string{"region", "plan"} {
+		if _, ok := entries[0][key]; ok {
+			t.Errorf("%s logged, want only configured non-empty members", key)
+		}
+	}
+	if _, ok := entries[1]["tenant"]; ok {
+		t.Error("tenant logged without baggage")
+	}
+}
+
+func TestFromContextDeadline(t *testing.T) {
+	logs := observeGlobals(t, zapcore.DebugLevel)
+	near, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	far, cancelFar := context.WithTimeout(context.Background(), time.Hour)
+	defer cancelFar()
+
+	logger := FromContextDeadline(near, time.Second)
+	logger.Debug("near debug")
+	logger.Info("near info")
+	FromContextDeadline(far, time.Second).Debug("far debug")
+	FromContextDeadline(context.Background(), time.Second).Debug("no deadline")
+
+	entries := logs.All()
+	var msgs []string
+	for _, e := range entries {
+		msgs = append(msgs, e.Message)
+	}
+	if want := []string{"near info", "far debug", "no deadline"}; fmt.Sprint(msgs) != fmt.Sprint(want) {
+		t.Fatalf("logged %v, want %v", msgs, want)
+	}
+	if v := entries[0].ContextMap()["near_deadline"]; v != true {
+		t.Errorf("near info near_deadline = %v, want true", v)
+	}
+	for _, e := range entries[1:] {
+		if _, ok := e.ContextMap()["near_deadline"]; ok {
+			t.Errorf("%s: near_deadline logged far from the deadline", e.Message)
+		}
+	}
+}
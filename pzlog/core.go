@@ -0,0 +1,170 @@
+package pzlog
+
+import (
+	"fmt"
+	"go.uber.org/zap/zapcore"
+	"time"
+)
+
+// syncOnLevelCore 在写入不低于指定级别的日志后立即刷新输出
+type syncOnLevelCore struct {
+	zapcore.Core
+	level zapcore.Level
+}
+
+func newSyncOnLevelCore(core zapcore.Core, level zapcore.Level) zapcore.Core {
+	return &syncOnLevelCore{Core: core, level: level}
+}
+
+func (c *syncOnLevelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &syncOnLevelCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *syncOnLevelCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *syncOnLevelCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if err := c.Core.Write(entry, fields); err != nil {
+		return err
+	}
+	if entry.Level >= c.level {
+		return c.Core.Sync()
+	}
+	return nil
+}
+
+// dedupCore 写入时合并With添加的字段和调用时传入的字段，同名字段只保留最后一个值，
+// 使调用时传入的字段可以覆盖Logger上的默认字段。With添加的字段在每次写入时重新编码。
+type dedupCore struct {
+	zapcore.Core
+	fields []zapcore.Field
+}
+
+func newDedupCore(core zapcore.Core) zapcore.Core {
+	return &dedupCore{Core: core}
+}
+
+func (c *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+	all = append(all, fields...)
+	return &dedupCore{Core: c.Core, fields: all}
+}
+
+func (c *dedupCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *dedupCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+	all = append(all, fields...)
+	return c.Core.Write(entry, dedupFields(all))
+}
+
+// dedupFields 同名字段只保留最后一个值，位置取第一次出现的位置。
+// 命名空间之后的字段属于嵌套对象，不参与去重。
+func dedupFields(fields []zapcore.Field) []zapcore.Field {
+	end := len(fields)
+	for i, f := range fields {
+		if f.Type == zapcore.NamespaceType {
+			end = i
+			break
+		}
+	}
+	index := make(map[string]int, end)
+	out := make([]zapcore.Field, 0, len(fields))
+	for _, f := range fields[:end] {
+		if i, ok := index[f.Key]; ok {
+			out[i] = f
+			continue
+		}
+		index[f.Key] = len(out)
+		out = append(out, f)
+	}
+	return append(out, fields[end:]...)
+}
+
+// panicSafeCore 捕获编码和写入过程中的panic(例如自定义的MarshalLogObject发生panic)，
+// 向errOut输出一条简短的替代日志后继续运行
+type panicSafeCore struct {
+	zapcore.Core
+	errOut zapcore.WriteSyncer
+}
+
+func newPanicSafeCore(core zapcore.Core, errOut zapcore.WriteSyncer) zapcore.Core {
+	return &panicSafeCore{Core: core, errOut: errOut}
+}
+
+func (c *panicSafeCore) With(fields []zapcore.Field) (core zapcore.Core) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.fallback(time.Now(), "pzlog: recovered panic while adding fields", r)
+			core = c
+		}
+	}()
+	return &panicSafeCore{Core: c.Core.With(fields), errOut: c.errOut}
+}
+
+func (c *panicSafeCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *panicSafeCore) Write(entry zapcore.Entry, fields []zapcore.Field) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			// 已经输出了替代日志，不再返回错误，避免zap重复输出
+			c.fallback(entry.Time, entry.Message, r)
+			err = nil
+		}
+	}()
+	return c.Core.Write(entry, fields)
+}
+
+// fallback 输出替代日志
+func (c *panicSafeCore) fallback(t time.Time, msg string, r interface{}) {
+	fmt.Fprintf(c.errOut, "%s\tERROR\tpzlog: recovered panic while encoding log entry: %v, msg: %q\n", t.Format(logTmFmt), r, msg)
+	_ = c.errOut.Sync()
+}
+
+// fieldHookCore 写入时根据日志追加由fn计算的字段
+type fieldHookCore struct {
+	zapcore.Core
+	fn func(zapcore.Entry) []zapcore.Field
+}
+
+func newFieldHookCore(core zapcore.Core, fn func(zapcore.Entry) []zapcore.Field) zapcore.Core {
+	return &fieldHookCore{Core: core, fn: fn}
+}
+
+func (c *fieldHookCore) With(fields []zapcore.Field) zapcore.Core {
+	return &fieldHookCore{Core: c.Core.With(fields), fn: c.fn}
+}
+
+func (c *fieldHookCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *fieldHookCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	extra := c.fn(entry)
+	if len(extra) == 0 {
+		return c.Core.Write(entry, fields)
+	}
+	all := make([]zapcore.Field, 0, len(fields)+len(extra))
+	all = append(all, fields...)
+	all = append(all, extra...)
+	return c.Core.Write(entry, all)
+}
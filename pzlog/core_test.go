@@ -0,0 +1,183 @@
+package pzlog
+
+import (
+	"bytes"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// syncCounter 记录写入内容和Sync调用次数
+type syncCounter struct {
+	bytes.Buffer
+	syncs int
+}
+
+func (s *syncCounter) Sync() error {
+	s.syncs++
+	return nil
+}
+
+func TestSyncOnLevel(t *testing.T) {
+	ws := &syncCounter{}
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), ws, zapcore.DebugLevel)
+	logger := zap.New(newSyncOnLevelCore(core, zapcore.ErrorLevel))
+
+	logger.Info("info")
+	if ws.syncs != 0 {
+		t.Errorf("sync after info entry, syncs = %d", ws.syncs)
+	}
+	logger.Error("error")
+	if ws.syncs != 1 {
+		t.Errorf("syncs after error entry = %d, want 1", ws.syncs)
+	}
+	logger.With(zap.String("k", "v")).Warn("warn")
+	if ws.syncs != 1 {
+		t.Errorf("sync after warn entry, syncs = %d", ws.syncs)
+	}
+}
+
+func TestSyncOnLevelInvalid(t *testing.T) {
+	config := NewDefaultConfig()
+	config.Output = "none"
+	config.SyncOnLevel = "loud"
+	if _, err := GetLoggerE(config); err == nil {
+		t.Error("expected error for unknown synconlevel")
+	}
+}
+
+func TestDedupFields(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.DedupFields = true
+	logger := GetLogger(config).With(zap.String("env", "prod"), zap.String("region", "eu"))
+	logger.Info("override", zap.String("env", "staging"))
+	logger.Info("default")
+
+	lines := out.Lines()
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	if strings.Count(lines[0], `"env"`) != 1 || !strings.Contains(lines[0], `"env":"staging"`) {
+		t.Errorf("override not applied exactly once: %s", lines[0])
+	}
+	if !strings.Contains(lines[0], `"region":"eu"`) {
+		t.Errorf("default field lost: %s", lines[0])
+	}
+	if !strings.Contains(lines[1], `"env":"prod"`) {
+		t.Errorf("default value missing: %s", lines[1])
+	}
+}
+
+func TestDedupFieldsNamespace(t *testing.T) {
+	fields := dedupFields([]zapcore.Field{
+		zap.String("a", "1"),
+		zap.String("b", "2"),
+		zap.String("a", "3"),
+		zap.Namespace("ns"),
+		zap.String("a", "4"),
+	})
+	want := []string{"a=3", "b=2", "ns=", "a=4"}
+	if len(fields) != len(want) {
+		t.Fatalf("got %d fields, want %d", len(fields), len(want))
+	}
+	for i, f := range fields {
+		if got := f.Key + "=" + f.String; got != want[i] {
+			t.Errorf("field %d = %s, want %s", i, got, want[i])
+		}
+	}
+}
+
+// panicMarshaler MarshalLogObject时panic
+type panicMarshaler struct{}
+
+func (panicMarshaler) MarshalLogObject(zapcore.ObjectEncoder) error {
+	panic("broken marshaler")
+}
+
+func TestPanicSafeCore(t *testing.T) {
+	var out, errOut bytes.Buffer
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(&out), zapcore.DebugLevel)
+	logger := zap.New(newPanicSafeCore(core, zapcore.AddSync(&errOut)))
+
+	logger.Info("bad field", zap.Object("obj", panicMarshaler{}))
+	if !strings.Contains(errOut.String(), "broken marshaler") || !strings.Contains(errOut.String(), `"bad field"`) {
+		t.Errorf("fallback = %q", errOut.String())
+	}
+
+	errOut.Reset()
+	child := logger.With(zap.Object("obj", panicMarshaler{}))
+	if !strings.Contains(errOut.String(), "adding fields") {
+		t.Errorf("fallback for With = %q", errOut.String())
+	}
+	child.Info("still works")
+	if !strings.Contains(out.String(), "still works") {
+		t.Errorf("logger unusable after recovered panic: %q", out.String())
+	}
+}
+
+func TestUptime(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.Uptime = true
+	logger := GetLogger(config)
+	logger.Info("first")
+	time.Sleep(5 * time.Millisecond)
+	// 日志时间早于上一条(模拟系统时间回拨)不影响uptime_ms
+	if ce := logger.Check(zapcore.InfoLevel, "second"); ce != nil {
+		ce.Time = time.Now().Add(-time.Hour)
+		ce.Write()
+	}
+
+	entries := out.Entries(t)
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	first, _ := entries[0]["uptime_ms"].(float64)
+	second, _ := entries[1]["uptime_ms"].(float64)
+	if first < 0 || second < first+5 {
+		t.Errorf("uptime_ms = %v, %v, want increasing by at least 5", entries[0]["uptime_ms"], entries[1]["uptime_ms"])
+	}
+}
+
+func TestRuntimeInfo(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.RuntimeInfo = true
+	GetLogger(config).Info("runtime")
+
+	entries := out.Entries(t)
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	for key, want := range map[string]string{"go_version": runtime.Version(), "goos": runtime.GOOS, "goarch": runtime.GOARCH} {
+		if got := entries[0][key]; got != want {
+			t.Errorf("%s = %v, want %s", key, got, want)
+		}
+	}
+
+	config, out = newCapturedConfig()
+	GetLogger(config).Info("plain")
+	if _, ok := out.Entries(t)[0]["go_version"]; ok {
+		t.Error("go_version present with RuntimeInfo disabled")
+	}
+}
+
+func TestFormatVersion(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.FormatVersion = 3
+	GetLogger(config).With(zap.String("k", "v")).Info("versioned")
+	entries := out.Entries(t)
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	if v := entries[0]["log_format_version"]; v != 3.0 {
+		t.Errorf("log_format_version = %v, want 3", v)
+	}
+
+	config, out = newCapturedConfig()
+	GetLogger(config).Info("plain")
+	if _, ok := out.Entries(t)[0]["log_format_version"]; ok {
+		t.Error("log_format_version present with FormatVersion 0")
+	}
+}
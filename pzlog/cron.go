@@ -0,0 +1,166 @@
+package pzlog
+
+import (
+	"fmt"
+	"go.uber.org/zap/zapcore"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cronSchedule 解析后的cron表达式(分 时 日 月 周)，每个字段为允许取值的位集合
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// domStar、dowStar 日和周字段是否为*，两者都不是*时满足其一即可，与标准cron一致
+	domStar, dowStar bool
+}
+
+// cronMacros 常用的cron表达式缩写
+var cronMacros = map[string]string{
+	"@yearly":  "0 0 1 1 *",
+	"@monthly": "0 0 1 * *",
+	"@weekly":  "0 0 * * 0",
+	"@daily":   "0 0 * * *",
+	"@hourly":  "0 * * * *",
+}
+
+// parseCron 解析标准的5字段cron表达式，支持*、逗号分隔的列表、a-b范围、/n步长，周字段0和7均表示周日，
+// 以及@yearly、@monthly、@weekly、@daily、@hourly
+func parseCron(spec string) (*cronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if macro, ok := cronMacros[spec]; ok {
+		spec = macro
+	}
+	parts := strings.Fields(spec)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, got %d", len(parts))
+	}
+	s := &cronSchedule{}
+	var err error
+	if s.minute, err = parseCronField(parts[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if s.hour, err = parseCronField(parts[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if s.dom, err = parseCronField(parts[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if s.month, err = parseCronField(parts[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if s.dow, err = parseCronField(parts[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domStar = parts[2] == "*" || strings.HasPrefix(parts[2], "*/")
+	s.dowStar = parts[4] == "*" || strings.HasPrefix(parts[4], "*/")
+	return s, nil
+}
+
+// parseCronField 解析cron表达式的一个字段
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepText)
+			}
+			step = n
+		}
+		lo, hi := min, max
+		if rng != "*" {
+			loText, hiText, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = strconv.Atoi(loText); err != nil {
+				return 0, fmt.Errorf("invalid value %q", loText)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(hiText); err != nil {
+					return 0, fmt.Errorf("invalid value %q", hiText)
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// next 返回t之后第一个满足表达式的时间(精确到分钟)，5年内没有满足的时间时返回零值
+func (s *cronSchedule) next(t time.Time) time.Time {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// cronRotateSyncer 按cron表达式切割lumberjack日志文件。在到达计划时间后的第一次写入前切割，没有日志写入时不会切割
+type cronRotateSyncer struct {
+	zapcore.WriteSyncer
+	logger   rotatingFile
+	schedule *cronSchedule
+	now      func() time.Time
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func newCronRotateSyncer(ws zapcore.WriteSyncer, logger rotatingFile, schedule *cronSchedule, now func() time.Time) *cronRotateSyncer {
+	return &cronRotateSyncer{WriteSyncer: ws, logger: logger, schedule: schedule, now: now, next: schedule.next(now())}
+}
+
+func (w *cronRotateSyncer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	now := w.now()
+	if !w.next.IsZero() && !now.Before(w.next) {
+		w.next = w.schedule.next(now)
+		if err := w.logger.Rotate(); err != nil {
+			w.mu.Unlock()
+			return 0, err
+		}
+	}
+	w.mu.Unlock()
+	return w.WriteSyncer.Write(p)
+}
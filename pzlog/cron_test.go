@@ -0,0 +1,74 @@
+package pzlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	from := time.Date(2024, 1, 6, 23, 30, 0, 0, time.UTC) // 周六
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"0 3 * * 0", time.Date(2024, 1, 7, 3, 0, 0, 0, time.UTC)},
+		{"0 3 * * 7", time.Date(2024, 1, 7, 3, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 6, 23, 45, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1-3 * 1", time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)},
+		{"30 9 29 2 *", time.Date(2024, 2, 29, 9, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		s, err := parseCron(tt.spec)
+		if err != nil {
+			t.Errorf("%s: %v", tt.spec, err)
+			continue
+		}
+		if got := s.next(from); !got.Equal(tt.want) {
+			t.Errorf("%s: next = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+	for _, spec := range []string{"* * * *", "60 * * * *", "* * * * 8", "*/0 * * * *", "5-1 * * * *"} {
+		if _, err := parseCron(spec); err == nil {
+			t.Errorf("%s: parsed, want error", spec)
+		}
+	}
+}
+
+func TestRotateCron(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2024, 1, 6, 23, 0, 0, 0, time.Local)}
+	config := NewDefaultConfig()
+	config.Clock = clock
+	config.Filename = filepath.Join(dir, "app.log")
+	config.RotateCron = "0 3 * * 0"
+	logger := GetLogger(config)
+	defer func() { _ = Close() }()
+	logger.Info("saturday")
+	clock.Add(3 * time.Hour)
+	logger.Info("before schedule")
+	clock.Add(time.Hour)
+	logger.Info("sunday")
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d files, want app.log and one backup", len(entries))
+	}
+	for _, e := range entries {
+		data := readFile(t, filepath.Join(dir, e.Name()))
+		if e.Name() == "app.log" {
+			if !strings.Contains(data, "sunday") || strings.Contains(data, "saturday") {
+				t.Errorf("app.log = %q, want only the entry after the schedule", data)
+			}
+		} else if !strings.Contains(data, "saturday") || !strings.Contains(data, "before schedule") {
+			t.Errorf("backup = %q, want entries before the schedule", data)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package pzlog
+
+import (
+	"context"
+	"encoding/binary"
+	"go.uber.org/zap"
+	"strconv"
+	"sync/atomic"
+)
+
+// SpanContextFunc 从context中读取当前span的跟踪ID和span ID，没有有效的span时ok为false。
+// 使用OpenTelemetry时可以这样实现，pzlog本身不依赖OpenTelemetry：
+//
+//	func(ctx context.Context) ([16]byte, [8]byte, bool) {
+//		sc := trace.SpanContextFromContext(ctx)
+//		return sc.TraceID(), sc.SpanID(), sc.IsValid()
+//	}
+//
+// 使用Datadog的tracer时将64位ID按大端序写入数组的低8字节即可
+type SpanContextFunc func(ctx context.Context) (traceID [16]byte, spanID [8]byte, ok bool)
+
+// currentDatadogSpan 最近一次GetLogger配置的span读取函数，未配置时为nil
+var currentDatadogSpan atomic.Pointer[SpanContextFunc]
+
+// setDatadogSpan 设置FromContext和GinLogger读取span的函数，get为nil时不读取
+func setDatadogSpan(get SpanContextFunc) {
+	if get == nil {
+		currentDatadogSpan.Store(nil)
+		return
+	}
+	currentDatadogSpan.Store(&get)
+}
+
+// datadogFields 返回Datadog关联日志和链路所需的dd.trace_id和dd.span_id字段，
+// 取值为跟踪ID低64位和span ID的十进制字符串
+func datadogFields(ctx context.Context) []zap.Field {
+	get := currentDatadogSpan.Load()
+	if get == nil {
+		return nil
+	}
+	traceID, spanID, ok := (*get)(ctx)
+	if !ok {
+		return nil
+	}
+	return []zap.Field{
+		zap.String("dd.trace_id", strconv.FormatUint(binary.BigEndian.Uint64(traceID[8:]), 10)),
+		zap.String("dd.span_id", strconv.FormatUint(binary.BigEndian.Uint64(spanID[:]), 10)),
+	}
+}
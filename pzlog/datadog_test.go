@@ -0,0 +1,60 @@
+package pzlog
+
+import (
+	"context"
+	"encoding/binary"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testSpanKey struct{}
+
+type testSpan struct {
+	traceID [16]byte
+	spanID  [8]byte
+}
+
+func TestDatadogSpan(t *testing.T) {
+	prev := zap.L()
+	t.Cleanup(func() {
+		zap.ReplaceGlobals(prev)
+		setDatadogSpan(nil)
+	})
+	config, out := newCapturedConfig()
+	config.ReplaceGlobals = true
+	config.DatadogSpan = func(ctx context.Context) ([16]byte, [8]byte, bool) {
+		span, ok := ctx.Value(testSpanKey{}).(testSpan)
+		return span.traceID, span.spanID, ok
+	}
+	GetLogger(config)
+
+	var span testSpan
+	// 高64位不参与Datadog的trace_id
+	binary.BigEndian.PutUint64(span.traceID[:8], 0xdeadbeef)
+	binary.BigEndian.PutUint64(span.traceID[8:], 0xffffffffffffffff)
+	binary.BigEndian.PutUint64(span.spanID[:], 42)
+	ctx := context.WithValue(context.Background(), testSpanKey{}, span)
+
+	FromContext(ctx).Info("in span")
+	FromContext(context.Background()).Info("no span")
+	e := gin.New()
+	e.Use(GinLogger())
+	e.GET("/", func(c *gin.Context) {})
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+	entries := out.Entries(t)
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	for _, i := range []int{0, 2} {
+		if entries[i]["dd.trace_id"] != "18446744073709551615" || entries[i]["dd.span_id"] != "42" {
+			t.Errorf("%v: dd.trace_id = %v, dd.span_id = %v", entries[i]["msg"], entries[i]["dd.trace_id"], entries[i]["dd.span_id"])
+		}
+	}
+	if v, ok := entries[1]["dd.trace_id"]; ok {
+		t.Errorf("entry without a span logged dd.trace_id = %v", v)
+	}
+}
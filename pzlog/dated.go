@@ -0,0 +1,108 @@
+package pzlog
+
+import (
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// isDatedFilename 判断文件名是否包含日期模板
+func isDatedFilename(filename string) bool {
+	for _, verb := range []string{"%Y", "%m", "%d", "%H"} {
+		if strings.Contains(filename, verb) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandDatedFilename 将文件名模板中的%Y(四位年份)、%m(月)、%d(日)、%H(小时)替换为t对应的值，%%替换为%，其余内容保持不变
+func expandDatedFilename(template string, t time.Time) string {
+	var sb strings.Builder
+	for i := 0; i < len(template); i++ {
+		if template[i] != '%' || i+1 == len(template) {
+			sb.WriteByte(template[i])
+			continue
+		}
+		switch template[i+1] {
+		case 'Y':
+			sb.WriteString(strconv.Itoa(t.Year()))
+		case 'm':
+			writeTwoDigits(&sb, int(t.Month()))
+		case 'd':
+			writeTwoDigits(&sb, t.Day())
+		case 'H':
+			writeTwoDigits(&sb, t.Hour())
+		case '%':
+			sb.WriteByte('%')
+		default:
+			sb.WriteByte('%')
+			continue
+		}
+		i++
+	}
+	return sb.String()
+}
+
+func writeTwoDigits(sb *strings.Builder, n int) {
+	if n < 10 {
+		sb.WriteByte('0')
+	}
+	sb.WriteString(strconv.Itoa(n))
+}
+
+// datedWriteSyncer 按文件名模板写入带日期的文件，日期变化后的第一次写入时关闭旧文件并切换到新文件，
+// 每个文件仍按lumberjack的大小限制切割
+type datedWriteSyncer struct {
+	template string
+	now      func() time.Time
+	open     func(filename string) (zapcore.WriteSyncer, *lumberjack.Logger)
+
+	mu       sync.Mutex
+	filename string
+	ws       zapcore.WriteSyncer
+	logger   *lumberjack.Logger
+}
+
+func newDatedWriteSyncer(template string, now func() time.Time, open func(string) (zapcore.WriteSyncer, *lumberjack.Logger)) *datedWriteSyncer {
+	return &datedWriteSyncer{template: template, now: now, open: open}
+}
+
+func (w *datedWriteSyncer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if filename := expandDatedFilename(w.template, w.now()); filename != w.filename {
+		if w.logger != nil {
+			_ = w.logger.Close()
+		}
+		w.filename = filename
+		w.ws, w.logger = w.open(filename)
+	}
+	return w.ws.Write(p)
+}
+
+func (w *datedWriteSyncer) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.ws == nil {
+		return nil
+	}
+	return w.ws.Sync()
+}
+
+// Close 关闭当前写入的文件
+func (w *datedWriteSyncer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.logger == nil {
+		return nil
+	}
+	err := w.logger.Close()
+	w.logger = nil
+	w.ws = nil
+	w.filename = ""
+	return err
+}
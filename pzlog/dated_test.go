@@ -0,0 +1,61 @@
+package pzlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpandDatedFilename(t *testing.T) {
+	at := time.Date(2024, 3, 5, 7, 0, 0, 0, time.UTC)
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"app-%Y%m%d.log", "app-20240305.log"},
+		{"%Y/%m/app-%H.log", "2024/03/app-07.log"},
+		{"app-100%%-%d.log", "app-100%-05.log"},
+		{"app-%x.log", "app-%x.log"},
+		{"app%", "app%"},
+	}
+	for _, tt := range tests {
+		if got := expandDatedFilename(tt.template, at); got != tt.want {
+			t.Errorf("expandDatedFilename(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+	if isDatedFilename("./logs/app.log") || !isDatedFilename("./logs/app-%Y.log") {
+		t.Error("isDatedFilename misdetects templates")
+	}
+}
+
+func TestDatedFilename(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 23, 59, 0, 0, time.Local)}
+	config := NewDefaultConfig()
+	config.Clock = clock
+	config.Filename = filepath.Join(dir, "app-%Y%m%d.log")
+	logger := GetLogger(config)
+	defer func() { _ = Close() }()
+	logger.Info("before midnight")
+	clock.Add(2 * time.Minute)
+	logger.Info("after midnight")
+	clock.Add(time.Hour)
+	logger.Info("same day")
+
+	if got := readFile(t, filepath.Join(dir, "app-20240101.log")); strings.Count(got, "\n") != 1 || !strings.Contains(got, "before midnight") {
+		t.Errorf("app-20240101.log = %q, want only the entry before midnight", got)
+	}
+	got := readFile(t, filepath.Join(dir, "app-20240102.log"))
+	if strings.Count(got, "\n") != 2 || !strings.Contains(got, "after midnight") || !strings.Contains(got, "same day") {
+		t.Errorf("app-20240102.log = %q, want the entries after midnight", got)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("got %d files, want 2 dated files", len(entries))
+	}
+}
@@ -0,0 +1,57 @@
+package pzlog
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"time"
+)
+
+// FromContextDeadline 返回FromContext(ctx)的Logger，context剩余时间不足threshold时丢弃debug日志，
+// 其余日志添加near_deadline字段(值为true)，避免在快超时的请求中因大量日志耗尽时间预算。context没有截止时间时与FromContext相同
+func FromContextDeadline(ctx context.Context, threshold time.Duration) *zap.Logger {
+	logger := FromContext(ctx)
+	if _, ok := ctx.Deadline(); !ok {
+		return logger
+	}
+	return logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &deadlineCore{Core: core, ctx: ctx, threshold: threshold}
+	}))
+}
+
+// deadlineCore 根据context的剩余时间丢弃debug日志并标记near_deadline
+type deadlineCore struct {
+	zapcore.Core
+	ctx       context.Context
+	threshold time.Duration
+}
+
+func (c *deadlineCore) With(fields []zapcore.Field) zapcore.Core {
+	return &deadlineCore{Core: c.Core.With(fields), ctx: c.ctx, threshold: c.threshold}
+}
+
+func (c *deadlineCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if entry.Level < zapcore.InfoLevel && c.nearDeadline() {
+		return ce
+	}
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *deadlineCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if c.nearDeadline() {
+		all := make([]zapcore.Field, 0, len(fields)+1)
+		all = append(all, fields...)
+		all = append(all, zap.Bool("near_deadline", true))
+		fields = all
+	}
+	return c.Core.Write(entry, fields)
+}
+
+// nearDeadline 判断context的剩余时间是否不足threshold
+func (c *deadlineCore) nearDeadline() bool {
+	deadline, ok := c.ctx.Deadline()
+	return ok && time.Until(deadline) < c.threshold
+}
@@ -0,0 +1,222 @@
+package pzlog
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"strings"
+)
+
+// newEncoder 根据配置创建指定格式(json、console、console-oneline、msgpack、loki、csv或者es-bulk)的Encoder，并按需包装附加功能
+func newEncoder(config *PzlogConfig, types string) zapcore.Encoder {
+	var enc zapcore.Encoder
+	switch types {
+	case "loki":
+		enc = newLokiEncoder(config.Service, timeFormatter(config), durationEncoder(config))
+	case "csv":
+		enc = newCSVEncoder(config.CSVColumns, timeFormatter(config))
+	case "es-bulk":
+		enc = newESBulkEncoder(config.ESIndex, timeFormatter(config), durationEncoder(config))
+	case "console-oneline":
+		enc = newOnelineEncoder(config.location)
+	default:
+		enc = getEncoder(types, timeFormatter(config), durationEncoder(config), consoleLevelEncoder(config))
+	}
+	if config.DedupEntryFields {
+		enc = &dedupEncoder{Encoder: enc}
+	}
+	if res := compileRedactPatterns(config.RedactMessage); len(res) > 0 {
+		enc = &redactMessageEncoder{Encoder: enc, patterns: res}
+	}
+	if config.MaxMessageLength > 0 {
+		enc = &messageCapEncoder{Encoder: enc, max: config.MaxMessageLength}
+	}
+	if config.ControlChars != "" {
+		enc = newControlCharEncoder(enc, config.ControlChars)
+	}
+	if config.OmitEmpty {
+		enc = &omitEmptyEncoder{Encoder: enc}
+	}
+	if config.SplitCaller {
+		enc = &splitCallerEncoder{Encoder: enc}
+	}
+	if config.MaxArrayLength > 0 {
+		enc = newArrayCapEncoder(enc, config.MaxArrayLength)
+	}
+	if config.NaNHandling != "" {
+		enc = newNaNEncoder(enc, config.NaNHandling)
+	}
+	if config.LevelNumber {
+		enc = &levelNumEncoder{Encoder: enc}
+	}
+	if config.LevelVerbose {
+		enc = &levelVerboseEncoder{Encoder: enc}
+	}
+	if config.IsErrorField {
+		enc = &isErrorEncoder{Encoder: enc}
+	}
+	if config.FlattenFields {
+		enc = newFlatEncoder(enc)
+	}
+	if config.LowercaseKeys {
+		enc = newLowerKeyEncoder(enc, config.LowercaseMessage)
+	}
+	if config.OnEncodeError != "" {
+		enc = &encodeErrorEncoder{Encoder: enc, policy: config.OnEncodeError}
+	}
+	if config.EncoderBufferSize > 0 {
+		enc = newBufferSizeEncoder(enc, config.EncoderBufferSize)
+	}
+	return enc
+}
+
+// dedupEncoder 编码时同名字段只保留最后一个值，包括其他选项(例如Uptime、LevelNumber)添加的字段
+type dedupEncoder struct {
+	zapcore.Encoder
+}
+
+func (e *dedupEncoder) Clone() zapcore.Encoder {
+	return &dedupEncoder{Encoder: e.Encoder.Clone()}
+}
+
+func (e *dedupEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	return e.Encoder.EncodeEntry(entry, dedupFields(fields))
+}
+
+// levelNumEncoder 在level字段之外额外输出数值形式的level_num字段，取值与zapcore.Level一致(debug为-1，fatal为5)
+type levelNumEncoder struct {
+	zapcore.Encoder
+}
+
+func (e *levelNumEncoder) Clone() zapcore.Encoder {
+	return &levelNumEncoder{Encoder: e.Encoder.Clone()}
+}
+
+func (e *levelNumEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	fs := make([]zapcore.Field, 0, len(fields)+1)
+	fs = append(fs, zap.Int8("level_num", int8(entry.Level)))
+	fs = append(fs, fields...)
+	return e.Encoder.EncodeEntry(entry, fs)
+}
+
+// verboseLevelNames 各级别的完整名称，用于level_verbose
+var verboseLevelNames = map[zapcore.Level]string{
+	zapcore.DebugLevel:  "debug",
+	zapcore.InfoLevel:   "info",
+	zapcore.WarnLevel:   "warning",
+	zapcore.ErrorLevel:  "error",
+	zapcore.DPanicLevel: "dpanic",
+	zapcore.PanicLevel:  "panic",
+	zapcore.FatalLevel:  "fatal",
+}
+
+// levelVerboseEncoder 在level字段之外额外输出完整单词形式的level_verbose字段，例如warn级别为warning
+type levelVerboseEncoder struct {
+	zapcore.Encoder
+}
+
+func (e *levelVerboseEncoder) Clone() zapcore.Encoder {
+	return &levelVerboseEncoder{Encoder: e.Encoder.Clone()}
+}
+
+func (e *levelVerboseEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	name, ok := verboseLevelNames[entry.Level]
+	if !ok {
+		name = entry.Level.String()
+	}
+	fs := make([]zapcore.Field, 0, len(fields)+1)
+	fs = append(fs, zap.String("level_verbose", name))
+	fs = append(fs, fields...)
+	return e.Encoder.EncodeEntry(entry, fs)
+}
+
+// isErrorEncoder 额外输出布尔字段is_error，warn及以上级别为true，便于简单的告警路由
+type isErrorEncoder struct {
+	zapcore.Encoder
+}
+
+func (e *isErrorEncoder) Clone() zapcore.Encoder {
+	return &isErrorEncoder{Encoder: e.Encoder.Clone()}
+}
+
+func (e *isErrorEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	fs := make([]zapcore.Field, 0, len(fields)+1)
+	fs = append(fs, zap.Bool("is_error", entry.Level >= zapcore.WarnLevel))
+	fs = append(fs, fields...)
+	return e.Encoder.EncodeEntry(entry, fs)
+}
+
+// mapEncoder 将字段收集到map中，供需要自行输出字段的Encoder使用
+type mapEncoder struct {
+	*zapcore.MapObjectEncoder
+	// ns With中打开的命名空间路径
+	ns []string
+}
+
+func newMapEncoder() *mapEncoder {
+	return &mapEncoder{MapObjectEncoder: zapcore.NewMapObjectEncoder()}
+}
+
+func (e *mapEncoder) OpenNamespace(key string) {
+	e.MapObjectEncoder.OpenNamespace(key)
+	e.ns = append(e.ns, key)
+}
+
+// clone 复制已有字段，并重新打开命名空间
+func (e *mapEncoder) clone() *mapEncoder {
+	clone := newMapEncoder()
+	src := e.Fields
+	dst := clone.MapObjectEncoder
+	for i := 0; ; i++ {
+		var next string
+		if i < len(e.ns) {
+			next = e.ns[i]
+		}
+		for k, v := range src {
+			if k != next || i >= len(e.ns) {
+				_ = dst.AddReflected(k, v)
+			}
+		}
+		if i >= len(e.ns) {
+			break
+		}
+		dst.OpenNamespace(next)
+		src, _ = src[next].(map[string]interface{})
+	}
+	clone.ns = append(clone.ns, e.ns...)
+	return clone
+}
+
+// withFields 复制已有字段并添加fields
+func (e *mapEncoder) withFields(fields []zapcore.Field) *mapEncoder {
+	enc := e.clone()
+	for i := range fields {
+		fields[i].AddTo(enc)
+	}
+	return enc
+}
+
+// splitCallerEncoder 将调用位置拆分为caller_file(字符串)和caller_line(整数)两个字段
+type splitCallerEncoder struct {
+	zapcore.Encoder
+}
+
+func (e *splitCallerEncoder) Clone() zapcore.Encoder {
+	return &splitCallerEncoder{Encoder: e.Encoder.Clone()}
+}
+
+func (e *splitCallerEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	if !entry.Caller.Defined {
+		return e.Encoder.EncodeEntry(entry, fields)
+	}
+	caller := entry.Caller
+	entry.Caller = zapcore.EntryCaller{}
+	file := caller.TrimmedPath()
+	if i := strings.LastIndexByte(file, ':'); i >= 0 {
+		file = file[:i]
+	}
+	fs := make([]zapcore.Field, 0, len(fields)+2)
+	fs = append(fs, zap.String("caller_file", file), zap.Int("caller_line", caller.Line))
+	fs = append(fs, fields...)
+	return e.Encoder.EncodeEntry(entry, fs)
+}
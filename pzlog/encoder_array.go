@@ -0,0 +1,248 @@
+package pzlog
+
+import (
+	"fmt"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"reflect"
+	"time"
+)
+
+// arrayCapEncoder 限制数组和切片字段输出的元素个数，超出部分以"…(+N more)"标记代替
+type arrayCapEncoder struct {
+	zapcore.Encoder
+	max int
+}
+
+func newArrayCapEncoder(enc zapcore.Encoder, max int) *arrayCapEncoder {
+	return &arrayCapEncoder{Encoder: enc, max: max}
+}
+
+func (e *arrayCapEncoder) Clone() zapcore.Encoder {
+	return &arrayCapEncoder{Encoder: e.Encoder.Clone(), max: e.max}
+}
+
+func (e *arrayCapEncoder) AddArray(key string, arr zapcore.ArrayMarshaler) error {
+	return e.Encoder.AddArray(key, cappedArray{arr: arr, max: e.max})
+}
+
+func (e *arrayCapEncoder) AddReflected(key string, value interface{}) error {
+	if arr, ok := reflectedArray(value, e.max); ok {
+		return e.Encoder.AddArray(key, arr)
+	}
+	return e.Encoder.AddReflected(key, value)
+}
+
+func (e *arrayCapEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	fs := make([]zapcore.Field, len(fields))
+	copy(fs, fields)
+	for i, f := range fs {
+		switch f.Type {
+		case zapcore.ArrayMarshalerType:
+			fs[i].Interface = cappedArray{arr: f.Interface.(zapcore.ArrayMarshaler), max: e.max}
+		case zapcore.ReflectType:
+			if arr, ok := reflectedArray(f.Interface, e.max); ok {
+				fs[i] = zapcore.Field{Key: f.Key, Type: zapcore.ArrayMarshalerType, Interface: arr}
+			}
+		}
+	}
+	return e.Encoder.EncodeEntry(entry, fs)
+}
+
+// reflectedArray 将超过max个元素的切片或数组转换为截断后的ArrayMarshaler
+func reflectedArray(value interface{}, max int) (zapcore.ArrayMarshaler, bool) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, false
+	}
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false
+	}
+	if v.Len() <= max {
+		return nil, false
+	}
+	return zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+		for i := 0; i < max; i++ {
+			if err := enc.AppendReflected(v.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		enc.AppendString(moreMarker(v.Len() - max))
+		return nil
+	}), true
+}
+
+func moreMarker(n int) string {
+	return fmt.Sprintf("…(+%d more)", n)
+}
+
+// cappedArray 最多输出max个元素的ArrayMarshaler
+type cappedArray struct {
+	arr zapcore.ArrayMarshaler
+	max int
+}
+
+func (a cappedArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	capped := &cappingArrayEncoder{ArrayEncoder: enc, max: a.max}
+	if err := a.arr.MarshalLogArray(capped); err != nil {
+		return err
+	}
+	if capped.n > a.max {
+		enc.AppendString(moreMarker(capped.n - a.max))
+	}
+	return nil
+}
+
+// cappingArrayEncoder 只转发前max个元素，并统计元素总数
+type cappingArrayEncoder struct {
+	zapcore.ArrayEncoder
+	max int
+	n   int
+}
+
+// next 统计元素个数，返回是否需要输出当前元素
+func (e *cappingArrayEncoder) next() bool {
+	e.n++
+	return e.n <= e.max
+}
+
+func (e *cappingArrayEncoder) AppendBool(v bool) {
+	if e.next() {
+		e.ArrayEncoder.AppendBool(v)
+	}
+}
+
+func (e *cappingArrayEncoder) AppendByteString(v []byte) {
+	if e.next() {
+		e.ArrayEncoder.AppendByteString(v)
+	}
+}
+
+func (e *cappingArrayEncoder) AppendComplex128(v complex128) {
+	if e.next() {
+		e.ArrayEncoder.AppendComplex128(v)
+	}
+}
+
+func (e *cappingArrayEncoder) AppendComplex64(v complex64) {
+	if e.next() {
+		e.ArrayEncoder.AppendComplex64(v)
+	}
+}
+
+func (e *cappingArrayEncoder) AppendFloat64(v float64) {
+	if e.next() {
+		e.ArrayEncoder.AppendFloat64(v)
+	}
+}
+
+func (e *cappingArrayEncoder) AppendFloat32(v float32) {
+	if e.next() {
+		e.ArrayEncoder.AppendFloat32(v)
+	}
+}
+
+func (e *cappingArrayEncoder) AppendInt(v int) {
+	if e.next() {
+		e.ArrayEncoder.AppendInt(v)
+	}
+}
+
+func (e *cappingArrayEncoder) AppendInt64(v int64) {
+	if e.next() {
+		e.ArrayEncoder.AppendInt64(v)
+	}
+}
+
+func (e *cappingArrayEncoder) AppendInt32(v int32) {
+	if e.next() {
+		e.ArrayEncoder.AppendInt32(v)
+	}
+}
+
+func (e *cappingArrayEncoder) AppendInt16(v int16) {
+	if e.next() {
+		e.ArrayEncoder.AppendInt16(v)
+	}
+}
+
+func (e *cappingArrayEncoder) AppendInt8(v int8) {
+	if e.next() {
+		e.ArrayEncoder.AppendInt8(v)
+	}
+}
+
+func (e *cappingArrayEncoder) AppendString(v string) {
+	if e.next() {
+		e.ArrayEncoder.AppendString(v)
+	}
+}
+
+func (e *cappingArrayEncoder) AppendUint(v uint) {
+	if e.next() {
+		e.ArrayEncoder.AppendUint(v)
+	}
+}
+
+func (e *cappingArrayEncoder) AppendUint64(v uint64) {
+	if e.next() {
+		e.ArrayEncoder.AppendUint64(v)
+	}
+}
+
+func (e *cappingArrayEncoder) AppendUint32(v uint32) {
+	if e.next() {
+		e.ArrayEncoder.AppendUint32(v)
+	}
+}
+
+func (e *cappingArrayEncoder) AppendUint16(v uint16) {
+	if e.next() {
+		e.ArrayEncoder.AppendUint16(v)
+	}
+}
+
+func (e *cappingArrayEncoder) AppendUint8(v uint8) {
+	if e.next() {
+		e.ArrayEncoder.AppendUint8(v)
+	}
+}
+
+func (e *cappingArrayEncoder) AppendUintptr(v uintptr) {
+	if e.next() {
+		e.ArrayEncoder.AppendUintptr(v)
+	}
+}
+
+func (e *cappingArrayEncoder) AppendDuration(v time.Duration) {
+	if e.next() {
+		e.ArrayEncoder.AppendDuration(v)
+	}
+}
+
+func (e *cappingArrayEncoder) AppendTime(v time.Time) {
+	if e.next() {
+		e.ArrayEncoder.AppendTime(v)
+	}
+}
+
+func (e *cappingArrayEncoder) AppendArray(v zapcore.ArrayMarshaler) error {
+	if e.next() {
+		return e.ArrayEncoder.AppendArray(v)
+	}
+	return nil
+}
+
+func (e *cappingArrayEncoder) AppendObject(v zapcore.ObjectMarshaler) error {
+	if e.next() {
+		return e.ArrayEncoder.AppendObject(v)
+	}
+	return nil
+}
+
+func (e *cappingArrayEncoder) AppendReflected(v interface{}) error {
+	if e.next() {
+		return e.ArrayEncoder.AppendReflected(v)
+	}
+	return nil
+}
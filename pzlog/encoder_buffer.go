@@ -0,0 +1,37 @@
+package pzlog
+
+import (
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// bufferSizeEncoder 将编码结果所在的缓冲区扩容到至少size字节。
+// 缓冲区释放后回到zap的缓冲池并保留容量，之后的大日志无需从1KB开始反复扩容
+type bufferSizeEncoder struct {
+	zapcore.Encoder
+	size int
+	// pad 用于扩容的零值数据，各Clone共享且只读
+	pad []byte
+}
+
+func newBufferSizeEncoder(enc zapcore.Encoder, size int) *bufferSizeEncoder {
+	return &bufferSizeEncoder{Encoder: enc, size: size, pad: make([]byte, size)}
+}
+
+func (e *bufferSizeEncoder) Clone() zapcore.Encoder {
+	return &bufferSizeEncoder{Encoder: e.Encoder.Clone(), size: e.size, pad: e.pad}
+}
+
+func (e *bufferSizeEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	buf, err := e.Encoder.EncodeEntry(entry, fields)
+	if err != nil || buf.Cap() >= e.size {
+		return buf, err
+	}
+	// 在编码结果之后写入填充数据使缓冲区原地扩容，再截回原长度，扩容时只复制一次已有内容
+	n := buf.Len()
+	_, _ = buf.Write(e.pad[:e.size-n])
+	data := buf.Bytes()
+	buf.Reset()
+	_, _ = buf.Write(data[:n])
+	return buf, nil
+}
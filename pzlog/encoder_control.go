@@ -0,0 +1,76 @@
+package pzlog
+
+import (
+	"fmt"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"strings"
+	"unicode"
+)
+
+// 控制字符的处理方式
+const (
+	// ControlCharsEscape 将控制字符转义为\xNN或\uNNNN形式的文本
+	ControlCharsEscape = "escape"
+	// ControlCharsStrip 删除控制字符
+	ControlCharsStrip = "strip"
+)
+
+// controlCharEncoder 转义或删除消息和字符串字段中的控制字符(换行和制表符除外)，避免破坏终端和日志查看器
+type controlCharEncoder struct {
+	zapcore.Encoder
+	mode string
+}
+
+func newControlCharEncoder(enc zapcore.Encoder, mode string) *controlCharEncoder {
+	return &controlCharEncoder{Encoder: enc, mode: mode}
+}
+
+func (e *controlCharEncoder) Clone() zapcore.Encoder {
+	return &controlCharEncoder{Encoder: e.Encoder.Clone(), mode: e.mode}
+}
+
+func (e *controlCharEncoder) AddString(key, value string) {
+	e.Encoder.AddString(key, e.clean(value))
+}
+
+func (e *controlCharEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	entry.Message = e.clean(entry.Message)
+	fs := make([]zapcore.Field, len(fields))
+	copy(fs, fields)
+	for i := range fs {
+		if fs[i].Type == zapcore.StringType {
+			fs[i].String = e.clean(fs[i].String)
+		}
+	}
+	return e.Encoder.EncodeEntry(entry, fs)
+}
+
+// clean 处理字符串中的控制字符
+func (e *controlCharEncoder) clean(s string) string {
+	if strings.IndexFunc(s, isControlChar) < 0 {
+		return s
+	}
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for _, r := range s {
+		if !isControlChar(r) {
+			sb.WriteRune(r)
+			continue
+		}
+		if e.mode == ControlCharsStrip {
+			continue
+		}
+		if r < 0x100 {
+			fmt.Fprintf(&sb, "\\x%02x", r)
+		} else {
+			fmt.Fprintf(&sb, "\\u%04x", r)
+		}
+	}
+	return sb.String()
+}
+
+// isControlChar 判断是否为需要处理的控制字符，换行和制表符保留
+func isControlChar(r rune) bool {
+	return unicode.IsControl(r) && r != '\n' && r != '\t'
+}
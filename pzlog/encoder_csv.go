@@ -0,0 +1,224 @@
+package pzlog
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+var csvPool = buffer.NewPool()
+
+// defaultCSVColumns csv格式默认的列
+var defaultCSVColumns = []string{"ts", "level", "caller", "msg"}
+
+// csvFieldsColumn 未单独成列的字段以json对象的形式放在最后一列
+const csvFieldsColumn = "fields"
+
+// csvEncoder 将日志编码为csv格式。
+// 列可以是ts、level、caller、msg、logger、stacktrace或者字段名，其余字段以json对象的形式放在最后的fields列。
+// 编码器不输出表头，表头由各输出在开头写入，见csvHeader
+type csvEncoder struct {
+	*mapEncoder
+	formatTime func(time.Time) string
+	columns    []string
+}
+
+func newCSVEncoder(columns []string, formatTime func(time.Time) string) *csvEncoder {
+	if len(columns) == 0 {
+		columns = defaultCSVColumns
+	}
+	return &csvEncoder{mapEncoder: newMapEncoder(), formatTime: formatTime, columns: columns}
+}
+
+func (e *csvEncoder) Clone() zapcore.Encoder {
+	return &csvEncoder{mapEncoder: e.clone(), formatTime: e.formatTime, columns: e.columns}
+}
+
+func (e *csvEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	enc := e.withFields(fields)
+	record := make([]string, 0, len(e.columns)+1)
+	for _, col := range e.columns {
+		switch col {
+		case "ts":
+			record = append(record, e.formatTime(entry.Time))
+		case "level":
+			record = append(record, entry.Level.CapitalString())
+		case "caller":
+			if entry.Caller.Defined {
+				record = append(record, entry.Caller.TrimmedPath())
+			} else {
+				record = append(record, "")
+			}
+		case "msg":
+			record = append(record, entry.Message)
+		case "logger":
+			record = append(record, entry.LoggerName)
+		case "stacktrace":
+			record = append(record, entry.Stack)
+		default:
+			v, ok := enc.Fields[col]
+			if !ok {
+				record = append(record, "")
+				continue
+			}
+			delete(enc.Fields, col)
+			record = append(record, csvValue(v))
+		}
+	}
+	spill := ""
+	if len(enc.Fields) > 0 {
+		data, err := json.Marshal(enc.Fields)
+		if err != nil {
+			return nil, err
+		}
+		spill = string(data)
+	}
+	record = append(record, spill)
+
+	buf := csvPool.Get()
+	w := csv.NewWriter(buf)
+	_ = w.Write(record)
+	w.Flush()
+	if err := w.Error(); err != nil {
+		buf.Free()
+		return nil, err
+	}
+	return buf, nil
+}
+
+// csvValue 将字段值转换为单元格文本，字符串原样输出，其余值编码为json
+func csvValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// csvHeader 返回types为csv时各输出开头的表头行，其余格式返回nil
+func csvHeader(config *PzlogConfig, types string) []byte {
+	if types != "csv" {
+		return nil
+	}
+	columns := config.CSVColumns
+	if len(columns) == 0 {
+		columns = defaultCSVColumns
+	}
+	header := make([]string, 0, len(columns)+1)
+	header = append(header, columns...)
+	header = append(header, csvFieldsColumn)
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write(header)
+	w.Flush()
+	return buf.Bytes()
+}
+
+// csvHeaderSyncer 在第一次写入前写入表头，用于stdout、回调等不切割的输出，每个输出各自写入一次
+type csvHeaderSyncer struct {
+	zapcore.WriteSyncer
+	header []byte
+	once   sync.Once
+}
+
+// withCSVHeader 为不切割的输出添加表头，header为nil时返回ws
+func withCSVHeader(ws zapcore.WriteSyncer, header []byte) zapcore.WriteSyncer {
+	if len(header) == 0 {
+		return ws
+	}
+	return &csvHeaderSyncer{WriteSyncer: ws, header: header}
+}
+
+func (w *csvHeaderSyncer) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	var err error
+	w.once.Do(func() {
+		_, err = w.WriteSyncer.Write(w.header)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return w.WriteSyncer.Write(p)
+}
+
+// rotatingFile 可切割的日志文件
+type rotatingFile interface {
+	io.WriteCloser
+	Rotate() error
+}
+
+// withCSVHeaderFile 为lumberjack日志文件添加表头，header为nil时返回logger
+func withCSVHeaderFile(logger *lumberjack.Logger, header []byte) rotatingFile {
+	if len(header) == 0 {
+		return logger
+	}
+	return &csvHeaderFile{Logger: logger, header: header, size: -1}
+}
+
+// csvHeaderFile 在lumberjack每个新日志文件的开头写入表头，包括按大小或Rotate切割后的新文件，追加写入已有文件时不重复写入。
+// lumberjack没有切割的回调，因此按lumberjack相同的规则跟踪当前文件的大小，在写入前判断是否会切换到新文件
+type csvHeaderFile struct {
+	*lumberjack.Logger
+	header []byte
+
+	mu sync.Mutex
+	// size 当前文件的大小，为-1时在第一次写入前读取已有文件的大小
+	size int64
+}
+
+func (f *csvHeaderFile) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	max := int64(f.MaxSize) * 1024 * 1024
+	if max == 0 {
+		max = 100 * 1024 * 1024
+	}
+	if f.size < 0 {
+		// lumberjack打开已有文件时，写入后达到上限则切换到新文件
+		f.size = 0
+		if info, err := os.Stat(f.Filename); err == nil && info.Size()+int64(len(p)) < max {
+			f.size = info.Size()
+		}
+	}
+	if f.size > 0 && f.size+int64(len(p)) <= max {
+		n, err := f.Logger.Write(p)
+		f.size += int64(n)
+		return n, err
+	}
+	// 新文件，表头与日志一起写入，lumberjack在写入前切割
+	buf := make([]byte, 0, len(f.header)+len(p))
+	buf = append(buf, f.header...)
+	buf = append(buf, p...)
+	n, err := f.Logger.Write(buf)
+	f.size = int64(n)
+	if n < len(f.header) {
+		return 0, err
+	}
+	return n - len(f.header), err
+}
+
+func (f *csvHeaderFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.size = 0
+	return f.Logger.Rotate()
+}
@@ -0,0 +1,122 @@
+package pzlog
+
+import (
+	"encoding/csv"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// parseCSV 解析csv文本，失败时终止测试
+func parseCSV(t *testing.T, text string) [][]string {
+	t.Helper()
+	r := csv.NewReader(strings.NewReader(text))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		t.Fatalf("invalid csv %q: %v", text, err)
+	}
+	return records
+}
+
+func TestCSVEncoder(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.Encoder = "csv"
+	config.CSVColumns = []string{"level", "msg", "user"}
+	logger := GetLogger(config)
+	logger.Info(`say "hi", then
+leave`, zap.String("user", "bob"), zap.Int("n", 1))
+	logger.Warn("plain")
+
+	records := parseCSV(t, strings.Join(out.Lines(), "\n"))
+	want := [][]string{
+		{"level", "msg", "user", "fields"},
+		{"INFO", "say \"hi\", then\nleave", "bob", `{"n":1}`},
+		{"WARN", "plain", "", ""},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("records = %q, want %q", records, want)
+	}
+}
+
+func TestCSVHeaderPerOutput(t *testing.T) {
+	stdout := redirectStdout(t)
+	dir := t.TempDir()
+	config := NewDefaultConfig()
+	config.Encoder = "csv"
+	config.Filename = filepath.Join(dir, "app.log")
+	config.PrintConsole = true
+	config.RotateEvery = 2
+	logger := GetLogger(config)
+	for _, msg := range []string{"one", "two", "three"} {
+		logger.Info(msg)
+	}
+	_ = Close()
+
+	header := []string{"ts", "level", "caller", "msg", "fields"}
+	if records := parseCSV(t, stdout()); len(records) != 4 || !reflect.DeepEqual(records[0], header) {
+		t.Errorf("stdout = %q, want header and three entries", records)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() == "app.log" {
+			continue
+		}
+		// 切割后的备份以表头开头
+		if records := parseCSV(t, readFile(t, filepath.Join(dir, e.Name()))); len(records) != 3 || !reflect.DeepEqual(records[0], header) {
+			t.Errorf("backup %s = %q, want header and two entries", e.Name(), records)
+		}
+	}
+
+	// 追加写入已有的文件时不重复写入表头
+	config = NewDefaultConfig()
+	config.Encoder = "csv"
+	config.Filename = filepath.Join(dir, "app.log")
+	GetLogger(config).Info("four")
+	_ = Close()
+	records := parseCSV(t, readFile(t, config.Filename))
+	if len(records) != 3 || !reflect.DeepEqual(records[0], header) || records[1][3] != "three" || records[2][3] != "four" {
+		t.Errorf("app.log = %q, want one header followed by three and four", records)
+	}
+}
+
+func TestCSVHeaderFileSizeRotation(t *testing.T) {
+	dir := t.TempDir()
+	config := NewDefaultConfig()
+	config.Filename = filepath.Join(dir, "app.log")
+	config.MaxSize = 1
+	header := csvHeader(config, "csv")
+	ws, logger := newFileWriteSyncer(config, &config.Logger, config.Filename, header)
+	defer logger.Close()
+	line := strings.Repeat("x", 300*1024) + "\n"
+	for i := 0; i < 5; i++ {
+		if _, err := ws.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d files, want one backup after the size limit", len(entries))
+	}
+	total := 0
+	for _, e := range entries {
+		data := readFile(t, filepath.Join(dir, e.Name()))
+		if !strings.HasPrefix(data, string(header)) || strings.Count(data, string(header)) != 1 {
+			t.Errorf("%s does not start with exactly one header", e.Name())
+		}
+		total += strings.Count(data, line)
+	}
+	if total != 5 {
+		t.Errorf("got %d lines across files, want 5", total)
+	}
+}
@@ -0,0 +1,83 @@
+package pzlog
+
+import (
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"reflect"
+)
+
+// omitEmptyEncoder 不输出值为nil或空(空字符串、空切片、空map)的字段
+type omitEmptyEncoder struct {
+	zapcore.Encoder
+}
+
+func (e *omitEmptyEncoder) Clone() zapcore.Encoder {
+	return &omitEmptyEncoder{Encoder: e.Encoder.Clone()}
+}
+
+func (e *omitEmptyEncoder) AddString(key, value string) {
+	if value == "" {
+		return
+	}
+	e.Encoder.AddString(key, value)
+}
+
+func (e *omitEmptyEncoder) AddByteString(key string, value []byte) {
+	if len(value) == 0 {
+		return
+	}
+	e.Encoder.AddByteString(key, value)
+}
+
+func (e *omitEmptyEncoder) AddBinary(key string, value []byte) {
+	if len(value) == 0 {
+		return
+	}
+	e.Encoder.AddBinary(key, value)
+}
+
+func (e *omitEmptyEncoder) AddReflected(key string, value interface{}) error {
+	if isEmptyValue(value) {
+		return nil
+	}
+	return e.Encoder.AddReflected(key, value)
+}
+
+func (e *omitEmptyEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	fs := make([]zapcore.Field, 0, len(fields))
+	for _, f := range fields {
+		if !isEmptyField(f) {
+			fs = append(fs, f)
+		}
+	}
+	return e.Encoder.EncodeEntry(entry, fs)
+}
+
+// isEmptyField 判断字段的值是否为nil或空
+func isEmptyField(f zapcore.Field) bool {
+	switch f.Type {
+	case zapcore.StringType:
+		return f.String == ""
+	case zapcore.ByteStringType, zapcore.BinaryType:
+		b, _ := f.Interface.([]byte)
+		return len(b) == 0
+	case zapcore.ReflectType:
+		return isEmptyValue(f.Interface)
+	}
+	return false
+}
+
+// isEmptyValue 判断值是否为nil、空切片或空map
+func isEmptyValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+package pzlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"reflect"
+	"sync"
+)
+
+// 编码失败时的处理方式
+const (
+	// EncodeErrorDrop 丢弃整条日志，丢弃的日志编码为空，输出会跳过空的写入
+	EncodeErrorDrop = "drop"
+	// EncodeErrorFallback 去掉所有字段，只输出消息和编码错误encode_error
+	EncodeErrorFallback = "fallback"
+	// EncodeErrorPanic 直接panic，用于测试中尽早发现无法编码的字段
+	EncodeErrorPanic = "panic"
+)
+
+// encodeErrorPool 用于丢弃日志时返回空的buffer
+var encodeErrorPool = buffer.NewPool()
+
+// encodeErrorEncoder 按policy处理编码失败的日志。zap默认将无法编码的字段替换为<key>Error字段，
+// 这里通过包装对象、数组、反射和Stringer类型的字段记录编码错误，再按policy丢弃、降级或panic
+type encodeErrorEncoder struct {
+	zapcore.Encoder
+	policy string
+}
+
+func (e *encodeErrorEncoder) Clone() zapcore.Encoder {
+	return &encodeErrorEncoder{Encoder: e.Encoder.Clone(), policy: e.policy}
+}
+
+func (e *encodeErrorEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	rec := &encodeErrors{}
+	buf, err := e.Encoder.EncodeEntry(entry, rec.wrap(fields))
+	if err == nil {
+		err = rec.first()
+	}
+	if err == nil {
+		return buf, nil
+	}
+	if buf != nil {
+		buf.Free()
+	}
+	switch e.policy {
+	case EncodeErrorDrop:
+		return encodeErrorPool.Get(), nil
+	case EncodeErrorPanic:
+		panic(fmt.Errorf("pzlog: encode log entry %q: %w", entry.Message, err))
+	default:
+		return e.Encoder.EncodeEntry(entry, []zapcore.Field{zap.String("encode_error", err.Error())})
+	}
+}
+
+// encodeErrors 记录字段编码过程中的错误
+type encodeErrors struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (r *encodeErrors) record(key string, err error) {
+	r.mu.Lock()
+	r.errs = append(r.errs, fmt.Errorf("%s: %w", key, err))
+	r.mu.Unlock()
+}
+
+func (r *encodeErrors) first() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.errs) == 0 {
+		return nil
+	}
+	return r.errs[0]
+}
+
+// wrap 包装可能编码失败的字段，包装后的字段输出与原字段相同
+func (r *encodeErrors) wrap(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, f := range fields {
+		switch f.Type {
+		case zapcore.ObjectMarshalerType, zapcore.InlineMarshalerType:
+			if m, ok := f.Interface.(zapcore.ObjectMarshaler); ok {
+				f.Interface = recordingObject{m: m, key: f.Key, rec: r}
+			}
+		case zapcore.ArrayMarshalerType:
+			if m, ok := f.Interface.(zapcore.ArrayMarshaler); ok {
+				f.Interface = recordingArray{m: m, key: f.Key, rec: r}
+			}
+		case zapcore.ReflectType:
+			if f.Interface != nil {
+				f.Interface = recordingJSON{v: f.Interface, key: f.Key, rec: r}
+			}
+		case zapcore.StringerType:
+			if s, ok := f.Interface.(fmt.Stringer); ok {
+				f.Interface = recordingStringer{s: s, key: f.Key, rec: r}
+			}
+		default:
+			continue
+		}
+		if out == nil {
+			out = make([]zapcore.Field, len(fields))
+			copy(out, fields)
+		}
+		out[i] = f
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+type recordingObject struct {
+	m   zapcore.ObjectMarshaler
+	key string
+	rec *encodeErrors
+}
+
+func (o recordingObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	err := o.m.MarshalLogObject(enc)
+	if err != nil {
+		o.rec.record(o.key, err)
+	}
+	return err
+}
+
+type recordingArray struct {
+	m   zapcore.ArrayMarshaler
+	key string
+	rec *encodeErrors
+}
+
+func (a recordingArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	err := a.m.MarshalLogArray(enc)
+	if err != nil {
+		a.rec.record(a.key, err)
+	}
+	return err
+}
+
+// recordingJSON 按zap的方式(不转义HTML字符)序列化反射字段并记录错误
+type recordingJSON struct {
+	v   interface{}
+	key string
+	rec *encodeErrors
+}
+
+func (j recordingJSON) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(j.v); err != nil {
+		j.rec.record(j.key, err)
+		return nil, err
+	}
+	return bytes.TrimRight(b.Bytes(), "\n"), nil
+}
+
+type recordingStringer struct {
+	s   fmt.Stringer
+	key string
+	rec *encodeErrors
+}
+
+func (s recordingStringer) String() (str string) {
+	defer func() {
+		if r := recover(); r != nil {
+			// 与zap一致，nil指针输出<nil>
+			if v := reflect.ValueOf(s.s); v.Kind() == reflect.Ptr && v.IsNil() {
+				str = "<nil>"
+				return
+			}
+			s.rec.record(s.key, fmt.Errorf("PANIC=%v", r))
+			panic(r)
+		}
+	}()
+	return s.s.String()
+}
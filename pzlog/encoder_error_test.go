@@ -0,0 +1,62 @@
+package pzlog
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"strings"
+	"testing"
+)
+
+func TestOnEncodeError(t *testing.T) {
+	failing := zapcore.ObjectMarshalerFunc(func(zapcore.ObjectEncoder) error { return errors.New("broken") })
+	tests := []struct {
+		name  string
+		field zap.Field
+	}{
+		{"reflect", zap.Any("ch", make(chan int))},
+		{"object", zap.Object("obj", failing)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, out := newCapturedConfig()
+			config.OnEncodeError = EncodeErrorDrop
+			logger := GetLogger(config)
+			logger.Info("bad", tt.field)
+			logger.Info("good", zap.Int("n", 1))
+			if lines := out.Lines(); len(lines) != 1 || !strings.Contains(lines[0], `"good"`) {
+				t.Errorf("drop: lines = %q, want only the good entry", lines)
+			}
+
+			config, out = newCapturedConfig()
+			config.OnEncodeError = EncodeErrorFallback
+			GetLogger(config).Info("bad", zap.String("user", "u1"), tt.field)
+			e := out.Entries(t)[0]
+			if e["msg"] != "bad" || e["encode_error"] == nil || e["user"] != nil {
+				t.Errorf("fallback: entry = %v, want only msg and encode_error", e)
+			}
+
+			config, _ = newCapturedConfig()
+			config.OnEncodeError = EncodeErrorPanic
+			logger = GetLogger(config)
+			func() {
+				defer func() {
+					if r := recover(); r == nil {
+						t.Error("panic: logging an un-encodable field did not panic")
+					}
+				}()
+				logger.Info("bad", tt.field)
+			}()
+
+			// 未设置时保持zap的默认行为
+			config, out = newCapturedConfig()
+			GetLogger(config).Info("bad", tt.field)
+			if e := out.Entries(t)[0]; e[tt.field.Key+"Error"] == nil {
+				t.Errorf("default: entry = %v, want %sError", e, tt.field.Key)
+			}
+		})
+	}
+	if _, err := GetLoggerE(&PzlogConfig{Output: "none", OnEncodeError: "ignore"}); err == nil {
+		t.Error("unknown onencodeerror accepted")
+	}
+}
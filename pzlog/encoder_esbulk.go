@@ -0,0 +1,43 @@
+package pzlog
+
+import (
+	"encoding/json"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"time"
+)
+
+var esBulkPool = buffer.NewPool()
+
+// esBulkEncoder 面向Elasticsearch _bulk接口的NDJSON Encoder，每条json日志之前输出一行index动作
+type esBulkEncoder struct {
+	zapcore.Encoder
+	action []byte
+}
+
+// newESBulkEncoder 创建es-bulk格式的Encoder，index不为空时写入动作行的_index
+func newESBulkEncoder(index string, formatTime func(time.Time) string, encodeDuration zapcore.DurationEncoder) zapcore.Encoder {
+	action := []byte(`{"index":{}}`)
+	if index != "" {
+		data, _ := json.Marshal(map[string]map[string]string{"index": {"_index": index}})
+		action = data
+	}
+	action = append(action, '\n')
+	return &esBulkEncoder{Encoder: getEncoder("json", formatTime, encodeDuration, cEncodeLevel), action: action}
+}
+
+func (e *esBulkEncoder) Clone() zapcore.Encoder {
+	return &esBulkEncoder{Encoder: e.Encoder.Clone(), action: e.action}
+}
+
+func (e *esBulkEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	source, err := e.Encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return nil, err
+	}
+	buf := esBulkPool.Get()
+	_, _ = buf.Write(e.action)
+	_, _ = buf.Write(source.Bytes())
+	source.Free()
+	return buf, nil
+}
@@ -0,0 +1,84 @@
+package pzlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// flatEncoder 将所有字段以key=value的形式追加到消息中，外层只保留时间、级别、调用位置和消息
+type flatEncoder struct {
+	*mapEncoder
+	inner zapcore.Encoder
+}
+
+func newFlatEncoder(inner zapcore.Encoder) *flatEncoder {
+	return &flatEncoder{mapEncoder: newMapEncoder(), inner: inner}
+}
+
+func (e *flatEncoder) Clone() zapcore.Encoder {
+	return &flatEncoder{mapEncoder: e.clone(), inner: e.inner}
+}
+
+func (e *flatEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	enc := e.withFields(fields)
+	if len(enc.Fields) > 0 {
+		var sb strings.Builder
+		sb.WriteString(entry.Message)
+		appendKeyValues(&sb, "", enc.Fields)
+		entry.Message = sb.String()
+	}
+	return e.inner.EncodeEntry(entry, nil)
+}
+
+// appendKeyValues 按key排序追加 key=value，命名空间和对象以prefix.key的形式展开
+func appendKeyValues(sb *strings.Builder, prefix string, fields map[string]interface{}) {
+	for _, k := range sortedKeys(fields) {
+		v := fields[k]
+		if ns, ok := v.(map[string]interface{}); ok {
+			appendKeyValues(sb, prefix+k+".", ns)
+			continue
+		}
+		sb.WriteByte(' ')
+		sb.WriteString(prefix)
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(quoteValue(formatValue(v)))
+	}
+}
+
+// formatValue 将MapObjectEncoder中保存的值格式化为文本
+func formatValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case []byte:
+		return string(val)
+	case time.Time:
+		return val.Format(logTmFmt)
+	case time.Duration:
+		return val.String()
+	case complex128, complex64:
+		return strings.Trim(fmt.Sprint(val), "()")
+	case bool, int, int64, int32, int16, int8, uint, uint64, uint32, uint16, uint8, uintptr, float64, float32:
+		return fmt.Sprint(val)
+	default:
+		data, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Sprint(val)
+		}
+		return string(data)
+	}
+}
+
+// quoteValue 值中包含空白、等号或引号时加引号
+func quoteValue(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\r\n=\"") {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,106 @@
+package pzlog
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"math"
+)
+
+// NaN/Inf浮点数的编码方式
+const (
+	// NaNAsNull 编码为null
+	NaNAsNull = "null"
+	// NaNAsString 编码为字符串"NaN"、"+Inf"、"-Inf"
+	NaNAsString = "string"
+	// NaNSkip 不输出该字段
+	NaNSkip = "skip"
+)
+
+// nanEncoder 按配置处理值为NaN或Inf的float字段，只处理顶层字段，数组和对象中的值不受影响
+type nanEncoder struct {
+	zapcore.Encoder
+	mode string
+}
+
+func newNaNEncoder(enc zapcore.Encoder, mode string) *nanEncoder {
+	return &nanEncoder{Encoder: enc, mode: mode}
+}
+
+func (e *nanEncoder) Clone() zapcore.Encoder {
+	return &nanEncoder{Encoder: e.Encoder.Clone(), mode: e.mode}
+}
+
+func (e *nanEncoder) AddFloat64(key string, value float64) {
+	if f, ok := e.replace(key, value); ok {
+		f.AddTo(e.Encoder)
+		return
+	}
+	e.Encoder.AddFloat64(key, value)
+}
+
+func (e *nanEncoder) AddFloat32(key string, value float32) {
+	if f, ok := e.replace(key, float64(value)); ok {
+		f.AddTo(e.Encoder)
+		return
+	}
+	e.Encoder.AddFloat32(key, value)
+}
+
+func (e *nanEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	var fs []zapcore.Field
+	for i, f := range fields {
+		var value float64
+		switch f.Type {
+		case zapcore.Float64Type:
+			value = math.Float64frombits(uint64(f.Integer))
+		case zapcore.Float32Type:
+			value = float64(math.Float32frombits(uint32(f.Integer)))
+		default:
+			if fs != nil {
+				fs = append(fs, f)
+			}
+			continue
+		}
+		nf, ok := e.replace(f.Key, value)
+		if !ok {
+			if fs != nil {
+				fs = append(fs, f)
+			}
+			continue
+		}
+		if fs == nil {
+			fs = make([]zapcore.Field, 0, len(fields))
+			fs = append(fs, fields[:i]...)
+		}
+		fs = append(fs, nf)
+	}
+	if fs == nil {
+		fs = fields
+	}
+	return e.Encoder.EncodeEntry(entry, fs)
+}
+
+// replace 返回NaN或Inf值替换后的字段，值为普通浮点数时返回false
+func (e *nanEncoder) replace(key string, value float64) (zapcore.Field, bool) {
+	if !math.IsNaN(value) && !math.IsInf(value, 0) {
+		return zapcore.Field{}, false
+	}
+	switch e.mode {
+	case NaNAsNull:
+		return zap.Reflect(key, nil), true
+	case NaNSkip:
+		return zap.Skip(), true
+	default:
+		var s string
+		switch {
+		case math.IsNaN(value):
+			s = "NaN"
+		case math.IsInf(value, 1):
+			s = "+Inf"
+		default:
+			s = "-Inf"
+		}
+		return zap.String(key, s), true
+	}
+}
@@ -0,0 +1,18 @@
+package pzlog
+
+import (
+	"go.uber.org/zap/zapcore"
+	"time"
+)
+
+// newLokiEncoder 创建面向Grafana Loki的json Encoder。
+// 顶层只保留level、service等低基数的字段(以及ts、msg、caller_line)，便于作为标签提取，
+// 其余所有字段都放在metadata对象中。
+func newLokiEncoder(service string, formatTime func(time.Time) string, encodeDuration zapcore.DurationEncoder) zapcore.Encoder {
+	enc := getEncoder("json", formatTime, encodeDuration, cEncodeLevel)
+	if service != "" {
+		enc.AddString("service", service)
+	}
+	enc.OpenNamespace("metadata")
+	return enc
+}
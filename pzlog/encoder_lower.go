@@ -0,0 +1,155 @@
+package pzlog
+
+import (
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"strings"
+	"time"
+)
+
+// lowerKeyEncoder 将字段的key转换为小写，可选将消息转换为小写。对象字段递归处理，数组和反射字段(例如map)内部的key不处理
+type lowerKeyEncoder struct {
+	lowerKeyObjectEncoder
+	enc     zapcore.Encoder
+	message bool
+}
+
+func newLowerKeyEncoder(enc zapcore.Encoder, message bool) *lowerKeyEncoder {
+	return &lowerKeyEncoder{lowerKeyObjectEncoder: lowerKeyObjectEncoder{enc}, enc: enc, message: message}
+}
+
+func (e *lowerKeyEncoder) Clone() zapcore.Encoder {
+	return newLowerKeyEncoder(e.enc.Clone(), e.message)
+}
+
+func (e *lowerKeyEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	if e.message {
+		entry.Message = strings.ToLower(entry.Message)
+	}
+	fs := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		f.Key = strings.ToLower(f.Key)
+		switch f.Type {
+		case zapcore.ObjectMarshalerType, zapcore.InlineMarshalerType:
+			if obj, ok := f.Interface.(zapcore.ObjectMarshaler); ok {
+				f.Interface = lowerKeyObject{obj}
+			}
+		}
+		fs[i] = f
+	}
+	return e.enc.EncodeEntry(entry, fs)
+}
+
+// lowerKeyObject 序列化时将对象内部的key转换为小写
+type lowerKeyObject struct {
+	zapcore.ObjectMarshaler
+}
+
+func (o lowerKeyObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return o.ObjectMarshaler.MarshalLogObject(lowerKeyObjectEncoder{enc})
+}
+
+// lowerKeyObjectEncoder 将key转换为小写后写入内部的ObjectEncoder
+type lowerKeyObjectEncoder struct {
+	zapcore.ObjectEncoder
+}
+
+func (e lowerKeyObjectEncoder) AddArray(key string, arr zapcore.ArrayMarshaler) error {
+	return e.ObjectEncoder.AddArray(strings.ToLower(key), arr)
+}
+
+func (e lowerKeyObjectEncoder) AddObject(key string, obj zapcore.ObjectMarshaler) error {
+	return e.ObjectEncoder.AddObject(strings.ToLower(key), lowerKeyObject{obj})
+}
+
+func (e lowerKeyObjectEncoder) AddBinary(key string, value []byte) {
+	e.ObjectEncoder.AddBinary(strings.ToLower(key), value)
+}
+
+func (e lowerKeyObjectEncoder) AddByteString(key string, value []byte) {
+	e.ObjectEncoder.AddByteString(strings.ToLower(key), value)
+}
+
+func (e lowerKeyObjectEncoder) AddBool(key string, value bool) {
+	e.ObjectEncoder.AddBool(strings.ToLower(key), value)
+}
+
+func (e lowerKeyObjectEncoder) AddComplex128(key string, value complex128) {
+	e.ObjectEncoder.AddComplex128(strings.ToLower(key), value)
+}
+
+func (e lowerKeyObjectEncoder) AddComplex64(key string, value complex64) {
+	e.ObjectEncoder.AddComplex64(strings.ToLower(key), value)
+}
+
+func (e lowerKeyObjectEncoder) AddDuration(key string, value time.Duration) {
+	e.ObjectEncoder.AddDuration(strings.ToLower(key), value)
+}
+
+func (e lowerKeyObjectEncoder) AddFloat64(key string, value float64) {
+	e.ObjectEncoder.AddFloat64(strings.ToLower(key), value)
+}
+
+func (e lowerKeyObjectEncoder) AddFloat32(key string, value float32) {
+	e.ObjectEncoder.AddFloat32(strings.ToLower(key), value)
+}
+
+func (e lowerKeyObjectEncoder) AddInt(key string, value int) {
+	e.ObjectEncoder.AddInt(strings.ToLower(key), value)
+}
+
+func (e lowerKeyObjectEncoder) AddInt64(key string, value int64) {
+	e.ObjectEncoder.AddInt64(strings.ToLower(key), value)
+}
+
+func (e lowerKeyObjectEncoder) AddInt32(key string, value int32) {
+	e.ObjectEncoder.AddInt32(strings.ToLower(key), value)
+}
+
+func (e lowerKeyObjectEncoder) AddInt16(key string, value int16) {
+	e.ObjectEncoder.AddInt16(strings.ToLower(key), value)
+}
+
+func (e lowerKeyObjectEncoder) AddInt8(key string, value int8) {
+	e.ObjectEncoder.AddInt8(strings.ToLower(key), value)
+}
+
+func (e lowerKeyObjectEncoder) AddString(key, value string) {
+	e.ObjectEncoder.AddString(strings.ToLower(key), value)
+}
+
+func (e lowerKeyObjectEncoder) AddTime(key string, value time.Time) {
+	e.ObjectEncoder.AddTime(strings.ToLower(key), value)
+}
+
+func (e lowerKeyObjectEncoder) AddUint(key string, value uint) {
+	e.ObjectEncoder.AddUint(strings.ToLower(key), value)
+}
+
+func (e lowerKeyObjectEncoder) AddUint64(key string, value uint64) {
+	e.ObjectEncoder.AddUint64(strings.ToLower(key), value)
+}
+
+func (e lowerKeyObjectEncoder) AddUint32(key string, value uint32) {
+	e.ObjectEncoder.AddUint32(strings.ToLower(key), value)
+}
+
+func (e lowerKeyObjectEncoder) AddUint16(key string, value uint16) {
+	e.ObjectEncoder.AddUint16(strings.ToLower(key), value)
+}
+
+func (e lowerKeyObjectEncoder) AddUint8(key string, value uint8) {
+	e.ObjectEncoder.AddUint8(strings.ToLower(key), value)
+}
+
+func (e lowerKeyObjectEncoder) AddUintptr(key string, value uintptr) {
+	e.ObjectEncoder.AddUintptr(strings.ToLower(key), value)
+}
+
+func (e lowerKeyObjectEncoder) AddReflected(key string, value interface{}) error {
+	return e.ObjectEncoder.AddReflected(strings.ToLower(key), value)
+}
+
+func (e lowerKeyObjectEncoder) OpenNamespace(key string) {
+	e.ObjectEncoder.OpenNamespace(strings.ToLower(key))
+}
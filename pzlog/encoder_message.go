@@ -0,0 +1,35 @@
+package pzlog
+
+import (
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"strconv"
+	"unicode/utf8"
+)
+
+// messageCapEncoder 截断超过max字节的日志消息，并追加被截断字节数的标记，字段不受影响
+type messageCapEncoder struct {
+	zapcore.Encoder
+	max int
+}
+
+func (e *messageCapEncoder) Clone() zapcore.Encoder {
+	return &messageCapEncoder{Encoder: e.Encoder.Clone(), max: e.max}
+}
+
+func (e *messageCapEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	entry.Message = truncateMessage(entry.Message, e.max)
+	return e.Encoder.EncodeEntry(entry, fields)
+}
+
+// truncateMessage 在不截断UTF-8字符的前提下保留msg的前max字节，并追加"...(truncated N bytes)"
+func truncateMessage(msg string, max int) string {
+	if len(msg) <= max {
+		return msg
+	}
+	n := max
+	for n > 0 && !utf8.RuneStart(msg[n]) {
+		n--
+	}
+	return msg[:n] + "...(truncated " + strconv.Itoa(len(msg)-n) + " bytes)"
+}
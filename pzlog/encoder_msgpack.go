@@ -0,0 +1,269 @@
+package pzlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+var msgpackPool = buffer.NewPool()
+
+// msgpackEncoder 将日志编码为msgpack格式，字段结构与json格式一致
+type msgpackEncoder struct {
+	*mapEncoder
+	formatTime func(time.Time) string
+}
+
+func newMsgpackEncoder(formatTime func(time.Time) string) *msgpackEncoder {
+	return &msgpackEncoder{mapEncoder: newMapEncoder(), formatTime: formatTime}
+}
+
+func (e *msgpackEncoder) Clone() zapcore.Encoder {
+	return &msgpackEncoder{mapEncoder: e.clone(), formatTime: e.formatTime}
+}
+
+func (e *msgpackEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	enc := e.withFields(fields)
+
+	keys := make([]string, 0, 6)
+	values := make([]interface{}, 0, 6)
+	keys = append(keys, "level")
+	values = append(values, entry.Level.CapitalString())
+	keys = append(keys, "ts")
+	values = append(values, e.formatTime(entry.Time))
+	if entry.LoggerName != "" {
+		keys = append(keys, "logger")
+		values = append(values, entry.LoggerName)
+	}
+	if entry.Caller.Defined {
+		keys = append(keys, "caller_line")
+		values = append(values, entry.Caller.TrimmedPath())
+	}
+	keys = append(keys, "msg")
+	values = append(values, entry.Message)
+	if entry.Stack != "" {
+		keys = append(keys, "stacktrace")
+		values = append(values, entry.Stack)
+	}
+
+	buf := msgpackPool.Get()
+	fieldKeys := sortedKeys(enc.Fields)
+	writeMsgpackMapHeader(buf, len(keys)+len(fieldKeys))
+	for i, k := range keys {
+		writeMsgpackString(buf, k)
+		if err := writeMsgpackValue(buf, values[i]); err != nil {
+			buf.Free()
+			return nil, err
+		}
+	}
+	for _, k := range fieldKeys {
+		writeMsgpackString(buf, k)
+		if err := writeMsgpackValue(buf, enc.Fields[k]); err != nil {
+			buf.Free()
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeMsgpackValue 将MapObjectEncoder中保存的值编码为msgpack
+func writeMsgpackValue(buf *buffer.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		buf.AppendByte(0xc0)
+	case bool:
+		if v {
+			buf.AppendByte(0xc3)
+		} else {
+			buf.AppendByte(0xc2)
+		}
+	case string:
+		writeMsgpackString(buf, v)
+	case []byte:
+		writeMsgpackBinary(buf, v)
+	case int:
+		writeMsgpackInt(buf, int64(v))
+	case int64:
+		writeMsgpackInt(buf, v)
+	case int32:
+		writeMsgpackInt(buf, int64(v))
+	case int16:
+		writeMsgpackInt(buf, int64(v))
+	case int8:
+		writeMsgpackInt(buf, int64(v))
+	case uint:
+		writeMsgpackUint(buf, uint64(v))
+	case uint64:
+		writeMsgpackUint(buf, v)
+	case uint32:
+		writeMsgpackUint(buf, uint64(v))
+	case uint16:
+		writeMsgpackUint(buf, uint64(v))
+	case uint8:
+		writeMsgpackUint(buf, uint64(v))
+	case uintptr:
+		writeMsgpackUint(buf, uint64(v))
+	case float64:
+		buf.AppendByte(0xcb)
+		writeBigEndian(buf, math.Float64bits(v), 8)
+	case float32:
+		buf.AppendByte(0xca)
+		writeBigEndian(buf, uint64(math.Float32bits(v)), 4)
+	case complex128:
+		writeMsgpackString(buf, strings.Trim(fmt.Sprint(v), "()"))
+	case complex64:
+		writeMsgpackString(buf, strings.Trim(fmt.Sprint(v), "()"))
+	case time.Duration:
+		return writeMsgpackValue(buf, v.Seconds())
+	case time.Time:
+		writeMsgpackString(buf, v.Format(logTmFmt))
+	case []interface{}:
+		writeMsgpackArrayHeader(buf, len(v))
+		for _, item := range v {
+			if err := writeMsgpackValue(buf, item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		keys := sortedKeys(v)
+		writeMsgpackMapHeader(buf, len(keys))
+		for _, k := range keys {
+			writeMsgpackString(buf, k)
+			if err := writeMsgpackValue(buf, v[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		// 其他类型先转换为json结构，保证与json格式一致
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		var generic interface{}
+		if err := json.Unmarshal(data, &generic); err != nil {
+			return err
+		}
+		return writeMsgpackValue(buf, generic)
+	}
+	return nil
+}
+
+func writeBigEndian(buf *buffer.Buffer, v uint64, size int) {
+	for i := size - 1; i >= 0; i-- {
+		buf.AppendByte(byte(v >> (8 * uint(i))))
+	}
+}
+
+func writeMsgpackInt(buf *buffer.Buffer, v int64) {
+	switch {
+	case v >= 0:
+		writeMsgpackUint(buf, uint64(v))
+	case v >= -32:
+		buf.AppendByte(byte(v))
+	case v >= math.MinInt8:
+		buf.AppendByte(0xd0)
+		writeBigEndian(buf, uint64(v), 1)
+	case v >= math.MinInt16:
+		buf.AppendByte(0xd1)
+		writeBigEndian(buf, uint64(v), 2)
+	case v >= math.MinInt32:
+		buf.AppendByte(0xd2)
+		writeBigEndian(buf, uint64(v), 4)
+	default:
+		buf.AppendByte(0xd3)
+		writeBigEndian(buf, uint64(v), 8)
+	}
+}
+
+func writeMsgpackUint(buf *buffer.Buffer, v uint64) {
+	switch {
+	case v <= 0x7f:
+		buf.AppendByte(byte(v))
+	case v <= math.MaxUint8:
+		buf.AppendByte(0xcc)
+		writeBigEndian(buf, v, 1)
+	case v <= math.MaxUint16:
+		buf.AppendByte(0xcd)
+		writeBigEndian(buf, v, 2)
+	case v <= math.MaxUint32:
+		buf.AppendByte(0xce)
+		writeBigEndian(buf, v, 4)
+	default:
+		buf.AppendByte(0xcf)
+		writeBigEndian(buf, v, 8)
+	}
+}
+
+func writeMsgpackString(buf *buffer.Buffer, s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		buf.AppendByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		buf.AppendByte(0xd9)
+		writeBigEndian(buf, uint64(n), 1)
+	case n <= math.MaxUint16:
+		buf.AppendByte(0xda)
+		writeBigEndian(buf, uint64(n), 2)
+	default:
+		buf.AppendByte(0xdb)
+		writeBigEndian(buf, uint64(n), 4)
+	}
+	buf.AppendString(s)
+}
+
+func writeMsgpackBinary(buf *buffer.Buffer, b []byte) {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		buf.AppendByte(0xc4)
+		writeBigEndian(buf, uint64(n), 1)
+	case n <= math.MaxUint16:
+		buf.AppendByte(0xc5)
+		writeBigEndian(buf, uint64(n), 2)
+	default:
+		buf.AppendByte(0xc6)
+		writeBigEndian(buf, uint64(n), 4)
+	}
+	_, _ = buf.Write(b)
+}
+
+func writeMsgpackArrayHeader(buf *buffer.Buffer, n int) {
+	switch {
+	case n < 16:
+		buf.AppendByte(0x90 | byte(n))
+	case n <= math.MaxUint16:
+		buf.AppendByte(0xdc)
+		writeBigEndian(buf, uint64(n), 2)
+	default:
+		buf.AppendByte(0xdd)
+		writeBigEndian(buf, uint64(n), 4)
+	}
+}
+
+func writeMsgpackMapHeader(buf *buffer.Buffer, n int) {
+	switch {
+	case n < 16:
+		buf.AppendByte(0x80 | byte(n))
+	case n <= math.MaxUint16:
+		buf.AppendByte(0xde)
+		writeBigEndian(buf, uint64(n), 2)
+	default:
+		buf.AppendByte(0xdf)
+		writeBigEndian(buf, uint64(n), 4)
+	}
+}
@@ -0,0 +1,175 @@
+package pzlog
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// decodeMsgpack 解码msgpack，数值统一转换为float64以便与json解码的结果比较
+func decodeMsgpack(data []byte) (interface{}, []byte, error) {
+	if len(data) == 0 {
+		return nil, nil, fmt.Errorf("unexpected end of data")
+	}
+	b := data[0]
+	data = data[1:]
+	readN := func(n int) uint64 {
+		var v uint64
+		for i := 0; i < n; i++ {
+			v = v<<8 | uint64(data[i])
+		}
+		return v
+	}
+	switch {
+	case b <= 0x7f:
+		return float64(b), data, nil
+	case b >= 0xe0:
+		return float64(int8(b)), data, nil
+	case b&0xe0 == 0xa0:
+		n := int(b & 0x1f)
+		return string(data[:n]), data[n:], nil
+	case b&0xf0 == 0x90:
+		return decodeMsgpackArray(data, int(b&0x0f))
+	case b&0xf0 == 0x80:
+		return decodeMsgpackMap(data, int(b&0x0f))
+	}
+	switch b {
+	case 0xc0:
+		return nil, data, nil
+	case 0xc2:
+		return false, data, nil
+	case 0xc3:
+		return true, data, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n := 1 << (b - 0xcc)
+		return float64(readN(n)), data[n:], nil
+	case 0xd0:
+		return float64(int8(data[0])), data[1:], nil
+	case 0xd1:
+		return float64(int16(binary.BigEndian.Uint16(data))), data[2:], nil
+	case 0xd2:
+		return float64(int32(binary.BigEndian.Uint32(data))), data[4:], nil
+	case 0xd3:
+		return float64(int64(binary.BigEndian.Uint64(data))), data[8:], nil
+	case 0xca:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data))), data[4:], nil
+	case 0xcb:
+		return math.Float64frombits(binary.BigEndian.Uint64(data)), data[8:], nil
+	case 0xd9, 0xda, 0xdb:
+		size := 1 << (b - 0xd9)
+		n := int(readN(size))
+		return string(data[size : size+n]), data[size+n:], nil
+	case 0xdc:
+		return decodeMsgpackArray(data[2:], int(readN(2)))
+	case 0xde:
+		return decodeMsgpackMap(data[2:], int(readN(2)))
+	}
+	return nil, nil, fmt.Errorf("unsupported msgpack type 0x%x", b)
+}
+
+func decodeMsgpackArray(data []byte, n int) (interface{}, []byte, error) {
+	arr := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		v, rest, err := decodeMsgpack(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		arr = append(arr, v)
+		data = rest
+	}
+	return arr, data, nil
+}
+
+func decodeMsgpackMap(data []byte, n int) (interface{}, []byte, error) {
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		k, rest, err := decodeMsgpack(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		v, rest, err := decodeMsgpack(rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		m[k.(string)] = v
+		data = rest
+	}
+	return m, data, nil
+}
+
+type msgpackUser struct {
+	Name  string   `json:"name"`
+	Roles []string `json:"roles"`
+}
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	formatTime := func(t time.Time) string { return t.UTC().Format(logTmFmt) }
+	entry := zapcore.Entry{
+		Level:      zapcore.WarnLevel,
+		Time:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		LoggerName: "db",
+		Message:    "slow query",
+	}
+	fields := []zapcore.Field{
+		zap.String("table", "users"),
+		zap.Int("rows", 300),
+		zap.Int64("offset", -70000),
+		zap.Float64("ratio", 0.25),
+		zap.Bool("cached", false),
+		zap.Duration("cost", 1500*time.Millisecond),
+		zap.Ints("ids", []int{1, 2, 3}),
+		zap.Any("user", msgpackUser{Name: "alice", Roles: []string{"admin"}}),
+		zap.Namespace("ctx"),
+		zap.String("trace", "abc"),
+	}
+
+	mp := newMsgpackEncoder(formatTime)
+	buf, err := mp.EncodeEntry(entry, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, rest, err := decodeMsgpack(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 0 {
+		t.Errorf("%d trailing bytes", len(rest))
+	}
+
+	js := getEncoder("json", formatTime, zapcore.SecondsDurationEncoder, cEncodeLevel)
+	jbuf, err := js.EncodeEntry(entry, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want map[string]interface{}
+	if err := json.Unmarshal(jbuf.Bytes(), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, want) {
+		t.Errorf("msgpack decoded to\n%v\nwant (json)\n%v", decoded, want)
+	}
+}
+
+func TestMsgpackEncoderOutput(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.Encoder = "msgpack"
+	GetLogger(config).Info("hello", zap.String("k", "v"))
+	lines := out.Lines()
+	if len(lines) != 1 {
+		t.Fatalf("got %d entries, want 1", len(lines))
+	}
+	decoded, _, err := decodeMsgpack([]byte(lines[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := decoded.(map[string]interface{})
+	if m["msg"] != "hello" || m["k"] != "v" || m["level"] != "INFO" {
+		t.Errorf("unexpected entry %v", m)
+	}
+}
@@ -0,0 +1,47 @@
+package pzlog
+
+import (
+	"go.uber.org/zap/zapcore"
+	"time"
+)
+
+// onelineLevelNames console-oneline格式中三个字母的日志级别
+var onelineLevelNames = map[zapcore.Level]string{
+	zapcore.DebugLevel:  "DBG",
+	zapcore.InfoLevel:   "INF",
+	zapcore.WarnLevel:   "WRN",
+	zapcore.ErrorLevel:  "ERR",
+	zapcore.DPanicLevel: "DPN",
+	zapcore.PanicLevel:  "PNC",
+	zapcore.FatalLevel:  "FTL",
+}
+
+// newOnelineEncoder 创建适合CI日志的紧凑格式"15:04:05 INF msg key=value"，只显示时分秒，不显示调用位置，
+// 字段按key排序以key=value的形式追加在消息之后
+func newOnelineEncoder(location *time.Location) zapcore.Encoder {
+	if location == nil {
+		location = time.Local
+	}
+	return newFlatEncoder(zapcore.NewConsoleEncoder(zapcore.EncoderConfig{
+		TimeKey:       "ts",
+		LevelKey:      "level",
+		NameKey:       "logger",
+		CallerKey:     zapcore.OmitKey,
+		FunctionKey:   zapcore.OmitKey,
+		MessageKey:    "msg",
+		StacktraceKey: "stacktrace",
+		LineEnding:    zapcore.DefaultLineEnding,
+		EncodeLevel: func(level zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+			name, ok := onelineLevelNames[level]
+			if !ok {
+				name = level.CapitalString()
+			}
+			enc.AppendString(name)
+		},
+		EncodeTime: func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+			enc.AppendString(t.In(location).Format("15:04:05"))
+		},
+		EncodeDuration:   zapcore.StringDurationEncoder,
+		ConsoleSeparator: " ",
+	}))
+}
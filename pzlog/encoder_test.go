@@ -0,0 +1,522 @@
+package pzlog
+
+import (
+	"encoding/json"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"math"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLevelNumber(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.LogLevel = "debug"
+	config.LevelNumber = true
+	logger := GetLogger(config)
+	logger.Debug("d")
+	logger.Info("i")
+	logger.Warn("w")
+	logger.Error("e")
+	want := []zapcore.Level{zapcore.DebugLevel, zapcore.InfoLevel, zapcore.WarnLevel, zapcore.ErrorLevel}
+	entries := out.Entries(t)
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(want))
+	}
+	for i, entry := range entries {
+		if entry["level"] != want[i].CapitalString() {
+			t.Errorf("level = %v, want %s", entry["level"], want[i].CapitalString())
+		}
+		if entry["level_num"] != float64(want[i]) {
+			t.Errorf("level_num = %v, want %d", entry["level_num"], want[i])
+		}
+	}
+}
+
+func TestLevelVerbose(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.LevelVerbose = true
+	logger := GetLogger(config)
+	logger.Warn("w")
+	logger.Error("e")
+	entries := out.Entries(t)
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if entries[0]["level"] != "WARN" || entries[0]["level_verbose"] != "warning" {
+		t.Errorf("warn entry level = %v, level_verbose = %v, want WARN and warning", entries[0]["level"], entries[0]["level_verbose"])
+	}
+	if entries[1]["level"] != "ERROR" || entries[1]["level_verbose"] != "error" {
+		t.Errorf("error entry level = %v, level_verbose = %v, want ERROR and error", entries[1]["level"], entries[1]["level_verbose"])
+	}
+}
+
+func TestConsoleLevelShort(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.Encoder = "console"
+	config.LogLevel = "debug"
+	config.ConsoleLevelFormat = ConsoleLevelShort
+	logger := GetLogger(config)
+	logger.Debug("d")
+	logger.Info("i")
+	logger.Warn("w")
+	logger.Error("e")
+	logger.DPanic("dp")
+	func() {
+		defer func() { _ = recover() }()
+		logger.Panic("p")
+	}()
+
+	want := []string{"D", "I", "W", "E", "P", "P"}
+	lines := out.Lines()
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d", len(lines), len(want))
+	}
+	for i, line := range lines {
+		if fields := strings.Split(line, "\t"); len(fields) < 2 || fields[1] != want[i] {
+			t.Errorf("line %q, want level %q", line, want[i])
+		}
+	}
+
+	buf, err := newEncoder(config, "console").EncodeEntry(zapcore.Entry{Level: zapcore.FatalLevel, Message: "f"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fields := strings.Split(buf.String(), "\t"); len(fields) < 2 || fields[1] != "F" {
+		t.Errorf("fatal line %q, want level F", buf.String())
+	}
+}
+
+func TestDedupEntryFields(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.DedupEntryFields = true
+	GetLogger(config).Info("dup", zap.String("user", "a"), zap.Int("n", 1), zap.String("user", "b"))
+
+	line := out.Lines()[0]
+	if n := strings.Count(line, `"user":`); n != 1 {
+		t.Errorf("line %q has %d user keys, want 1", line, n)
+	}
+	if e := out.Entries(t)[0]; e["user"] != "b" || e["n"] != float64(1) {
+		t.Errorf("entry = %v, want the last user value and other fields kept", e)
+	}
+}
+
+func TestOnelineEncoder(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.Encoder = "console-oneline"
+	config.TimeZone = "UTC"
+	config.Clock = &fakeClock{t: time.Date(2024, 1, 1, 9, 5, 7, 0, time.UTC)}
+	logger := GetLogger(config)
+	logger.Warn("disk almost full", zap.Int("used", 95), zap.String("disk", "sda"))
+	logger.Info("plain")
+
+	want := []string{
+		"09:05:07 WRN disk almost full disk=sda used=95",
+		"09:05:07 INF plain",
+	}
+	if got := out.Lines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+}
+
+func TestESBulkEncoder(t *testing.T) {
+	for _, index := range []string{"", "logs-app"} {
+		config := NewDefaultConfig()
+		config.Filename = filepath.Join(t.TempDir(), "bulk.ndjson")
+		config.Encoder = "es-bulk"
+		config.ESIndex = index
+		logger := GetLogger(config)
+		logger.Info("first", zap.Int("n", 1))
+		logger.Warn("second")
+		_ = Close()
+
+		lines := strings.Split(strings.TrimSuffix(readFile(t, config.Filename), "\n"), "\n")
+		if len(lines) != 4 {
+			t.Fatalf("index %q: got %d lines, want 4", index, len(lines))
+		}
+		wantAction := `{"index":{}}`
+		if index != "" {
+			wantAction = `{"index":{"_index":"logs-app"}}`
+		}
+		for i, msg := range []string{"first", "second"} {
+			if lines[2*i] != wantAction {
+				t.Errorf("index %q: line %d = %q, want action %s", index, 2*i, lines[2*i], wantAction)
+			}
+			var source map[string]interface{}
+			if err := json.Unmarshal([]byte(lines[2*i+1]), &source); err != nil || source["msg"] != msg {
+				t.Errorf("index %q: line %d = %q, want the %s source (%v)", index, 2*i+1, lines[2*i+1], msg, err)
+			}
+		}
+	}
+}
+
+func TestLokiEncoder(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.Encoder = "loki"
+	config.Service = "checkout"
+	logger := GetLogger(config)
+	logger.With(zap.String("request_id", "r1")).Info("order placed", zap.Int("items", 3))
+
+	entries := out.Entries(t)
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	entry := entries[0]
+	if entry["level"] != "INFO" || entry["service"] != "checkout" || entry["msg"] != "order placed" {
+		t.Errorf("labels should be top-level: %v", entry)
+	}
+	for _, key := range []string{"request_id", "items"} {
+		if _, ok := entry[key]; ok {
+			t.Errorf("high-cardinality field %q at top level", key)
+		}
+	}
+	metadata, _ := entry["metadata"].(map[string]interface{})
+	if metadata["request_id"] != "r1" || metadata["items"] != float64(3) {
+		t.Errorf("metadata = %v, want request details", entry["metadata"])
+	}
+}
+
+func TestFlattenFields(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.FlattenFields = true
+	logger := GetLogger(config).With(zap.String("service", "billing"))
+	logger.Info("charged", zap.Int("amount", 42), zap.String("note", "two words"), zap.Namespace("req"), zap.String("id", "r1"))
+
+	entries := out.Entries(t)
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	entry := entries[0]
+	want := `charged amount=42 note="two words" req.id=r1 service=billing`
+	if entry["msg"] != want {
+		t.Errorf("msg = %q, want %q", entry["msg"], want)
+	}
+	for _, key := range []string{"amount", "note", "req", "service"} {
+		if _, ok := entry[key]; ok {
+			t.Errorf("field %q should only appear in the message", key)
+		}
+	}
+}
+
+func TestNaNHandling(t *testing.T) {
+	tests := []struct {
+		mode string
+		want interface{}
+		skip bool
+	}{
+		{NaNAsNull, nil, false},
+		{NaNAsString, "NaN", false},
+		{NaNSkip, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			config, out := newCapturedConfig()
+			config.NaNHandling = tt.mode
+			logger := GetLogger(config)
+			logger.With(zap.Float64("base", math.Inf(1))).Info("ratio", zap.Float64("ratio", math.NaN()), zap.Float32("f32", float32(math.Inf(-1))))
+
+			// Entries会在json无效时失败
+			entries := out.Entries(t)
+			if len(entries) != 1 {
+				t.Fatalf("got %d entries, want 1", len(entries))
+			}
+			v, ok := entries[0]["ratio"]
+			if ok == tt.skip || v != tt.want {
+				t.Errorf("ratio = %v (present %v), want %v", v, ok, tt.want)
+			}
+			if tt.mode == NaNAsString && (entries[0]["base"] != "+Inf" || entries[0]["f32"] != "-Inf") {
+				t.Errorf("base = %v, f32 = %v", entries[0]["base"], entries[0]["f32"])
+			}
+		})
+	}
+}
+
+func TestDurationEncoding(t *testing.T) {
+	tests := []struct {
+		mode string
+		want interface{}
+	}{
+		{"", 0.0125},
+		{DurationSeconds, 0.0125},
+		{DurationMillis, 12.0},
+		{DurationNanos, 12500000.0},
+		{DurationString, "12.5ms"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			config, out := newCapturedConfig()
+			config.DurationEncoding = tt.mode
+			GetLogger(config).Info("query", zap.Duration("cost", 12500*time.Microsecond))
+			entries := out.Entries(t)
+			if len(entries) != 1 {
+				t.Fatalf("got %d entries, want 1", len(entries))
+			}
+			if got := entries[0]["cost"]; got != tt.want {
+				t.Errorf("cost = %v (%T), want %v", got, got, tt.want)
+			}
+		})
+	}
+
+	config := NewDefaultConfig()
+	config.DurationEncoding = "hours"
+	if _, err := GetLoggerE(config); err == nil || !strings.Contains(err.Error(), "durationencoding") {
+		t.Errorf("err = %v, want unknown durationencoding", err)
+	}
+}
+
+func TestMaxArrayLength(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.MaxArrayLength = 3
+	logger := GetLogger(config)
+	ids := make([]int, 1000)
+	for i := range ids {
+		ids[i] = i
+	}
+	names := []string{"a", "b", "c", "d"}
+	logger.Info("batch", zap.Ints("ids", ids), zap.Any("names", names), zap.Strings("short", []string{"x"}))
+
+	entries := out.Entries(t)
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	tests := []struct {
+		key  string
+		want []interface{}
+	}{
+		{"ids", []interface{}{float64(0), float64(1), float64(2), "…(+997 more)"}},
+		{"names", []interface{}{"a", "b", "c", "…(+1 more)"}},
+		{"short", []interface{}{"x"}},
+	}
+	for _, tt := range tests {
+		if got := entries[0][tt.key]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestSplitCaller(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.SplitCaller = true
+	GetLogger(config).Info("split")
+
+	entries := out.Entries(t)
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	file, ok := entries[0]["caller_file"].(string)
+	if !ok || !strings.HasSuffix(file, "encoder_test.go") {
+		t.Errorf("caller_file = %#v, want a string ending with encoder_test.go", entries[0]["caller_file"])
+	}
+	line, ok := entries[0]["caller_line"].(float64)
+	if !ok || line <= 0 {
+		t.Errorf("caller_line = %#v, want a positive number", entries[0]["caller_line"])
+	}
+}
+
+func TestOmitEmpty(t *testing.T) {
+	for _, omit := range []bool{false, true} {
+		config, out := newCapturedConfig()
+		config.OmitEmpty = omit
+		GetLogger(config).With(zap.String("empty_with", "")).Info("nil field",
+			zap.Any("k", nil), zap.String("s", ""), zap.Strings("list", nil), zap.String("kept", "v"))
+
+		entries := out.Entries(t)
+		if len(entries) != 1 {
+			t.Fatalf("got %d entries, want 1", len(entries))
+		}
+		entry := entries[0]
+		for _, key := range []string{"k", "s", "empty_with"} {
+			if _, ok := entry[key]; ok == omit {
+				t.Errorf("OmitEmpty %v: field %q present = %v", omit, key, ok)
+			}
+		}
+		if !omit && entry["k"] != nil {
+			t.Errorf("k = %v, want null", entry["k"])
+		}
+		if entry["kept"] != "v" {
+			t.Errorf("OmitEmpty %v: kept = %v", omit, entry["kept"])
+		}
+	}
+}
+
+func TestControlChars(t *testing.T) {
+	tests := []struct {
+		mode string
+		msg  string
+		user string
+	}{
+		// console格式的字段部分为json，转义后的反斜杠会再次被转义
+		{ControlCharsEscape, `red \x1b[31mtext`, `bob\\x07`},
+		{ControlCharsStrip, "red [31mtext", "bob"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			config, out := newCapturedConfig()
+			config.Encoder = "console"
+			config.ControlChars = tt.mode
+			GetLogger(config).Info("red \x1b[31mtext", zap.String("user", "bob\a"))
+
+			lines := out.Lines()
+			if len(lines) != 1 {
+				t.Fatalf("got %d lines, want 1", len(lines))
+			}
+			if strings.ContainsAny(lines[0], "\x1b\a") {
+				t.Errorf("control characters not neutralized: %q", lines[0])
+			}
+			if !strings.Contains(lines[0], tt.msg) || !strings.Contains(lines[0], `"user": "`+tt.user+`"`) {
+				t.Errorf("line = %q, want message %q and user %q", lines[0], tt.msg, tt.user)
+			}
+		})
+	}
+}
+
+func TestMaxMessageLength(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.MaxMessageLength = 10
+	logger := GetLogger(config)
+	logger.Info(strings.Repeat("a", 25), zap.String("payload", strings.Repeat("b", 25)))
+	logger.Info("short")
+	// 不截断多字节字符
+	logger.Info("日志消息日志消息")
+
+	entries := out.Entries(t)
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	if msg := entries[0]["msg"]; msg != "aaaaaaaaaa...(truncated 15 bytes)" {
+		t.Errorf("msg = %v, want truncated at 10 bytes", msg)
+	}
+	if payload := entries[0]["payload"]; payload != strings.Repeat("b", 25) {
+		t.Errorf("payload = %v, want untouched", payload)
+	}
+	if msg := entries[1]["msg"]; msg != "short" {
+		t.Errorf("msg = %v, want short", msg)
+	}
+	if msg := entries[2]["msg"]; msg != "日志消...(truncated 15 bytes)" {
+		t.Errorf("msg = %v, want truncated at a rune boundary", msg)
+	}
+}
+
+func TestIsErrorField(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.LogLevel = "debug"
+	config.IsErrorField = true
+	logger := GetLogger(config)
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warn")
+	logger.Error("error")
+
+	want := map[string]bool{"debug": false, "info": false, "warn": true, "error": true}
+	entries := out.Entries(t)
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(want))
+	}
+	for _, e := range entries {
+		if e["is_error"] != want[e["msg"].(string)] {
+			t.Errorf("%s: is_error = %v, want %v", e["msg"], e["is_error"], want[e["msg"].(string)])
+		}
+	}
+}
+
+func TestLowercaseKeys(t *testing.T) {
+	for _, message := range []bool{false, true} {
+		config, out := newCapturedConfig()
+		config.LowercaseKeys = true
+		config.LowercaseMessage = message
+		logger := GetLogger(config).With(zap.String("TraceID", "t1"))
+		logger.Info("Hello World",
+			zap.Int("UserID", 1),
+			zap.Object("Req", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+				enc.AddString("Path", "/A")
+				return nil
+			})),
+		)
+
+		e := out.Entries(t)[0]
+		if e["traceid"] != "t1" || e["userid"] != float64(1) {
+			t.Errorf("message=%v: keys not lowercased: %v", message, e)
+		}
+		if req, _ := e["req"].(map[string]interface{}); req["path"] != "/A" {
+			t.Errorf("message=%v: nested object = %v, want lowercased key with value untouched", message, e["req"])
+		}
+		for _, key := range []string{"TraceID", "UserID", "Req"} {
+			if _, ok := e[key]; ok {
+				t.Errorf("message=%v: mixed-case key %q still present", message, key)
+			}
+		}
+		want := "Hello World"
+		if message {
+			want = "hello world"
+		}
+		if e["msg"] != want {
+			t.Errorf("message=%v: msg = %v, want %q", message, e["msg"], want)
+		}
+	}
+}
+
+func TestEncoderBufferSize(t *testing.T) {
+	entry := zapcore.Entry{Level: zapcore.InfoLevel, Message: "large", Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	for _, size := range []int{100, 4000} {
+		fields := []zapcore.Field{zap.String("payload", strings.Repeat("x", size)), zap.Int("n", 1)}
+		plain, err := testJSONEncoder().EncodeEntry(entry, fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sized, err := newBufferSizeEncoder(testJSONEncoder(), 8192).EncodeEntry(entry, fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sized.String() != plain.String() {
+			t.Errorf("size %d: sized output = %q, want %q", size, sized.String(), plain.String())
+		}
+		if sized.Cap() < 8192 {
+			t.Errorf("size %d: buffer cap = %d, want at least 8192", size, sized.Cap())
+		}
+		plain.Free()
+		sized.Free()
+	}
+
+	config, out := newCapturedConfig()
+	config.EncoderBufferSize = 64 * 1024
+	GetLogger(config).Info("sized", zap.String("k", "v"))
+	entries := out.Entries(t)
+	if len(entries) != 1 || entries[0]["msg"] != "sized" || entries[0]["k"] != "v" {
+		t.Errorf("entries = %v, want one sized entry", entries)
+	}
+}
+
+// BenchmarkEncoderBufferSize 每次迭代前清空zap的缓冲池(sync.Pool在两次GC后清空)，
+// 新缓冲区先编码小日志再编码大日志，对比大日志在缓冲区中反复扩容与预先扩容的分配次数
+func BenchmarkEncoderBufferSize(b *testing.B) {
+	small := zapcore.Entry{Level: zapcore.InfoLevel, Message: "small"}
+	large := []zapcore.Field{zap.String("payload", strings.Repeat("x", 32*1024))}
+	for _, bm := range []struct {
+		name string
+		enc  zapcore.Encoder
+	}{
+		{"default", testJSONEncoder()},
+		{"sized", newBufferSizeEncoder(testJSONEncoder(), 64*1024)},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				runtime.GC()
+				runtime.GC()
+				b.StartTimer()
+				for _, fields := range [][]zapcore.Field{nil, large} {
+					buf, err := bm.enc.EncodeEntry(small, fields)
+					if err != nil {
+						b.Fatal(err)
+					}
+					buf.Free()
+				}
+			}
+		})
+	}
+}
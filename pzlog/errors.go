@@ -0,0 +1,96 @@
+package pzlog
+
+import (
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"hash/fnv"
+	"regexp"
+)
+
+// Coder 带有错误码的错误
+type Coder interface {
+	Code() string
+}
+
+// ErrorField 返回记录错误的字段，输出error字段，错误链中有实现Coder的错误时额外输出error_code字段
+func ErrorField(err error) zap.Field {
+	if err == nil {
+		return zap.Skip()
+	}
+	return zap.Inline(codedError{err: err})
+}
+
+type codedError struct {
+	err error
+}
+
+func (e codedError) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("error", e.err.Error())
+	var coder Coder
+	if errors.As(e.err, &coder) {
+		enc.AddString("error_code", coder.Code())
+	}
+	return nil
+}
+
+// 计算错误指纹时替换的易变内容
+var (
+	fingerprintUUID   = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	fingerprintHex    = regexp.MustCompile(`\b(0x[0-9a-fA-F]+|[0-9a-fA-F]{16,})\b`)
+	fingerprintNumber = regexp.MustCompile(`[0-9]+`)
+)
+
+// ErrorFingerprint 返回错误的稳定指纹，用于聚合相似的错误。
+// 错误消息中的UUID、十六进制串和数字(ID、时间戳等)被替换后再计算哈希，只有这些内容不同的错误指纹相同
+func ErrorFingerprint(err error) string {
+	if err == nil {
+		return ""
+	}
+	msg := fingerprintUUID.ReplaceAllLiteralString(err.Error(), "<uuid>")
+	msg = fingerprintHex.ReplaceAllLiteralString(msg, "<hex>")
+	msg = fingerprintNumber.ReplaceAllLiteralString(msg, "<n>")
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(msg))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// errorFingerprintCore 为错误字段追加指纹字段，error字段的指纹为error_fingerprint，其余为<key>_fingerprint
+type errorFingerprintCore struct {
+	zapcore.Core
+}
+
+func (c *errorFingerprintCore) With(fields []zapcore.Field) zapcore.Core {
+	return &errorFingerprintCore{Core: c.Core.With(withFingerprints(fields))}
+}
+
+func (c *errorFingerprintCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *errorFingerprintCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(entry, withFingerprints(fields))
+}
+
+// withFingerprints 在字段之后追加错误字段的指纹字段，没有错误字段时返回原字段
+func withFingerprints(fields []zapcore.Field) []zapcore.Field {
+	var extra []zapcore.Field
+	for _, f := range fields {
+		if f.Type != zapcore.ErrorType {
+			continue
+		}
+		if err, ok := f.Interface.(error); ok && err != nil {
+			extra = append(extra, zap.String(f.Key+"_fingerprint", ErrorFingerprint(err)))
+		}
+	}
+	if len(extra) == 0 {
+		return fields
+	}
+	all := make([]zapcore.Field, 0, len(fields)+len(extra))
+	all = append(all, fields...)
+	return append(all, extra...)
+}
@@ -0,0 +1,80 @@
+package pzlog
+
+import (
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"testing"
+)
+
+type codeError struct {
+	code string
+}
+
+func (e codeError) Error() string { return "quota exceeded" }
+
+func (e codeError) Code() string { return e.code }
+
+func TestErrorField(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		msg  string
+		code interface{}
+	}{
+		{"coded", codeError{code: "E_QUOTA"}, "quota exceeded", "E_QUOTA"},
+		{"wrapped", fmt.Errorf("save: %w", codeError{code: "E_QUOTA"}), "save: quota exceeded", "E_QUOTA"},
+		{"plain", errors.New("boom"), "boom", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, out := newCapturedConfig()
+			GetLogger(config).Error("failed", ErrorField(tt.err))
+			entries := out.Entries(t)
+			if len(entries) != 1 {
+				t.Fatalf("got %d entries, want 1", len(entries))
+			}
+			if entries[0]["error"] != tt.msg || entries[0]["error_code"] != tt.code {
+				t.Errorf("error = %v, error_code = %v, want %q, %v", entries[0]["error"], entries[0]["error_code"], tt.msg, tt.code)
+			}
+		})
+	}
+
+	config, out := newCapturedConfig()
+	GetLogger(config).Info("no error", ErrorField(nil))
+	if entries := out.Entries(t); len(entries) != 1 || entries[0]["error"] != nil {
+		t.Errorf("nil error should be skipped: %v", entries)
+	}
+}
+
+func TestErrorFingerprint(t *testing.T) {
+	a := fmt.Errorf("order 1234 not found for user 550e8400-e29b-41d4-a716-446655440000")
+	b := fmt.Errorf("order 98 not found for user 123e4567-e89b-12d3-a456-426614174000")
+	c := fmt.Errorf("order 1234 already paid")
+	if ErrorFingerprint(a) != ErrorFingerprint(b) {
+		t.Errorf("fingerprints differ for errors differing only by IDs")
+	}
+	if ErrorFingerprint(a) == ErrorFingerprint(c) {
+		t.Errorf("fingerprints equal for different errors")
+	}
+	if ErrorFingerprint(nil) != "" {
+		t.Errorf("nil error fingerprint = %q", ErrorFingerprint(nil))
+	}
+
+	config, out := newCapturedConfig()
+	config.ErrorFingerprint = true
+	logger := GetLogger(config)
+	logger.With(zap.NamedError("cause", b)).Error("failed", zap.Error(a))
+	logger.Info("no error")
+	entries := out.Entries(t)
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	want := ErrorFingerprint(a)
+	if entries[0]["error_fingerprint"] != want || entries[0]["cause_fingerprint"] != want {
+		t.Errorf("entry = %v, want error_fingerprint and cause_fingerprint %s", entries[0], want)
+	}
+	if _, ok := entries[1]["error_fingerprint"]; ok {
+		t.Error("error_fingerprint logged without an error field")
+	}
+}
@@ -0,0 +1,80 @@
+package pzlog
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap/zapcore"
+	"reflect"
+	"runtime"
+)
+
+// errorStack 返回错误链中第一个携带调用栈的错误的调用栈。
+// 通过反射识别StackTrace()方法返回的程序计数器切片(例如github.com/pkg/errors的errors.StackTrace)，pzlog不依赖这些库
+func errorStack(err error) []uintptr {
+	for err != nil {
+		if m := reflect.ValueOf(err).MethodByName("StackTrace"); m.IsValid() && m.Type().NumIn() == 0 && m.Type().NumOut() == 1 {
+			out := m.Call(nil)[0]
+			if out.Kind() == reflect.Slice && out.Type().Elem().Kind() == reflect.Uintptr {
+				pcs := make([]uintptr, out.Len())
+				for i := range pcs {
+					pcs[i] = uintptr(out.Index(i).Uint())
+				}
+				return pcs
+			}
+		}
+		if next := errors.Unwrap(err); next != nil {
+			err = next
+			continue
+		}
+		causer, ok := err.(interface{ Cause() error })
+		if !ok {
+			return nil
+		}
+		err = causer.Cause()
+	}
+	return nil
+}
+
+// stackFrames 调用栈的各帧，记录函数名、文件和行号
+type stackFrames []uintptr
+
+func (s stackFrames) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	frames := runtime.CallersFrames(s)
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" || frame.File != "" {
+			err := enc.AppendObject(zapcore.ObjectMarshalerFunc(func(obj zapcore.ObjectEncoder) error {
+				obj.AddString("func", frame.Function)
+				obj.AddString("file", frame.File)
+				obj.AddInt("line", frame.Line)
+				return nil
+			}))
+			if err != nil {
+				return err
+			}
+		}
+		if !more {
+			return nil
+		}
+	}
+}
+
+// ginErrorStacks gin请求中的错误及其调用栈
+type ginErrorStacks []*gin.Error
+
+func (errs ginErrorStacks) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, e := range errs {
+		e := e
+		err := enc.AppendObject(zapcore.ObjectMarshalerFunc(func(obj zapcore.ObjectEncoder) error {
+			obj.AddString("error", e.Error())
+			if pcs := errorStack(e.Err); len(pcs) > 0 {
+				return obj.AddArray("frames", stackFrames(pcs))
+			}
+			return nil
+		}))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package pzlog
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// frame、stackTrace 与github.com/pkg/errors的Frame、StackTrace结构相同
+type frame uintptr
+
+type stackTrace []frame
+
+// stackError 创建时记录调用栈的错误
+type stackError struct {
+	msg   string
+	stack stackTrace
+}
+
+func newStackError(msg string) error {
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(2, pcs)
+	st := make(stackTrace, n)
+	for i, pc := range pcs[:n] {
+		st[i] = frame(pc)
+	}
+	return &stackError{msg: msg, stack: st}
+}
+
+func (e *stackError) Error() string { return e.msg }
+
+func (e *stackError) StackTrace() stackTrace { return e.stack }
+
+func TestErrorStack(t *testing.T) {
+	err := fmt.Errorf("save: %w", newStackError("disk full"))
+	if pcs := errorStack(err); len(pcs) == 0 {
+		t.Error("no stack found through the wrapped error")
+	}
+	if pcs := errorStack(errors.New("plain")); pcs != nil {
+		t.Errorf("plain error stack = %v, want nil", pcs)
+	}
+}
+
+func TestGinErrorStacks(t *testing.T) {
+	entry := serveGin(t, GinConfig{LogErrorStacks: true}, func(e *gin.Engine) {
+		e.GET("/save", func(c *gin.Context) {
+			_ = c.Error(fmt.Errorf("save: %w", newStackError("disk full")))
+			_ = c.Error(errors.New("plain"))
+			c.Status(http.StatusInternalServerError)
+		})
+	}, httptest.NewRequest(http.MethodGet, "/save", nil))
+
+	stacks, ok := entry["error_stacks"].([]interface{})
+	if !ok || len(stacks) != 2 {
+		t.Fatalf("error_stacks = %v, want two errors", entry["error_stacks"])
+	}
+	first := stacks[0].(map[string]interface{})
+	frames, _ := first["frames"].([]interface{})
+	if first["error"] != "save: disk full" || len(frames) == 0 {
+		t.Fatalf("first error = %v, want frames", first)
+	}
+	top := frames[0].(map[string]interface{})
+	if !strings.HasSuffix(top["file"].(string), "errstack_test.go") || !strings.Contains(top["func"].(string), "TestGinErrorStacks") || top["line"].(float64) <= 0 {
+		t.Errorf("top frame = %v, want the handler in TestGinErrorStacks", top)
+	}
+	if second := stacks[1].(map[string]interface{}); second["error"] != "plain" || second["frames"] != nil {
+		t.Errorf("second error = %v, want no frames", second)
+	}
+}
@@ -0,0 +1,36 @@
+package pzlog
+
+import (
+	"go.uber.org/zap"
+	"sync/atomic"
+)
+
+const defaultEventKey = "event"
+
+// currentEventKey 最近一次GetLogger配置的事件字段名
+var currentEventKey atomic.Value
+
+// setEventKey 设置LogEvent使用的事件字段名，为空时使用event
+func setEventKey(key string) {
+	if key == "" {
+		key = defaultEventKey
+	}
+	currentEventKey.Store(key)
+}
+
+// eventKey 返回LogEvent使用的事件字段名
+func eventKey() string {
+	if key, ok := currentEventKey.Load().(string); ok {
+		return key
+	}
+	return defaultEventKey
+}
+
+// LogEvent 以info级别记录一个结构化事件，事件名写入事件字段(默认event，可通过EventKey配置)而不是消息，消息为空，
+// 便于按事件名分组统计
+func LogEvent(name string, fields ...zap.Field) {
+	fs := make([]zap.Field, 0, len(fields)+1)
+	fs = append(fs, zap.String(eventKey(), name))
+	fs = append(fs, fields...)
+	zap.L().WithOptions(zap.AddCallerSkip(1)).Info("", fs...)
+}
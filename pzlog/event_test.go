@@ -0,0 +1,35 @@
+package pzlog
+
+import (
+	"go.uber.org/zap"
+	"strings"
+	"testing"
+)
+
+func TestLogEvent(t *testing.T) {
+	for _, key := range []string{"", "event_name"} {
+		prev := zap.L()
+		config, out := newCapturedConfig()
+		config.ReplaceGlobals = true
+		config.EventKey = key
+		GetLogger(config)
+		LogEvent("user.signup", zap.String("plan", "pro"))
+		zap.ReplaceGlobals(prev)
+
+		want := key
+		if want == "" {
+			want = "event"
+		}
+		e := out.Entries(t)[0]
+		if e[want] != "user.signup" || e["plan"] != "pro" {
+			t.Errorf("key %q: entry = %v, want %s=user.signup with the fields", key, e, want)
+		}
+		if e["msg"] != "" || e["level"] != "INFO" {
+			t.Errorf("key %q: msg = %v, level = %v, want an empty info message", key, e["msg"], e["level"])
+		}
+		if c, _ := e["caller_line"].(string); !strings.HasPrefix(c, "pzlog/event_test.go:") {
+			t.Errorf("key %q: caller_line = %q, want the LogEvent call site", key, c)
+		}
+	}
+	setEventKey("")
+}
@@ -0,0 +1,67 @@
+package pzlog
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"sort"
+	"sync"
+	"time"
+)
+
+// flagSnapshotTTL FlagSnapshot结果的缓存时间
+const flagSnapshotTTL = time.Second
+
+// flagSnapshotCache 缓存FlagSnapshot的结果，每flagSnapshotTTL最多调用一次，避免每条日志都获取功能开关
+type flagSnapshotCache struct {
+	fn  func() map[string]bool
+	now func() time.Time
+
+	mu     sync.Mutex
+	at     time.Time
+	fields []zapcore.Field
+}
+
+func newFlagSnapshotCache(fn func() map[string]bool, now func() time.Time) *flagSnapshotCache {
+	return &flagSnapshotCache{fn: fn, now: now}
+}
+
+// flagFields 返回flags字段，没有功能开关时不添加字段
+func (c *flagSnapshotCache) flagFields(zapcore.Entry) []zapcore.Field {
+	now := c.now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fields == nil || now.Sub(c.at) >= flagSnapshotTTL {
+		c.at = now
+		c.fields = []zapcore.Field{}
+		if flags := c.fn(); len(flags) > 0 {
+			c.fields = []zapcore.Field{zap.Object("flags", newFlagObject(flags))}
+		}
+	}
+	return c.fields
+}
+
+// flagObject 按名称排序输出的功能开关
+type flagObject struct {
+	names  []string
+	values []bool
+}
+
+func newFlagObject(flags map[string]bool) flagObject {
+	names := make([]string, 0, len(flags))
+	for name := range flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	values := make([]bool, len(names))
+	for i, name := range names {
+		values[i] = flags[name]
+	}
+	return flagObject{names: names, values: values}
+}
+
+func (o flagObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for i, name := range o.names {
+		enc.AddBool(name, o.values[i])
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package pzlog
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFlagSnapshot(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	calls := 0
+	flags := map[string]bool{"new_ui": true, "beta": false}
+	config, out := newCapturedConfig()
+	config.Clock = clock
+	config.FlagSnapshot = func() map[string]bool {
+		calls++
+		return flags
+	}
+	logger := GetLogger(config)
+	logger.Info("a")
+	logger.Info("b")
+	// 缓存过期前修改的开关不会生效
+	flags = map[string]bool{"new_ui": false}
+	logger.Info("c")
+	clock.Add(flagSnapshotTTL)
+	logger.Info("d")
+
+	if calls != 2 {
+		t.Errorf("FlagSnapshot called %d times, want 2", calls)
+	}
+	want := []interface{}{
+		map[string]interface{}{"beta": false, "new_ui": true},
+		map[string]interface{}{"beta": false, "new_ui": true},
+		map[string]interface{}{"beta": false, "new_ui": true},
+		map[string]interface{}{"new_ui": false},
+	}
+	var got []interface{}
+	for _, e := range out.Entries(t) {
+		got = append(got, e["flags"])
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("flags = %v, want %v", got, want)
+	}
+}
+
+func TestFlagSnapshotEmpty(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.FlagSnapshot = func() map[string]bool { return nil }
+	GetLogger(config).Info("a")
+	if _, ok := out.Entries(t)[0]["flags"]; ok {
+		t.Error("flags field added without any feature flag")
+	}
+}
@@ -0,0 +1,60 @@
+package pzlog
+
+import (
+	"go.uber.org/zap"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// defaultGCMonitorInterval MonitorGCPauses默认的检查间隔
+const defaultGCMonitorInterval = 10 * time.Second
+
+// MonitorGCPauses 启动后台协程，每隔interval(默认10秒)通过runtime.ReadMemStats检查新发生的GC，
+// 停顿超过threshold时通过zap.L()记录warn日志(gc_pause_ms、threshold_ms、num_gc)。
+// runtime只保留最近256次GC的停顿时间，两次检查之间超出的部分不会被检查。返回的stop函数用于停止监控
+func MonitorGCPauses(threshold, interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		interval = defaultGCMonitorInterval
+	}
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	last := stats.NumGC
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			runtime.ReadMemStats(&stats)
+			last = logGCPauses(&stats, last, threshold)
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// logGCPauses 记录last之后停顿超过threshold的GC，返回当前的GC次数
+func logGCPauses(stats *runtime.MemStats, last uint32, threshold time.Duration) uint32 {
+	n := stats.NumGC - last
+	if n > uint32(len(stats.PauseNs)) {
+		n = uint32(len(stats.PauseNs))
+	}
+	for i := stats.NumGC - n + 1; i <= stats.NumGC; i++ {
+		pause := time.Duration(stats.PauseNs[(i+uint32(len(stats.PauseNs))-1)%uint32(len(stats.PauseNs))])
+		if pause <= threshold {
+			continue
+		}
+		zap.L().Warn("slow gc pause",
+			zap.Float64("gc_pause_ms", durationMs(pause)),
+			zap.Float64("threshold_ms", durationMs(threshold)),
+			zap.Uint32("num_gc", i),
+		)
+	}
+	return stats.NumGC
+}
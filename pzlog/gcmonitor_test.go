@@ -0,0 +1,54 @@
+package pzlog
+
+import (
+	"go.uber.org/zap/zapcore"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestMonitorGCPauses(t *testing.T) {
+	logs := observeGlobals(t, zapcore.WarnLevel)
+	stop := MonitorGCPauses(0, 10*time.Millisecond)
+	defer stop()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for logs.FilterMessage("slow gc pause").Len() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("no slow gc pause logged with a zero threshold")
+		}
+		runtime.GC()
+		time.Sleep(20 * time.Millisecond)
+	}
+	stop()
+	stop()
+
+	fields := logs.FilterMessage("slow gc pause").All()[0].ContextMap()
+	for _, key := range []string{"gc_pause_ms", "threshold_ms", "num_gc"} {
+		if _, ok := fields[key]; !ok {
+			t.Errorf("gc pause entry misses %q: %v", key, fields)
+		}
+	}
+}
+
+func TestLogGCPausesThreshold(t *testing.T) {
+	logs := observeGlobals(t, zapcore.WarnLevel)
+	var stats runtime.MemStats
+	stats.NumGC = 3
+	stats.PauseNs[0] = uint64(time.Millisecond)
+	stats.PauseNs[1] = uint64(50 * time.Millisecond)
+	stats.PauseNs[2] = uint64(2 * time.Millisecond)
+
+	// 只检查last之后的GC，第2次GC已经检查过
+	if got := logGCPauses(&stats, 2, 10*time.Millisecond); got != 3 {
+		t.Errorf("logGCPauses() = %d, want 3", got)
+	}
+	if n := logs.Len(); n != 0 {
+		t.Fatalf("logged %d entries for pauses under the threshold", n)
+	}
+	logGCPauses(&stats, 0, 10*time.Millisecond)
+	entries := logs.All()
+	if len(entries) != 1 || entries[0].ContextMap()["num_gc"] != uint32(2) {
+		t.Errorf("entries = %v, want only the 50ms pause of gc #2", entries)
+	}
+}
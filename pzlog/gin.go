@@ -0,0 +1,738 @@
+package pzlog
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultMaxBodySize    = 4096
+	defaultMaxContextKeys = 32
+)
+
+// GinVerbosity 控制gin日志额外记录的内容
+type GinVerbosity struct {
+	// 记录请求头(headers)，敏感请求头会被脱敏
+	Headers bool
+
+	// 记录请求体(body)，只读取前MaxBodySize字节，RedactKeys中的字段会被脱敏
+	Body bool
+
+	// 记录路由参数(params)
+	Params bool
+}
+
+// GinPathRule 按请求路径匹配的日志详细程度规则
+type GinPathRule struct {
+	// 路径匹配模式，语法同path.Match，以"/*"结尾时匹配该前缀下的所有路径
+	Pattern string
+
+	Verbosity GinVerbosity
+}
+
+// GinConfig gin日志中间件配置
+type GinConfig struct {
+	// 是否记录路由分组前缀(route_group)
+	LogRouteGroup bool
+
+	// 已知的路由分组前缀，按最长前缀匹配；为空时根据FullPath推导
+	RouteGroups []string
+
+	// 未匹配任何PathRules时使用的日志详细程度
+	Verbosity GinVerbosity
+
+	// 按路径设置日志详细程度，按顺序匹配，第一条匹配的规则生效
+	PathRules []GinPathRule
+
+	// 记录请求体的最大字节数，默认4096
+	MaxBodySize int
+
+	// 是否为每个请求生成请求ID(request_id)，请求头中已携带时沿用。请求ID同时保存到请求context中(见WithRequestID)，
+	// 处理函数通过FromGinContext获取的Logger记录的日志带有相同的request_id
+	RequestID bool
+
+	// 请求参数绑定失败时(c.Bind等产生的gin.ErrorTypeBind错误)记录请求体(bind_body)和错误(bind_error)
+	LogBindErrorBody bool
+
+	// 记录请求体时需要脱敏的json字段名，为空时使用默认的password、token等
+	RedactKeys []string
+
+	// 读取和返回请求ID的请求头，默认X-Request-ID
+	RequestIDHeader string
+
+	// 请求ID生成函数，默认生成UUIDv4
+	GenerateRequestID func() string
+
+	// 是否记录匹配路由的完整处理函数链(handlers)
+	LogHandlerChain bool
+
+	// 是否记录multipart上传文件的文件名、大小和类型(uploads)，不记录文件内容
+	LogUploads bool
+
+	// 只记录最终状态码不小于该值的请求，为0时记录所有请求
+	MinStatusToLog int
+
+	// 记录的耗时(cost)按该时长取整，例如time.Millisecond，为0时不取整
+	CostRounding time.Duration
+
+	// 提取已认证用户信息(例如JWT的sub等声明)，结果作为user对象记录，RedactKeys中的字段会被脱敏，不要返回令牌本身
+	UserClaims func(c *gin.Context) map[string]interface{}
+
+	// 是否将c.Keys中字符串、数值、布尔等基本类型的值记录到ctx对象中，RedactKeys中的键会被脱敏
+	LogContextKeys bool
+
+	// ctx对象最多记录的键数，按键名排序后截取，默认32
+	MaxContextKeys int
+
+	// 是否记录HTTPS请求的TLS版本(tls_version)和加密套件(tls_cipher)，明文请求不记录
+	LogTLS bool
+
+	// 记录原始请求数据(raw_data)的路径，语法同GinPathRule.Pattern，用于调试特定接口。
+	// 请求数据会被脱敏和截断，读取后恢复请求体，处理函数中的c.GetRawData和参数绑定不受影响
+	RawDataPaths []string
+
+	// 是否记录处理函数通过SetBound保存的已绑定结构体(bound)，字段规则见StructObject，RedactKeys中的字段会被脱敏
+	LogBound bool
+
+	// 是否记录查询参数的个数(query_params)，同名参数按出现次数计数
+	LogQueryCount bool
+
+	// 是否不记录完整的查询字符串(query)，可与LogQueryCount配合以降低日志的基数
+	OmitQuery bool
+
+	// 是否按路由统计最近请求的耗时，并记录本次耗时是否超过最近的p99(slow_vs_p99)，路由的样本不足100个时不记录
+	LogSlowVsP99 bool
+
+	// 每个路由统计的最近请求数，默认1000
+	LatencyWindow int
+
+	// 耗时分桶的上界，设置时记录本次耗时所在的分桶(latency_bucket)，例如le_100ms，超过所有上界时为gt_加最大上界，便于预聚合分析
+	LatencyBuckets []time.Duration
+
+	// 是否记录c.Error添加的各错误及其调用栈(error_stacks)，错误链中有携带调用栈的错误(例如github.com/pkg/errors创建的错误)时记录各帧的函数、文件和行号
+	LogErrorStacks bool
+
+	// 是否对记录的客户端ip做匿名化处理，IPv4去掉最后一段(置为0)，IPv6只保留前48位，用于满足GDPR等隐私要求
+	AnonymizeIP bool
+
+	// 记录的user-agent的最大字节数，超过时截断，为0时不截断
+	MaxUserAgentLength int
+
+	// 客户端重试次数的请求头，例如X-Retry-Count，值为整数时记录retry_count
+	RetryHeader string
+
+	// 幂等键的请求头，例如Idempotency-Key，携带时记录idempotency_key，用于关联同一请求的多次重试
+	IdempotencyHeader string
+
+	// 是否记录Accept-Language中优先级最高的语言(lang)，请求头缺失或格式错误时不记录
+	LogLanguage bool
+
+	// 记录最近请求路径的条数，大于0时请求期间通过FromGinContext记录的error及以上级别的日志，
+	// 以及请求出错(状态码>=500或有c.Error添加的错误)的请求日志会附加此前最近的请求路径(recent_paths)，从旧到新排列
+	RecentPaths int
+
+	// 是否按客户端ip分别记录最近的请求路径，默认所有请求共用一份记录
+	RecentPathsByClient bool
+
+	// 处理函数通过c.Set保存是否命中缓存(bool)的上下文键，设置时记录cache_hit，未保存或不是bool时为false
+	CacheHitKey string
+
+	// 需要记录的响应头，例如ETag、Cache-Control，存在时以小写并将-替换为_的名称记录(etag、cache_control)
+	ResponseHeaders []string
+
+	// 提取请求的API版本，例如从路径参数或请求头中读取，返回非空时记录api_version，在请求处理完成后调用
+	APIVersion func(c *gin.Context) string
+
+	// 是否记录请求的Accept头(accept)和最终响应的Content-Type(content_type)，用于排查内容协商问题
+	LogContentNegotiation bool
+
+	// 添加到每条请求日志的静态标签，例如region、az，只作用于请求日志，不影响其他日志
+	Labels map[string]string
+
+	// 在请求处理完成后调用，返回true时不记录该请求，例如跳过状态码为200的健康检查
+	Skip func(c *gin.Context) bool
+}
+
+// GinLogger 使用默认配置创建gin日志中间件
+func GinLogger() gin.HandlerFunc {
+	return GinLoggerWithConfig(GinConfig{})
+}
+
+// GinLoggerWithConfig 根据配置创建gin日志中间件。
+// 每个请求结束时才获取zap.L()，因此可以先注册中间件，之后再通过ReplaceGlobals初始化全局Logger，初始化之后的请求会被正常记录。
+// 启动时先zap.ReplaceGlobals(Bootstrap())，初始化之前的请求日志会被缓存，在GetLogger之后重放
+func GinLoggerWithConfig(conf GinConfig) gin.HandlerFunc {
+	labels := labelFields(conf.Labels)
+	buckets := newLatencyBuckets(conf.LatencyBuckets)
+	var recent *recentPaths
+	if conf.RecentPaths > 0 {
+		recent = newRecentPaths(conf.RecentPaths)
+	}
+	var latencies *latencyTracker
+	if conf.LogSlowVsP99 {
+		latencies = newLatencyTracker(conf.LatencyWindow)
+	}
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		query := c.Request.URL.RawQuery
+		var requestID string
+		if conf.RequestID {
+			requestID = conf.requestID(c)
+			c.Request = c.Request.WithContext(WithRequestID(c.Request.Context(), requestID))
+		}
+		verbosity := conf.verbosity(path)
+		rawData := conf.logRawData(path)
+		var body []byte
+		if verbosity.Body || conf.LogBindErrorBody || rawData {
+			body = readBody(c, conf.maxBodySize())
+			c.Set(RequestBodyKey, body)
+		}
+		var prevPaths []string
+		if recent != nil {
+			var key string
+			if conf.RecentPathsByClient {
+				key = c.ClientIP()
+			}
+			prevPaths = recent.add(key, c.Request.Method+" "+path)
+			if len(prevPaths) > 0 {
+				c.Request = c.Request.WithContext(withRecentPaths(c.Request.Context(), prevPaths))
+			}
+		}
+		c.Next()
+		cost := time.Since(start)
+		var slowVsP99, latencyOK bool
+		if latencies != nil {
+			slowVsP99, latencyOK = latencies.observe(c.Request.Method+" "+c.FullPath(), cost)
+		}
+		var bucketLabel string
+		if buckets != nil {
+			bucketLabel = buckets.label(cost)
+		}
+		if conf.MinStatusToLog > 0 && c.Writer.Status() < conf.MinStatusToLog {
+			return
+		}
+		if conf.Skip != nil && conf.Skip(c) {
+			return
+		}
+		cost = conf.roundCost(cost)
+		fields := []zap.Field{
+			zap.Int("status", c.Writer.Status()),
+			zap.String("method", c.Request.Method),
+			zap.String("path", path),
+		}
+		if !conf.OmitQuery {
+			fields = append(fields, zap.String("query", query))
+		}
+		fields = append(fields,
+			zap.String("ip", conf.clientIP(c)),
+			zap.String("user-agent", conf.userAgent(c)),
+			zap.String("errors", c.Errors.ByType(gin.ErrorTypePrivate).String()),
+			zap.Duration("cost", cost),
+		)
+		if conf.LogErrorStacks && len(c.Errors) > 0 {
+			fields = append(fields, zap.Array("error_stacks", ginErrorStacks(c.Errors)))
+		}
+		if conf.RetryHeader != "" {
+			if n, err := strconv.Atoi(c.GetHeader(conf.RetryHeader)); err == nil {
+				fields = append(fields, zap.Int("retry_count", n))
+			}
+		}
+		if conf.IdempotencyHeader != "" {
+			if key := c.GetHeader(conf.IdempotencyHeader); key != "" {
+				fields = append(fields, zap.String("idempotency_key", key))
+			}
+		}
+		if conf.LogLanguage {
+			if lang := preferredLanguage(c.GetHeader("Accept-Language")); lang != "" {
+				fields = append(fields, zap.String("lang", lang))
+			}
+		}
+		if fs := datadogFields(c.Request.Context()); len(fs) > 0 {
+			fields = append(fields, fs...)
+		}
+		if latencyOK {
+			fields = append(fields, zap.Bool("slow_vs_p99", slowVsP99))
+		}
+		if buckets != nil {
+			fields = append(fields, zap.String("latency_bucket", bucketLabel))
+		}
+		if recent != nil && len(prevPaths) > 0 && (c.Writer.Status() >= http.StatusInternalServerError || len(c.Errors) > 0) {
+			fields = append(fields, zap.Strings("recent_paths", prevPaths))
+		}
+		for _, h := range conf.ResponseHeaders {
+			if v := c.Writer.Header().Get(h); v != "" {
+				fields = append(fields, zap.String(headerFieldName(h), v))
+			}
+		}
+		if conf.CacheHitKey != "" {
+			v, _ := c.Get(conf.CacheHitKey)
+			hit, _ := v.(bool)
+			fields = append(fields, zap.Bool("cache_hit", hit))
+		}
+		if conf.LogContentNegotiation {
+			fields = append(fields,
+				zap.String("accept", c.GetHeader("Accept")),
+				zap.String("content_type", c.Writer.Header().Get("Content-Type")),
+			)
+		}
+		if conf.APIVersion != nil {
+			if v := conf.APIVersion(c); v != "" {
+				fields = append(fields, zap.String("api_version", v))
+			}
+		}
+		if conf.LogQueryCount {
+			fields = append(fields, zap.Int("query_params", queryParamCount(c.Request.URL.Query())))
+		}
+		if conf.RequestID {
+			fields = append(fields, zap.String(RequestIDKey, requestID))
+		}
+		fields = append(fields, labels...)
+		if err := c.Request.Context().Err(); err != nil {
+			fields = append(fields, zap.String("ctx_err", err.Error()))
+		}
+		if verbosity.Headers {
+			fields = append(fields, zap.Any("headers", redactHeaders(c.Request.Header)))
+		}
+		if verbosity.Body {
+			fields = append(fields, zap.ByteString("body", conf.redactBody(body)))
+		}
+		if rawData {
+			fields = append(fields, zap.ByteString("raw_data", conf.redactBody(body)))
+		}
+		if conf.LogBindErrorBody {
+			if bindErrs := c.Errors.ByType(gin.ErrorTypeBind); len(bindErrs) > 0 {
+				fields = append(fields,
+					zap.String("bind_error", bindErrs.String()),
+					zap.ByteString("bind_body", conf.redactBody(body)),
+				)
+			}
+		}
+		if verbosity.Params {
+			params := make(map[string]string, len(c.Params))
+			for _, p := range c.Params {
+				params[p.Key] = p.Value
+			}
+			fields = append(fields, zap.Any("params", params))
+		}
+		if conf.LogHandlerChain {
+			fields = append(fields, zap.Strings("handlers", c.HandlerNames()))
+		}
+		if conf.LogUploads {
+			if uploads := multipartUploads(c); len(uploads) > 0 {
+				fields = append(fields, zap.Array("uploads", uploads))
+			}
+		}
+		if conf.UserClaims != nil {
+			if claims := conf.UserClaims(c); len(claims) > 0 {
+				fields = append(fields, zap.Any("user", redactMap(claims, redactKeySet(conf.RedactKeys))))
+			}
+		}
+		if conf.LogBound {
+			if v, ok := c.Get(boundKey); ok {
+				fields = append(fields, zap.Object("bound", StructObject(v, conf.RedactKeys)))
+			}
+		}
+		if conf.LogContextKeys && len(c.Keys) > 0 {
+			fields = append(fields, zap.Object("ctx", contextKeys{
+				keys:   c.Keys,
+				max:    conf.maxContextKeys(),
+				redact: redactKeySet(conf.RedactKeys),
+			}))
+		}
+		if conf.LogTLS && c.Request.TLS != nil {
+			fields = append(fields,
+				zap.String("tls_version", tlsVersionName(c.Request.TLS.Version)),
+				zap.String("tls_cipher", tls.CipherSuiteName(c.Request.TLS.CipherSuite)),
+			)
+		}
+		if conf.LogRouteGroup {
+			if group := routeGroup(c.FullPath(), conf.RouteGroups); group != "" {
+				fields = append(fields, zap.String("route_group", group))
+			}
+		}
+		zap.L().Info(path, fields...)
+	}
+}
+
+// routeGroup 获取路由分组前缀，优先匹配已知前缀，否则去掉FullPath末尾的资源段和参数段
+func routeGroup(fullPath string, groups []string) string {
+	if fullPath == "" {
+		return ""
+	}
+	if len(groups) > 0 {
+		best := ""
+		for _, g := range groups {
+			g = strings.TrimSuffix(g, "/")
+			if (fullPath == g || strings.HasPrefix(fullPath, g+"/")) && len(g) > len(best) {
+				best = g
+			}
+		}
+		return best
+	}
+	segments := strings.Split(strings.Trim(fullPath, "/"), "/")
+	end := len(segments)
+	for end > 0 && (strings.HasPrefix(segments[end-1], ":") || strings.HasPrefix(segments[end-1], "*")) {
+		end--
+	}
+	if end > 0 {
+		end--
+	}
+	if end == 0 {
+		return ""
+	}
+	return "/" + strings.Join(segments[:end], "/")
+}
+
+// verbosity 返回请求路径对应的日志详细程度
+func (conf *GinConfig) verbosity(p string) GinVerbosity {
+	for _, rule := range conf.PathRules {
+		if matchPath(rule.Pattern, p) {
+			return rule.Verbosity
+		}
+	}
+	return conf.Verbosity
+}
+
+// requestID 获取或生成请求ID，保存到gin上下文并写入响应头
+func (conf *GinConfig) requestID(c *gin.Context) string {
+	header := conf.RequestIDHeader
+	if header == "" {
+		header = defaultRequestIDHeader
+	}
+	id := c.GetHeader(header)
+	if id == "" {
+		if conf.GenerateRequestID != nil {
+			id = conf.GenerateRequestID()
+		} else {
+			id = newUUIDv4()
+		}
+	}
+	c.Set(RequestIDKey, id)
+	c.Header(header, id)
+	return id
+}
+
+func (conf *GinConfig) maxContextKeys() int {
+	if conf.MaxContextKeys <= 0 {
+		return defaultMaxContextKeys
+	}
+	return conf.MaxContextKeys
+}
+
+// roundCost 按CostRounding对耗时取整，未配置时返回原值
+func (conf *GinConfig) roundCost(cost time.Duration) time.Duration {
+	if conf.CostRounding <= 0 {
+		return cost
+	}
+	return cost.Round(conf.CostRounding)
+}
+
+func (conf *GinConfig) maxBodySize() int {
+	if conf.MaxBodySize <= 0 {
+		return defaultMaxBodySize
+	}
+	return conf.MaxBodySize
+}
+
+// matchPath 判断路径是否匹配模式
+func matchPath(pattern, p string) bool {
+	if strings.HasSuffix(pattern, "/*") {
+		prefix := strings.TrimSuffix(pattern, "*")
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	ok, _ := path.Match(pattern, p)
+	return ok
+}
+
+// logRawData 判断请求路径是否需要记录原始请求数据
+func (conf *GinConfig) logRawData(p string) bool {
+	for _, pattern := range conf.RawDataPaths {
+		if matchPath(pattern, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactBody 脱敏并截断请求体，被截断的json按字段名替换能识别的敏感字段，不是json的请求体只截断
+func (conf *GinConfig) redactBody(body []byte) []byte {
+	keys := redactKeySet(conf.RedactKeys)
+	if redacted, ok := redactJSON(body, keys); ok {
+		body = redacted
+	} else {
+		body = redactJSONPrefix(body, keys)
+	}
+	return capBytes(body, conf.maxBodySize())
+}
+
+// readBody 读取请求体的前limit字节用于记录日志，并恢复完整的请求体供后续处理使用，
+// 超过limit的部分不会读入内存
+func readBody(c *gin.Context, limit int) []byte {
+	if c.Request.Body == nil {
+		return nil
+	}
+	body := c.Request.Body
+	data, err := io.ReadAll(io.LimitReader(body, int64(limit)))
+	c.Request.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(data), body), Closer: body}
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// readCloser 组合Reader和Closer
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// labelFields 将标签转换为按键名排序的字段
+func labelFields(labels map[string]string) []zap.Field {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fields := make([]zap.Field, 0, len(keys))
+	for _, k := range keys {
+		fields = append(fields, zap.String(k, labels[k]))
+	}
+	return fields
+}
+
+// queryParamCount 返回查询参数的个数，同名参数按出现次数计数
+func queryParamCount(values url.Values) int {
+	n := 0
+	for _, v := range values {
+		n += len(v)
+	}
+	return n
+}
+
+// preferredLanguage 返回Accept-Language中q值最高的语言，q值相同时取靠前的，忽略*、q为0和格式错误的项
+func preferredLanguage(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" || strings.ContainsAny(tag, " \t=") {
+			continue
+		}
+		q := 1.0
+		if params = strings.TrimSpace(params); params != "" {
+			if !strings.HasPrefix(params, "q=") {
+				continue
+			}
+			f, err := strconv.ParseFloat(strings.TrimPrefix(params, "q="), 64)
+			if err != nil || f < 0 || f > 1 {
+				continue
+			}
+			q = f
+		}
+		if q > bestQ {
+			best, bestQ = tag, q
+		}
+	}
+	return best
+}
+
+// clientIP 返回客户端ip，AnonymizeIP为true时做匿名化处理
+func (conf *GinConfig) clientIP(c *gin.Context) string {
+	ip := c.ClientIP()
+	if conf.AnonymizeIP {
+		return anonymizeIP(ip)
+	}
+	return ip
+}
+
+// anonymizeIP 将IPv4的最后一段和IPv6的后80位置为0，无法解析时返回空字符串
+func anonymizeIP(s string) string {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return ""
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(48, 128)).String()
+}
+
+// headerFieldName 将请求头名称转换为字段名，例如Cache-Control转换为cache_control
+func headerFieldName(header string) string {
+	return strings.ReplaceAll(strings.ToLower(header), "-", "_")
+}
+
+// userAgent 返回按MaxUserAgentLength截断的user-agent
+func (conf *GinConfig) userAgent(c *gin.Context) string {
+	ua := c.Request.UserAgent()
+	if conf.MaxUserAgentLength <= 0 || len(ua) <= conf.MaxUserAgentLength {
+		return ua
+	}
+	n := conf.MaxUserAgentLength
+	for n > 0 && !utf8.RuneStart(ua[n]) {
+		n--
+	}
+	return ua[:n]
+}
+
+// capBytes 截断超过limit的内容
+func capBytes(data []byte, limit int) []byte {
+	if len(data) > limit {
+		return data[:limit]
+	}
+	return data
+}
+
+// sensitiveHeaders 记录日志时需要脱敏的请求头
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// redactHeaders 复制请求头并将敏感请求头的值替换为***
+func redactHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for k, v := range header {
+		if sensitiveHeaders[k] {
+			headers[k] = "***"
+			continue
+		}
+		headers[k] = strings.Join(v, ", ")
+	}
+	return headers
+}
+
+// uploadFiles 上传文件的元信息
+type uploadFiles []*multipart.FileHeader
+
+func (u uploadFiles) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, fh := range u {
+		fh := fh
+		err := enc.AppendObject(zapcore.ObjectMarshalerFunc(func(obj zapcore.ObjectEncoder) error {
+			obj.AddString("filename", fh.Filename)
+			obj.AddInt64("size", fh.Size)
+			obj.AddString("type", fh.Header.Get("Content-Type"))
+			return nil
+		}))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// multipartUploads 获取请求中上传文件的元信息，处理函数未解析multipart表单时尝试解析
+func multipartUploads(c *gin.Context) uploadFiles {
+	if c.Request.MultipartForm == nil {
+		if !strings.HasPrefix(c.ContentType(), "multipart/") {
+			return nil
+		}
+		if err := c.Request.ParseMultipartForm(32 << 20); err != nil {
+			return nil
+		}
+	}
+	keys := make([]string, 0, len(c.Request.MultipartForm.File))
+	for k := range c.Request.MultipartForm.File {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var uploads uploadFiles
+	for _, k := range keys {
+		uploads = append(uploads, c.Request.MultipartForm.File[k]...)
+	}
+	return uploads
+}
+
+// contextKeys 记录gin上下文中基本类型的值
+type contextKeys struct {
+	keys   map[string]interface{}
+	max    int
+	redact map[string]bool
+}
+
+func (ck contextKeys) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	keys := make([]string, 0, len(ck.keys))
+	for k := range ck.keys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	n := 0
+	for _, k := range keys {
+		if n >= ck.max {
+			break
+		}
+		if ck.redact[strings.ToLower(k)] {
+			enc.AddString(k, redactedValue)
+			n++
+			continue
+		}
+		switch v := ck.keys[k].(type) {
+		case string:
+			enc.AddString(k, v)
+		case bool:
+			enc.AddBool(k, v)
+		case int:
+			enc.AddInt(k, v)
+		case int64:
+			enc.AddInt64(k, v)
+		case int32:
+			enc.AddInt32(k, v)
+		case uint:
+			enc.AddUint(k, v)
+		case uint64:
+			enc.AddUint64(k, v)
+		case uint32:
+			enc.AddUint32(k, v)
+		case float64:
+			enc.AddFloat64(k, v)
+		case float32:
+			enc.AddFloat32(k, v)
+		case time.Duration:
+			enc.AddDuration(k, v)
+		case time.Time:
+			enc.AddTime(k, v)
+		case fmt.Stringer:
+			enc.AddString(k, v.String())
+		default:
+			// 跳过非基本类型的值
+			continue
+		}
+		n++
+	}
+	return nil
+}
+
+// tlsVersionName 返回TLS版本的名称
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	}
+	return fmt.Sprintf("0x%04X", version)
+}
@@ -0,0 +1,908 @@
+package pzlog
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// useCapturedGlobals 创建写入内存的Logger并替换zap的全局Logger，测试结束时恢复
+func useCapturedGlobals(t *testing.T) *captured {
+	t.Helper()
+	prev := zap.L()
+	t.Cleanup(func() { zap.ReplaceGlobals(prev) })
+	config, out := newCapturedConfig()
+	config.ReplaceGlobals = true
+	GetLogger(config)
+	return out
+}
+
+// serveGin 使用conf创建的GinLogger处理请求，返回请求日志
+func serveGin(t *testing.T, conf GinConfig, register func(e *gin.Engine), req *http.Request) map[string]interface{} {
+	t.Helper()
+	out := useCapturedGlobals(t)
+	e := gin.New()
+	e.Use(GinLoggerWithConfig(conf))
+	register(e)
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	entries := out.Entries(t)
+	if len(entries) == 0 {
+		t.Fatal("no request log")
+	}
+	return entries[len(entries)-1]
+}
+
+func TestGinRouteGroup(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups []string
+		route  string
+		path   string
+		want   interface{}
+	}{
+		{"derived", nil, "/api/v1/users/:id", "/api/v1/users/7", "/api/v1"},
+		{"known prefix", []string{"/api", "/api/v1/"}, "/api/v1/users/:id", "/api/v1/users/7", "/api/v1"},
+		{"no group", nil, "/health", "/health", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := serveGin(t, GinConfig{LogRouteGroup: true, RouteGroups: tt.groups}, func(e *gin.Engine) {
+				e.GET(tt.route, func(c *gin.Context) {})
+			}, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if got := entry["route_group"]; got != tt.want {
+				t.Errorf("route_group = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGinPathRules(t *testing.T) {
+	conf := GinConfig{
+		PathRules: []GinPathRule{
+			{Pattern: "/admin/*", Verbosity: GinVerbosity{Headers: true, Body: true, Params: true}},
+			{Pattern: "/public/*", Verbosity: GinVerbosity{}},
+		},
+	}
+	register := func(e *gin.Engine) {
+		e.POST("/admin/users/:id", func(c *gin.Context) {})
+		e.POST("/public/users/:id", func(c *gin.Context) {})
+	}
+	newRequest := func(path string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"name":"a","password":"secret"}`))
+		req.Header.Set("Authorization", "Bearer token")
+		return req
+	}
+
+	admin := serveGin(t, conf, register, newRequest("/admin/users/1"))
+	for _, key := range []string{"headers", "body", "params"} {
+		if _, ok := admin[key]; !ok {
+			t.Errorf("admin request log is missing %s", key)
+		}
+	}
+	if body := admin["body"].(string); strings.Contains(body, "secret") {
+		t.Errorf("body was not redacted: %s", body)
+	}
+
+	public := serveGin(t, conf, register, newRequest("/public/users/1"))
+	for _, key := range []string{"headers", "body", "params"} {
+		if _, ok := public[key]; ok {
+			t.Errorf("public request log should not have %s", key)
+		}
+	}
+}
+
+func TestGinBodyReadLimit(t *testing.T) {
+	payload := `{"password":"` + strings.Repeat("x", 1<<20) + `"}`
+	var received int
+	entry := serveGin(t, GinConfig{MaxBodySize: 32, Verbosity: GinVerbosity{Body: true}}, func(e *gin.Engine) {
+		e.POST("/upload", func(c *gin.Context) {
+			data, _ := io.ReadAll(c.Request.Body)
+			received = len(data)
+		})
+	}, httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(payload)))
+	if received != len(payload) {
+		t.Errorf("handler read %d bytes, want %d", received, len(payload))
+	}
+	body := entry["body"].(string)
+	if len(body) > 32 {
+		t.Errorf("logged body has %d bytes, want at most 32", len(body))
+	}
+	if strings.Contains(body, "xxx") {
+		t.Errorf("truncated body was not redacted: %s", body)
+	}
+}
+
+func TestReadBodyLimit(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("0123456789"))
+	if got := string(readBody(c, 4)); got != "0123" {
+		t.Errorf("readBody = %q, want 0123", got)
+	}
+	rest, _ := io.ReadAll(c.Request.Body)
+	if string(rest) != "0123456789" {
+		t.Errorf("restored body = %q, want the full body", rest)
+	}
+}
+
+func TestGinContextError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodGet, "/slow", nil).WithContext(ctx)
+	entry := serveGin(t, GinConfig{}, func(e *gin.Engine) {
+		e.GET("/slow", func(c *gin.Context) {})
+	}, req)
+	if got := entry["ctx_err"]; got != context.Canceled.Error() {
+		t.Errorf("ctx_err = %v, want %s", got, context.Canceled)
+	}
+
+	entry = serveGin(t, GinConfig{}, func(e *gin.Engine) {
+		e.GET("/fast", func(c *gin.Context) {})
+	}, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	if _, ok := entry["ctx_err"]; ok {
+		t.Error("ctx_err should be omitted when the context is not done")
+	}
+}
+
+func TestGinRequestIDGenerator(t *testing.T) {
+	n := 0
+	conf := GinConfig{RequestID: true, GenerateRequestID: func() string {
+		n++
+		return fmt.Sprintf("req-%d", n)
+	}}
+	register := func(e *gin.Engine) { e.GET("/", func(c *gin.Context) {}) }
+	if got := serveGin(t, conf, register, httptest.NewRequest(http.MethodGet, "/", nil))["request_id"]; got != "req-1" {
+		t.Errorf("request_id = %v, want req-1", got)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "upstream")
+	if got := serveGin(t, conf, register, req)["request_id"]; got != "upstream" {
+		t.Errorf("request_id = %v, want the id from the request header", got)
+	}
+
+	got, _ := serveGin(t, GinConfig{RequestID: true}, register, httptest.NewRequest(http.MethodGet, "/", nil))["request_id"].(string)
+	if len(got) != 36 || got[14] != '4' {
+		t.Errorf("default request_id %q is not a UUIDv4", got)
+	}
+}
+
+func TestGinBindErrorBody(t *testing.T) {
+	type login struct {
+		User     string `json:"user" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+	body := `{"password":"hunter2","age":3}`
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	entry := serveGin(t, GinConfig{LogBindErrorBody: true}, func(e *gin.Engine) {
+		e.POST("/login", func(c *gin.Context) {
+			var l login
+			if err := c.ShouldBindJSON(&l); err != nil {
+				_ = c.Error(err).SetType(gin.ErrorTypeBind)
+				c.Status(http.StatusBadRequest)
+			}
+		})
+	}, req)
+
+	bindBody, _ := entry["bind_body"].(string)
+	if strings.Contains(bindBody, "hunter2") || !strings.Contains(bindBody, `"password":"***"`) {
+		t.Errorf("bind_body = %q, want redacted password", bindBody)
+	}
+	if bindErr, _ := entry["bind_error"].(string); !strings.Contains(bindErr, "User") {
+		t.Errorf("bind_error = %q, want validation error", bindErr)
+	}
+
+	// 没有绑定错误时不记录
+	req = httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"user":"a","password":"b"}`))
+	req.Header.Set("Content-Type", "application/json")
+	entry = serveGin(t, GinConfig{LogBindErrorBody: true}, func(e *gin.Engine) {
+		e.POST("/login", func(c *gin.Context) { c.Status(http.StatusOK) })
+	}, req)
+	if _, ok := entry["bind_body"]; ok {
+		t.Errorf("bind_body logged without bind error: %v", entry)
+	}
+}
+
+func TestGinSkipPredicate(t *testing.T) {
+	conf := GinConfig{Skip: func(c *gin.Context) bool {
+		return c.Request.URL.Path == "/healthz" && c.Writer.Status() == http.StatusOK
+	}}
+	tests := []struct {
+		status int
+		logged bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusServiceUnavailable, true},
+	}
+	for _, tt := range tests {
+		out := useCapturedGlobals(t)
+		e := gin.New()
+		e.Use(GinLoggerWithConfig(conf))
+		e.GET("/healthz", func(c *gin.Context) { c.Status(tt.status) })
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		if logged := len(out.Lines()) > 0; logged != tt.logged {
+			t.Errorf("status %d: logged = %v, want %v", tt.status, logged, tt.logged)
+		}
+	}
+}
+
+func TestGinHandlerChain(t *testing.T) {
+	auth := func(c *gin.Context) { c.Next() }
+	entry := serveGin(t, GinConfig{LogHandlerChain: true}, func(e *gin.Engine) {
+		e.GET("/items", auth, func(c *gin.Context) { c.Status(http.StatusOK) })
+	}, httptest.NewRequest(http.MethodGet, "/items", nil))
+
+	handlers, _ := entry["handlers"].([]interface{})
+	if len(handlers) != 3 {
+		t.Fatalf("handlers = %v, want logger, auth and handler", entry["handlers"])
+	}
+	if name, _ := handlers[0].(string); !strings.Contains(name, "GinLoggerWithConfig") {
+		t.Errorf("first handler = %q, want the logger middleware", name)
+	}
+}
+
+func TestGinUploads(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", `form-data; name="avatar"; filename="me.png"`)
+	h.Set("Content-Type", "image/png")
+	part, err := mw.CreatePart(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = part.Write([]byte("secret-file-contents"))
+	_ = mw.WriteField("name", "alice")
+	_ = mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	out := useCapturedGlobals(t)
+	e := gin.New()
+	e.Use(GinLoggerWithConfig(GinConfig{LogUploads: true}))
+	e.POST("/upload", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := out.Lines()
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1", len(lines))
+	}
+	if strings.Contains(lines[0], "secret-file-contents") {
+		t.Error("file contents logged")
+	}
+	entry := out.Entries(t)[0]
+	uploads, _ := entry["uploads"].([]interface{})
+	if len(uploads) != 1 {
+		t.Fatalf("uploads = %v, want one file", entry["uploads"])
+	}
+	upload := uploads[0].(map[string]interface{})
+	if upload["filename"] != "me.png" || upload["size"] != float64(20) || upload["type"] != "image/png" {
+		t.Errorf("upload = %v", upload)
+	}
+}
+
+func TestGinMinStatusToLog(t *testing.T) {
+	for _, tt := range []struct {
+		status int
+		logged bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusNotFound, false},
+		{http.StatusServiceUnavailable, true},
+	} {
+		out := useCapturedGlobals(t)
+		e := gin.New()
+		e.Use(GinLoggerWithConfig(GinConfig{MinStatusToLog: 500}))
+		e.GET("/r", func(c *gin.Context) { c.Status(tt.status) })
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/r", nil))
+		if logged := len(out.Lines()) > 0; logged != tt.logged {
+			t.Errorf("status %d: logged = %v, want %v", tt.status, logged, tt.logged)
+		}
+	}
+}
+
+func TestGinCostRounding(t *testing.T) {
+	cost := 1234500 * time.Nanosecond
+	rounded := (&GinConfig{CostRounding: time.Millisecond}).roundCost(cost)
+	if rounded != time.Millisecond {
+		t.Errorf("rounded cost = %v, want exactly 1ms", rounded)
+	}
+	if got := (&GinConfig{}).roundCost(cost); got != cost {
+		t.Errorf("cost without rounding = %v, want %v", got, cost)
+	}
+}
+
+func TestGinUserClaims(t *testing.T) {
+	conf := GinConfig{UserClaims: func(c *gin.Context) map[string]interface{} {
+		return map[string]interface{}{"sub": "user-42", "roles": []string{"admin"}, "token": "eyJhbGci"}
+	}}
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer eyJhbGci")
+	entry := serveGin(t, conf, func(e *gin.Engine) {
+		e.GET("/me", func(c *gin.Context) { c.Status(http.StatusOK) })
+	}, req)
+
+	user, _ := entry["user"].(map[string]interface{})
+	if user["sub"] != "user-42" {
+		t.Errorf("user = %v, want sub user-42", entry["user"])
+	}
+	if user["token"] != redactedValue {
+		t.Errorf("token = %v, want redacted", user["token"])
+	}
+	if line := fmt.Sprint(entry); strings.Contains(line, "eyJhbGci") {
+		t.Errorf("token logged: %s", line)
+	}
+}
+
+func TestGinContextKeys(t *testing.T) {
+	entry := serveGin(t, GinConfig{LogContextKeys: true, MaxContextKeys: 4}, func(e *gin.Engine) {
+		e.GET("/r", func(c *gin.Context) {
+			c.Set("a_tenant", "acme")
+			c.Set("b_retries", 2)
+			c.Set("c_cached", true)
+			c.Set("d_obj", struct{ X int }{1})
+			c.Set("password", "hunter2")
+			c.Set("z_over_cap", "dropped")
+			c.Status(http.StatusOK)
+		})
+	}, httptest.NewRequest(http.MethodGet, "/r", nil))
+
+	ctx, _ := entry["ctx"].(map[string]interface{})
+	if ctx["a_tenant"] != "acme" || ctx["b_retries"] != float64(2) || ctx["c_cached"] != true {
+		t.Errorf("ctx = %v, want primitive keys", entry["ctx"])
+	}
+	if _, ok := ctx["d_obj"]; ok {
+		t.Errorf("non-primitive value logged: %v", ctx["d_obj"])
+	}
+	if ctx["password"] != redactedValue {
+		t.Errorf("password = %v, want redacted", ctx["password"])
+	}
+	if _, ok := ctx["z_over_cap"]; ok || len(ctx) > 4 {
+		t.Errorf("ctx = %v, want at most 4 keys", ctx)
+	}
+}
+
+func TestGinTLS(t *testing.T) {
+	register := func(e *gin.Engine) {
+		e.GET("/r", func(c *gin.Context) { c.Status(http.StatusOK) })
+	}
+	req := httptest.NewRequest(http.MethodGet, "https://example.com/r", nil)
+	req.TLS = &tls.ConnectionState{Version: tls.VersionTLS13, CipherSuite: tls.TLS_AES_128_GCM_SHA256}
+	entry := serveGin(t, GinConfig{LogTLS: true}, register, req)
+	if entry["tls_version"] != "TLS 1.3" || entry["tls_cipher"] != "TLS_AES_128_GCM_SHA256" {
+		t.Errorf("tls_version = %v, tls_cipher = %v", entry["tls_version"], entry["tls_cipher"])
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/r", nil)
+	req.TLS = nil
+	entry = serveGin(t, GinConfig{LogTLS: true}, register, req)
+	if _, ok := entry["tls_version"]; ok {
+		t.Error("tls fields logged for a plaintext request")
+	}
+}
+
+func TestGinRawData(t *testing.T) {
+	body := `{"user":"bob","password":"hunter2"}`
+	var handlerData []byte
+	var bound struct {
+		User string `json:"user"`
+	}
+	req := httptest.NewRequest(http.MethodPost, "/debug/echo", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	entry := serveGin(t, GinConfig{RawDataPaths: []string{"/debug/*"}}, func(e *gin.Engine) {
+		e.POST("/debug/echo", func(c *gin.Context) {
+			handlerData, _ = c.GetRawData()
+			c.Request.Body = io.NopCloser(bytes.NewReader(handlerData))
+			_ = c.ShouldBindJSON(&bound)
+			c.Status(http.StatusOK)
+		})
+	}, req)
+
+	if string(handlerData) != body || bound.User != "bob" {
+		t.Errorf("handler read %q, bound %q", handlerData, bound.User)
+	}
+	raw, _ := entry["raw_data"].(string)
+	if !strings.Contains(raw, `"user":"bob"`) || strings.Contains(raw, "hunter2") {
+		t.Errorf("raw_data = %q, want redacted request data", raw)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/echo", strings.NewReader(body))
+	entry = serveGin(t, GinConfig{RawDataPaths: []string{"/debug/*"}}, func(e *gin.Engine) {
+		e.POST("/api/echo", func(c *gin.Context) { c.Status(http.StatusOK) })
+	}, req)
+	if _, ok := entry["raw_data"]; ok {
+		t.Error("raw_data logged for a path that is not configured")
+	}
+}
+
+func TestGinLogBound(t *testing.T) {
+	type query struct {
+		Page   int    `form:"page"`
+		Name   string `form:"name" log:"user"`
+		Token  string `form:"token"`
+		Secret string `form:"secret" log:"secret,redact"`
+		Skip   string `form:"skip" log:"-"`
+	}
+	conf := GinConfig{LogBound: true}
+	entry := serveGin(t, conf, func(e *gin.Engine) {
+		e.GET("/search", func(c *gin.Context) {
+			var q query
+			if err := c.ShouldBindQuery(&q); err != nil {
+				t.Error(err)
+			}
+			SetBound(c, &q)
+		})
+	}, httptest.NewRequest(http.MethodGet, "/search?page=2&name=bob&token=t1&secret=s1&skip=x", nil))
+
+	bound, ok := entry["bound"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("bound = %v, want object", entry["bound"])
+	}
+	want := map[string]interface{}{"page": 2.0, "user": "bob", "token": redactedValue, "secret": redactedValue}
+	if len(bound) != len(want) {
+		t.Errorf("bound = %v, want %v", bound, want)
+	}
+	for k, v := range want {
+		if bound[k] != v {
+			t.Errorf("bound[%s] = %v, want %v", k, bound[k], v)
+		}
+	}
+}
+
+func TestGinQueryCount(t *testing.T) {
+	register := func(e *gin.Engine) { e.GET("/search", func(c *gin.Context) {}) }
+	entry := serveGin(t, GinConfig{LogQueryCount: true}, register, httptest.NewRequest(http.MethodGet, "/search?a=1&b=2&a=3", nil))
+	if entry["query_params"] != 3.0 || entry["query"] != "a=1&b=2&a=3" {
+		t.Errorf("query_params = %v, query = %v, want 3 and the raw query", entry["query_params"], entry["query"])
+	}
+
+	entry = serveGin(t, GinConfig{LogQueryCount: true, OmitQuery: true}, register, httptest.NewRequest(http.MethodGet, "/search?a=1&b=2", nil))
+	if _, ok := entry["query"]; ok || entry["query_params"] != 2.0 {
+		t.Errorf("entry = %v, want query_params 2 without query", entry)
+	}
+}
+
+func TestGinSlowVsP99(t *testing.T) {
+	out := useCapturedGlobals(t)
+	e := gin.New()
+	e.Use(GinLoggerWithConfig(GinConfig{LogSlowVsP99: true}))
+	e.GET("/work", func(c *gin.Context) {})
+	for i := 0; i <= latencyMinSamples; i++ {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/work", nil))
+	}
+
+	// 是否慢于p99取决于实际耗时，由TestLatencyTrackerSlowVsP99使用固定耗时验证，这里只检查字段
+	entries := out.Entries(t)
+	if len(entries) != latencyMinSamples+1 {
+		t.Fatalf("got %d entries, want %d", len(entries), latencyMinSamples+1)
+	}
+	if _, ok := entries[0]["slow_vs_p99"]; ok {
+		t.Error("slow_vs_p99 logged before enough samples")
+	}
+	if _, ok := entries[len(entries)-1]["slow_vs_p99"].(bool); !ok {
+		t.Errorf("slow_vs_p99 = %v, want a bool once enough samples are recorded", entries[len(entries)-1]["slow_vs_p99"])
+	}
+}
+
+func TestGinLatencyBuckets(t *testing.T) {
+	entry := serveGin(t, GinConfig{LatencyBuckets: []time.Duration{10 * time.Second}}, func(e *gin.Engine) {
+		e.GET("/work", func(c *gin.Context) {})
+	}, httptest.NewRequest(http.MethodGet, "/work", nil))
+
+	// 各耗时所在的分桶由TestLatencyBuckets使用固定耗时验证，这里只检查字段
+	if v := entry["latency_bucket"]; v != "le_10s" {
+		t.Errorf("latency_bucket = %v, want le_10s", v)
+	}
+}
+
+func TestGinMaxUserAgentLength(t *testing.T) {
+	register := func(e *gin.Engine) { e.GET("/", func(c *gin.Context) {}) }
+	tests := []struct {
+		ua   string
+		max  int
+		want string
+	}{
+		{strings.Repeat("bot", 100), 16, "botbotbotbotbotb"},
+		{"curl/8.0", 16, "curl/8.0"},
+		{strings.Repeat("bot", 100), 0, strings.Repeat("bot", 100)},
+		// 不截断多字节字符
+		{"ua-浏览器", 5, "ua-"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("User-Agent", tt.ua)
+		entry := serveGin(t, GinConfig{MaxUserAgentLength: tt.max}, register, req)
+		if got := entry["user-agent"]; got != tt.want {
+			t.Errorf("max %d: user-agent = %v, want %q", tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestGinAnonymizeIP(t *testing.T) {
+	register := func(e *gin.Engine) { e.GET("/", func(c *gin.Context) {}) }
+	tests := []struct {
+		remote    string
+		anonymize bool
+		want      string
+	}{
+		{"203.0.113.57:1234", true, "203.0.113.0"},
+		{"[2001:db8:abcd:12:34:56:78:9a]:1234", true, "2001:db8:abcd::"},
+		{"203.0.113.57:1234", false, "203.0.113.57"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = tt.remote
+		entry := serveGin(t, GinConfig{AnonymizeIP: tt.anonymize}, register, req)
+		if got := entry["ip"]; got != tt.want {
+			t.Errorf("%s (anonymize %v): ip = %v, want %q", tt.remote, tt.anonymize, got, tt.want)
+		}
+	}
+	if got := anonymizeIP("not-an-ip"); got != "" {
+		t.Errorf("anonymizeIP(invalid) = %q, want empty", got)
+	}
+}
+
+func TestGinRecentPaths(t *testing.T) {
+	for _, byClient := range []bool{false, true} {
+		out := useCapturedGlobals(t)
+		e := gin.New()
+		e.Use(GinLoggerWithConfig(GinConfig{RecentPaths: 2, RecentPathsByClient: byClient}))
+		e.GET("/page/:n", func(c *gin.Context) {})
+		e.GET("/fail", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+		serve := func(path, remote string) {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.RemoteAddr = remote
+			e.ServeHTTP(httptest.NewRecorder(), req)
+		}
+		serve("/page/1", "10.0.0.1:1")
+		serve("/page/2", "10.0.0.1:1")
+		serve("/page/3", "10.0.0.2:1")
+		serve("/fail", "10.0.0.1:1")
+
+		entries := out.Entries(t)
+		if len(entries) != 4 {
+			t.Fatalf("byClient %v: got %d entries, want 4", byClient, len(entries))
+		}
+		for _, entry := range entries[:3] {
+			if v, ok := entry["recent_paths"]; ok {
+				t.Errorf("byClient %v: successful request logged recent_paths %v", byClient, v)
+			}
+		}
+		want := []interface{}{"GET /page/2", "GET /page/3"}
+		if byClient {
+			want = []interface{}{"GET /page/1", "GET /page/2"}
+		}
+		if got := entries[3]["recent_paths"]; !reflect.DeepEqual(got, want) {
+			t.Errorf("byClient %v: recent_paths = %v, want %v", byClient, got, want)
+		}
+	}
+}
+
+func TestGinRecentPathsOnErrorLogs(t *testing.T) {
+	out := useCapturedGlobals(t)
+	e := gin.New()
+	e.Use(GinLoggerWithConfig(GinConfig{RecentPaths: 2}))
+	e.GET("/page/:n", func(c *gin.Context) {})
+	e.GET("/work", func(c *gin.Context) {
+		logger := FromGinContext(c).With(zap.String("job", "sync"))
+		logger.Info("working")
+		logger.Error("job failed")
+	})
+	for _, path := range []string{"/page/1", "/page/2", "/work"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	entries := out.Entries(t)
+	byMsg := make(map[string]map[string]interface{})
+	for _, entry := range entries {
+		byMsg[entry["msg"].(string)] = entry
+	}
+	want := []interface{}{"GET /page/1", "GET /page/2"}
+	if got := byMsg["job failed"]["recent_paths"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("error entry recent_paths = %v, want %v", got, want)
+	}
+	if byMsg["job failed"]["job"] != "sync" {
+		t.Errorf("error entry lost its fields: %v", byMsg["job failed"])
+	}
+	for _, msg := range []string{"working", "/work"} {
+		if v, ok := byMsg[msg]["recent_paths"]; ok {
+			t.Errorf("%s: recent_paths = %v, want none below error level", msg, v)
+		}
+	}
+}
+
+func TestGinCacheHit(t *testing.T) {
+	register := func(e *gin.Engine) {
+		e.GET("/", func(c *gin.Context) {
+			if v := c.Query("hit"); v != "" {
+				c.Set("cached", v == "1")
+			}
+		})
+	}
+	tests := []struct {
+		path string
+		want interface{}
+	}{
+		{"/?hit=1", true},
+		{"/?hit=0", false},
+		{"/", false},
+	}
+	for _, tt := range tests {
+		entry := serveGin(t, GinConfig{CacheHitKey: "cached"}, register, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if got := entry["cache_hit"]; got != tt.want {
+			t.Errorf("%s: cache_hit = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+	entry := serveGin(t, GinConfig{}, register, httptest.NewRequest(http.MethodGet, "/?hit=1", nil))
+	if v, ok := entry["cache_hit"]; ok {
+		t.Errorf("cache_hit = %v logged without CacheHitKey", v)
+	}
+}
+
+func TestGinResponseHeaders(t *testing.T) {
+	register := func(e *gin.Engine) {
+		e.GET("/", func(c *gin.Context) {
+			c.Header("ETag", `"v1"`)
+			c.Header("Cache-Control", "max-age=60")
+			c.String(http.StatusOK, "ok")
+		})
+	}
+	conf := GinConfig{ResponseHeaders: []string{"ETag", "Cache-Control", "Expires"}}
+	entry := serveGin(t, conf, register, httptest.NewRequest(http.MethodGet, "/", nil))
+	if entry["etag"] != `"v1"` || entry["cache_control"] != "max-age=60" {
+		t.Errorf("etag = %v, cache_control = %v", entry["etag"], entry["cache_control"])
+	}
+	if v, ok := entry["expires"]; ok {
+		t.Errorf("missing header logged as expires = %v", v)
+	}
+}
+
+func TestGinRetryHeaders(t *testing.T) {
+	conf := GinConfig{RetryHeader: "X-Retry-Count", IdempotencyHeader: "Idempotency-Key"}
+	register := func(e *gin.Engine) { e.POST("/pay", func(c *gin.Context) {}) }
+	req := httptest.NewRequest(http.MethodPost, "/pay", nil)
+	req.Header.Set("X-Retry-Count", "2")
+	req.Header.Set("Idempotency-Key", "k-1")
+	entry := serveGin(t, conf, register, req)
+	if entry["retry_count"] != 2.0 || entry["idempotency_key"] != "k-1" {
+		t.Errorf("retry_count = %v, idempotency_key = %v, want 2 and k-1", entry["retry_count"], entry["idempotency_key"])
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/pay", nil)
+	req.Header.Set("X-Retry-Count", "many")
+	entry = serveGin(t, conf, register, req)
+	for _, key := range []string{"retry_count", "idempotency_key"} {
+		if _, ok := entry[key]; ok {
+			t.Errorf("%s logged without a valid header", key)
+		}
+	}
+}
+
+func TestGinLabels(t *testing.T) {
+	out := useCapturedGlobals(t)
+	e := gin.New()
+	e.Use(GinLoggerWithConfig(GinConfig{Labels: map[string]string{"region": "eu-west-1", "az": "b"}}))
+	e.GET("/", func(c *gin.Context) { zap.L().Info("handler") })
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	entries := out.Entries(t)
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want app log and request log", len(entries))
+	}
+	if _, ok := entries[0]["region"]; ok {
+		t.Errorf("app log has labels: %v", entries[0])
+	}
+	if entries[1]["region"] != "eu-west-1" || entries[1]["az"] != "b" {
+		t.Errorf("request log = %v, want region and az labels", entries[1])
+	}
+}
+
+func TestNewGinEngine(t *testing.T) {
+	prev := zap.L()
+	t.Cleanup(func() { zap.ReplaceGlobals(prev) })
+	config, out := newCapturedConfig()
+	e := NewGinEngine(config)
+	if config.ReplaceGlobals {
+		t.Error("NewGinEngine modified the caller's config")
+	}
+	e.GET("/ok", func(c *gin.Context) {})
+	e.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", rec.Code)
+	}
+
+	var msgs []string
+	for _, entry := range out.Entries(t) {
+		msgs = append(msgs, fmt.Sprintf("%v %v %v", entry["msg"], entry["path"], entry["status"]))
+		if entry["msg"] == "[Recovery from panic]" && (entry["error"] != "boom" || entry["stacktrace"] == nil) {
+			t.Errorf("recovery entry = %v, want error and stacktrace", entry)
+		}
+	}
+	want := []string{"/ok /ok 200", "[Recovery from panic] /panic <nil>", "/panic /panic 500"}
+	if strings.Join(msgs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("entries = %q, want %q", msgs, want)
+	}
+}
+
+func TestGinRecoveryBody(t *testing.T) {
+	out := useCapturedGlobals(t)
+	e := gin.New()
+	e.Use(GinLoggerWithConfig(GinConfig{Verbosity: GinVerbosity{Body: true}}), GinRecovery(false))
+	e.POST("/orders", func(c *gin.Context) {
+		var order map[string]interface{}
+		if err := c.ShouldBindJSON(&order); err != nil || order["user"] != "u1" {
+			t.Errorf("handler got %v, %v, want the request body", order, err)
+		}
+		panic("boom")
+	})
+	e.GET("/panic", func(c *gin.Context) { panic("boom") })
+	body := `{"user":"u1","password":"hunter2"}`
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body)))
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	var recovered []map[string]interface{}
+	for _, entry := range out.Entries(t) {
+		if entry["msg"] == "[Recovery from panic]" {
+			recovered = append(recovered, entry)
+		}
+	}
+	if len(recovered) != 2 {
+		t.Fatalf("got %d recovery entries, want 2", len(recovered))
+	}
+	got, _ := recovered[0]["body"].(string)
+	if !strings.Contains(got, `"user":"u1"`) || strings.Contains(got, "hunter2") {
+		t.Errorf("recovery body = %q, want the redacted request body", got)
+	}
+	if v, ok := recovered[1]["body"]; ok {
+		t.Errorf("recovery without a body logged body = %v", v)
+	}
+}
+
+func TestGinAPIVersion(t *testing.T) {
+	register := func(e *gin.Engine) {
+		e.GET("/api/:version/users", func(c *gin.Context) {})
+		e.GET("/health", func(c *gin.Context) {})
+	}
+	conf := GinConfig{APIVersion: func(c *gin.Context) string { return c.Param("version") }}
+	entry := serveGin(t, conf, register, httptest.NewRequest(http.MethodGet, "/api/v2/users", nil))
+	if entry["api_version"] != "v2" {
+		t.Errorf("api_version = %v, want v2", entry["api_version"])
+	}
+	entry = serveGin(t, conf, register, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if v, ok := entry["api_version"]; ok {
+		t.Errorf("unversioned path logged api_version = %v", v)
+	}
+}
+
+func TestGinContentNegotiation(t *testing.T) {
+	register := func(e *gin.Engine) {
+		e.GET("/item", func(c *gin.Context) {
+			c.Negotiate(http.StatusOK, gin.Negotiate{
+				Offered: []string{gin.MIMEJSON, gin.MIMEXML},
+				Data:    gin.H{"id": 1},
+			})
+		})
+	}
+	req := httptest.NewRequest(http.MethodGet, "/item", nil)
+	req.Header.Set("Accept", "application/xml;q=0.9, text/html")
+	entry := serveGin(t, GinConfig{LogContentNegotiation: true}, register, req)
+	if entry["accept"] != "application/xml;q=0.9, text/html" {
+		t.Errorf("accept = %v", entry["accept"])
+	}
+	if ct, _ := entry["content_type"].(string); !strings.HasPrefix(ct, gin.MIMEXML) {
+		t.Errorf("content_type = %q, want the negotiated %s", ct, gin.MIMEXML)
+	}
+}
+
+func TestGinRequestIDCorrelation(t *testing.T) {
+	out := useCapturedGlobals(t)
+	e := gin.New()
+	e.Use(GinLoggerWithConfig(GinConfig{RequestID: true}))
+	var fromCtx string
+	e.GET("/orders", func(c *gin.Context) {
+		FromGinContext(c).Info("loading orders")
+		FromContext(c.Request.Context()).With(zap.Int("count", 3)).Info("loaded")
+		fromCtx = RequestIDFromContext(c.Request.Context())
+	})
+	for _, id := range []string{"req-1", ""} {
+		req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		if id != "" {
+			req.Header.Set("X-Request-ID", id)
+		}
+		e.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	entries := out.Entries(t)
+	if len(entries) != 6 {
+		t.Fatalf("got %d entries, want 6", len(entries))
+	}
+	for i := 0; i < len(entries); i += 3 {
+		access := entries[i+2]
+		id, _ := access["request_id"].(string)
+		if id == "" || access["path"] != "/orders" {
+			t.Fatalf("access log = %v, want a request_id", access)
+		}
+		for _, app := range entries[i : i+2] {
+			if app["request_id"] != id {
+				t.Errorf("app log %v: request_id = %v, want the access log's %s", app["msg"], app["request_id"], id)
+			}
+		}
+	}
+	if entries[2]["request_id"] != "req-1" || entries[5]["request_id"] == entries[2]["request_id"] {
+		t.Errorf("request ids = %v, %v, want the header value and a generated one", entries[2]["request_id"], entries[5]["request_id"])
+	}
+	if fromCtx != entries[5]["request_id"] {
+		t.Errorf("RequestIDFromContext = %q, want %v", fromCtx, entries[5]["request_id"])
+	}
+}
+
+func TestGinLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   interface{}
+	}{
+		{"fr-CH, fr;q=0.9, en;q=0.8, de;q=0.7, *;q=0.5", "fr-CH"},
+		{"en;q=0.5, zh-CN;q=0.9, ja", "ja"},
+		{"en;q=0.5, zh-CN;q=0.9", "zh-CN"},
+		{"*;q=1, de;q=0.1", "de"},
+		{"en;q=abc, es;level=1, pt;q=0", nil},
+		{"", nil},
+	}
+	register := func(e *gin.Engine) { e.GET("/", func(c *gin.Context) {}) }
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.header != "" {
+			req.Header.Set("Accept-Language", tt.header)
+		}
+		entry := serveGin(t, GinConfig{LogLanguage: true}, register, req)
+		if entry["lang"] != tt.want {
+			t.Errorf("%q: lang = %v, want %v", tt.header, entry["lang"], tt.want)
+		}
+	}
+}
+
+func TestGinLoggerLazyInit(t *testing.T) {
+	resetBootstrap(t)
+	prev := zap.L()
+	t.Cleanup(func() { zap.ReplaceGlobals(prev) })
+	zap.ReplaceGlobals(Bootstrap())
+
+	// 先创建engine和中间件，之后再初始化Logger
+	e := gin.New()
+	e.Use(GinLogger())
+	e.GET("/ping", func(c *gin.Context) {})
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ping?early=1", nil))
+
+	config, out := newCapturedConfig()
+	config.ReplaceGlobals = true
+	GetLogger(config)
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ping?late=1", nil))
+
+	var queries []interface{}
+	for _, entry := range out.Entries(t) {
+		queries = append(queries, entry["query"])
+	}
+	if want := []interface{}{"early=1", "late=1"}; fmt.Sprint(queries) != fmt.Sprint(want) {
+		t.Errorf("logged queries = %v, want the buffered early request followed by the late one", queries)
+	}
+}
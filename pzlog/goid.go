@@ -0,0 +1,30 @@
+package pzlog
+
+import (
+	"bytes"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"runtime"
+	"strconv"
+)
+
+// goid 从runtime.Stack输出的第一行"goroutine 123 [running]:"中解析当前协程的ID，解析失败时返回0。
+// Go没有公开协程ID，该方法每次调用都要获取调用栈，开销约为一微秒，只应作为调试手段
+func goid() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, err := strconv.ParseUint(string(b), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// goidFields 返回当前协程ID字段goid
+func goidFields(zapcore.Entry) []zapcore.Field {
+	return []zapcore.Field{zap.Uint64("goid", goid())}
+}
@@ -0,0 +1,37 @@
+package pzlog
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestGoroutineID(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.GoroutineID = true
+	logger := GetLogger(config)
+	logger.Info("main")
+	for i := 0; i < 2; i++ {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger.Info("worker")
+		}()
+		wg.Wait()
+	}
+
+	seen := map[interface{}]bool{}
+	for _, e := range out.Entries(t) {
+		id, _ := e["goid"].(float64)
+		if id == 0 {
+			t.Fatalf("%s: goid = %v, want a goroutine id", e["msg"], e["goid"])
+		}
+		if seen[id] {
+			t.Errorf("goid %v logged by different goroutines", id)
+		}
+		seen[id] = true
+	}
+	if len(seen) != 3 {
+		t.Errorf("got %d distinct goids, want 3", len(seen))
+	}
+}
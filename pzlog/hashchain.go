@@ -0,0 +1,125 @@
+package pzlog
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go.uber.org/zap/zapcore"
+	"io"
+	"os"
+	"sync"
+)
+
+// hashChainTail 启动时从已有日志文件末尾读取的最大字节数，用于延续上一条日志的hash
+const hashChainTail = 64 * 1024
+
+// hashChainPrefix 追加在每行json末尾的prev_hash字段的开头
+var hashChainPrefix = []byte(`,"prev_hash":"`)
+
+// hashChainSyncer 为每行json日志追加prev_hash和hash字段，hash为sha256(prev_hash + 追加字段前的日志行)，
+// 形成链式校验，修改或删除其中一行都会导致之后的校验失败
+type hashChainSyncer struct {
+	zapcore.WriteSyncer
+	mu   sync.Mutex
+	prev string
+}
+
+func newHashChainSyncer(ws zapcore.WriteSyncer, prev string) *hashChainSyncer {
+	return &hashChainSyncer{WriteSyncer: ws, prev: prev}
+}
+
+func (w *hashChainSyncer) Write(p []byte) (int, error) {
+	line := bytes.TrimRight(p, "\r\n")
+	if len(line) == 0 || line[len(line)-1] != '}' {
+		return w.WriteSyncer.Write(p)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	hash := chainHash(w.prev, line)
+	buf := make([]byte, 0, len(p)+len(hashChainPrefix)+140)
+	buf = append(buf, line[:len(line)-1]...)
+	buf = append(buf, hashChainPrefix...)
+	buf = append(buf, w.prev...)
+	buf = append(buf, `","hash":"`...)
+	buf = append(buf, hash...)
+	buf = append(buf, `"}`...)
+	buf = append(buf, p[len(line):]...)
+	if _, err := w.WriteSyncer.Write(buf); err != nil {
+		return 0, err
+	}
+	w.prev = hash
+	return len(p), nil
+}
+
+// chainHash 计算sha256(prev + line)的十六进制字符串
+func chainHash(prev string, line []byte) string {
+	h := sha256.New()
+	h.Write([]byte(prev))
+	h.Write(line)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// lastChainHash 返回已有日志文件最后一行的hash，文件不存在或没有hash时返回空字符串
+func lastChainHash(filename string) string {
+	f, err := os.Open(filename)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return ""
+	}
+	offset := info.Size() - hashChainTail
+	if offset < 0 {
+		offset = 0
+	}
+	data := make([]byte, info.Size()-offset)
+	if _, err := f.ReadAt(data, offset); err != nil && err != io.EOF {
+		return ""
+	}
+	lines := bytes.Split(bytes.TrimRight(data, "\r\n"), []byte("\n"))
+	var entry struct {
+		Hash string `json:"hash"`
+	}
+	if err := json.Unmarshal(lines[len(lines)-1], &entry); err != nil {
+		return ""
+	}
+	return entry.Hash
+}
+
+// VerifyHashChain 校验HashChain开启时写入的日志，校验失败时返回的错误包含第一处失败的行号(从1开始)和原因。
+// 第一行的prev_hash不做校验，因此可以单独校验切割后的文件
+func VerifyHashChain(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	var prev string
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Bytes()
+		i := bytes.LastIndex(line, hashChainPrefix)
+		if i < 0 {
+			return fmt.Errorf("pzlog: line %d: missing prev_hash", n)
+		}
+		var entry struct {
+			PrevHash string `json:"prev_hash"`
+			Hash     string `json:"hash"`
+		}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return fmt.Errorf("pzlog: line %d: %w", n, err)
+		}
+		if n > 1 && entry.PrevHash != prev {
+			return fmt.Errorf("pzlog: line %d: prev_hash does not match the previous line", n)
+		}
+		base := make([]byte, 0, i+1)
+		base = append(base, line[:i]...)
+		base = append(base, '}')
+		if chainHash(entry.PrevHash, base) != entry.Hash {
+			return fmt.Errorf("pzlog: line %d: hash mismatch", n)
+		}
+		prev = entry.Hash
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,50 @@
+package pzlog
+
+import (
+	"go.uber.org/zap"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHashChain(t *testing.T) {
+	config := NewDefaultConfig()
+	config.Filename = filepath.Join(t.TempDir(), "audit.log")
+	config.HashChain = true
+	audit, err := NewAuditLogger(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	audit.Log("user.create", zap.String("target", "u1"))
+	audit.Log("user.grant", zap.String("target", "u1"))
+	// 重新打开时从已有文件的最后一行延续哈希链
+	audit, err = NewAuditLogger(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	audit.Log("user.delete", zap.String("target", "u1"))
+	_ = audit.Sync()
+
+	data := readFile(t, config.Filename)
+	lines := strings.Split(strings.TrimSuffix(data, "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3", len(lines))
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, `"prev_hash":"`) || !strings.Contains(line, `"hash":"`) {
+			t.Fatalf("line %q misses prev_hash or hash", line)
+		}
+	}
+	if err := VerifyHashChain(strings.NewReader(data)); err != nil {
+		t.Fatalf("VerifyHashChain() = %v, want a consistent chain", err)
+	}
+
+	tampered := strings.Replace(data, `"msg":"user.grant"`, `"msg":"user.revoke"`, 1)
+	if err := VerifyHashChain(strings.NewReader(tampered)); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("tampered middle entry: err = %v, want a line 2 failure", err)
+	}
+	removed := lines[0] + "\n" + lines[2] + "\n"
+	if err := VerifyHashChain(strings.NewReader(removed)); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("removed middle entry: err = %v, want a line 2 failure", err)
+	}
+}
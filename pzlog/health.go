@@ -0,0 +1,146 @@
+package pzlog
+
+import (
+	"errors"
+	"go.uber.org/zap/zapcore"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// LoggingHealth 日志系统的健康状态
+type LoggingHealth struct {
+	// 最近一次GetLogger或Reconfigure创建的各输出的状态
+	Sinks []SinkHealth `json:"sinks"`
+
+	// 异步模式下因缓冲区满丢弃的日志总数
+	DroppedEntries int64 `json:"dropped_entries"`
+
+	// 各级别被采样丢弃的日志条数
+	DroppedSamples map[string]uint64 `json:"dropped_samples"`
+}
+
+// SinkHealth 单个输出的状态
+type SinkHealth struct {
+	// 输出名称，file、stdout、stderr、callback、none或者console
+	Name string `json:"name"`
+
+	// 日志文件路径，只对文件输出有效
+	Filename string `json:"filename,omitempty"`
+
+	// 是否可写，文件输出检查文件(不存在时检查目录)，其他输出根据最近一次写入是否出错判断
+	Writable bool `json:"writable"`
+
+	// 最近一次写入的时间和错误
+	LastWrite time.Time `json:"last_write"`
+	LastError string    `json:"last_error,omitempty"`
+
+	// 写入的日志条数
+	Writes uint64 `json:"writes"`
+
+	// 通过SetSinkLevel单独设置的日志级别，跟随全局级别时为空
+	Level string `json:"level,omitempty"`
+
+	// 是否异步写入，以及异步缓冲区中的条数、容量和丢弃的条数
+	Async     bool  `json:"async"`
+	BufferLen int   `json:"buffer_len"`
+	BufferCap int   `json:"buffer_cap"`
+	Dropped   int64 `json:"dropped"`
+}
+
+// trackedSink 记录写入状态的WriteSyncer
+type trackedSink struct {
+	zapcore.WriteSyncer
+	name     string
+	filename string
+	async    *asyncWriteSyncer
+	// level 输出单独的日志级别，为nil时不能单独调整
+	level *sinkLevel
+
+	writes    atomic.Uint64
+	lastWrite atomic.Int64
+	lastErr   atomic.Pointer[string]
+}
+
+func newTrackedSink(name, filename string, ws zapcore.WriteSyncer) *trackedSink {
+	return &trackedSink{WriteSyncer: ws, name: name, filename: filename}
+}
+
+func (s *trackedSink) Write(p []byte) (int, error) {
+	n, err := s.WriteSyncer.Write(p)
+	s.writes.Add(1)
+	s.lastWrite.Store(time.Now().UnixNano())
+	if err != nil {
+		msg := err.Error()
+		s.lastErr.Store(&msg)
+	} else {
+		s.lastErr.Store(nil)
+	}
+	return n, err
+}
+
+func (s *trackedSink) health() SinkHealth {
+	h := SinkHealth{
+		Name:     s.name,
+		Filename: s.filename,
+		Writes:   s.writes.Load(),
+	}
+	if t := s.lastWrite.Load(); t > 0 {
+		h.LastWrite = time.Unix(0, t)
+	}
+	if msg := s.lastErr.Load(); msg != nil {
+		h.LastError = *msg
+	}
+	if s.filename != "" {
+		h.Writable = fileWritable(s.filename)
+	} else {
+		h.Writable = h.LastError == ""
+	}
+	if s.level != nil && s.level.set.Load() {
+		h.Level = zapcore.Level(s.level.level.Load()).String()
+	}
+	if s.async != nil {
+		h.Async = true
+		h.BufferLen = len(s.async.queue)
+		h.BufferCap = cap(s.async.queue)
+		h.Dropped = s.async.dropped.Load()
+	}
+	return h
+}
+
+// fileWritable 检查文件是否可以追加写入，文件不存在时检查所在目录是否存在(lumberjack会在写入时创建文件)
+func fileWritable(filename string) bool {
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND, 0)
+	if err == nil {
+		_ = f.Close()
+		return true
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return false
+	}
+	info, err := os.Stat(filepath.Dir(filename))
+	if err != nil {
+		// 目录不存在时lumberjack会创建
+		return errors.Is(err, os.ErrNotExist)
+	}
+	return info.IsDir()
+}
+
+// Health 返回日志系统的健康状态，包括最近一次GetLogger或Reconfigure创建的各输出的状态
+func Health() LoggingHealth {
+	h := LoggingHealth{
+		DroppedEntries: DroppedEntries(),
+		DroppedSamples: DroppedSamplesByLevel(),
+	}
+	currentSwapMu.Lock()
+	state := currentSwap
+	currentSwapMu.Unlock()
+	if state == nil {
+		return h
+	}
+	for _, s := range state.root.Load().sinks {
+		h.Sinks = append(h.Sinks, s.health())
+	}
+	return h
+}
@@ -0,0 +1,67 @@
+package pzlog
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHealthAsync(t *testing.T) {
+	release := make(chan struct{})
+	config := NewDefaultConfig()
+	config.Output = "callback"
+	config.Callback = func([]byte) { <-release }
+	config.Async = true
+	config.AsyncBufferSize = 4
+	logger := GetLogger(config)
+
+	start := time.Now()
+	logger.Info("first")
+	time.Sleep(10 * time.Millisecond)
+	logger.Info("second")
+	logger.Info("third")
+
+	h := Health()
+	if len(h.Sinks) != 1 {
+		t.Fatalf("got %d sinks, want 1", len(h.Sinks))
+	}
+	s := h.Sinks[0]
+	if s.Name != "callback" || !s.Async || s.BufferLen != 2 || s.BufferCap != 4 {
+		t.Errorf("sink health = %+v, want 2 of 4 buffered", s)
+	}
+
+	close(release)
+	_ = logger.Sync()
+	s = Health().Sinks[0]
+	if s.Writes != 3 || s.BufferLen != 0 || !s.Writable {
+		t.Errorf("sink health after drain = %+v", s)
+	}
+	if s.LastWrite.Before(start) {
+		t.Errorf("LastWrite = %v, want after %v", s.LastWrite, start)
+	}
+}
+
+func TestHealthFile(t *testing.T) {
+	redirectStdout(t)
+	config := NewDefaultConfig()
+	config.Filename = filepath.Join(t.TempDir(), "app.log")
+	config.PrintConsole = true
+	logger := GetLogger(config)
+	defer func() { _ = Close() }()
+
+	h := Health()
+	if len(h.Sinks) != 2 {
+		t.Fatalf("got %d sinks, want file and console", len(h.Sinks))
+	}
+	file := h.Sinks[0]
+	if file.Name != "file" || file.Filename != config.Filename || !file.Writable || file.Writes != 0 {
+		t.Errorf("file health before write = %+v", file)
+	}
+	logger.Info("hello")
+	if file = Health().Sinks[0]; file.Writes != 1 || file.LastWrite.IsZero() {
+		t.Errorf("file health after write = %+v", file)
+	}
+	if h.DroppedSamples == nil {
+		t.Error("DroppedSamples not reported")
+	}
+}
@@ -0,0 +1,23 @@
+package pzlog
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"time"
+)
+
+// LogAt 使用指定的时间记录日志，用于回放历史事件等场景，日志的ts为t而不是当前时间
+func LogAt(t time.Time, level zapcore.Level, msg string, fields ...zap.Field) {
+	if ce := zap.L().WithOptions(zap.AddCallerSkip(1)).Check(level, msg); ce != nil {
+		ce.Time = t
+		ce.Write(fields...)
+	}
+}
+
+// LogWithCaller 使用指定的调用位置记录日志，用于日志适配器等代替其他位置记录日志的场景，caller_line为file:line而不是实际的调用位置
+func LogWithCaller(file string, line int, level zapcore.Level, msg string, fields ...zap.Field) {
+	if ce := zap.L().Check(level, msg); ce != nil {
+		ce.Caller = zapcore.EntryCaller{Defined: true, File: file, Line: line}
+		ce.Write(fields...)
+	}
+}
@@ -0,0 +1,65 @@
+package pzlog
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogAt(t *testing.T) {
+	out := useCapturedGlobals(t)
+	at := time.Date(2020, 5, 6, 7, 8, 9, 0, time.Local)
+	LogAt(at, zapcore.WarnLevel, "replayed", zap.String("event", "e1"))
+	LogAt(at, zapcore.DebugLevel, "disabled")
+
+	entries := out.Entries(t)
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	if ts := entries[0]["ts"]; ts != at.Format(logTmFmt) {
+		t.Errorf("ts = %v, want %s", ts, at.Format(logTmFmt))
+	}
+	if entries[0]["event"] != "e1" || entries[0]["level"] != "WARN" {
+		t.Errorf("unexpected entry %v", entries[0])
+	}
+}
+
+func TestLogAtCaller(t *testing.T) {
+	logs := observeGlobals(t, zapcore.InfoLevel)
+	LogAt(time.Now(), zapcore.InfoLevel, "replayed")
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	if file := entries[0].Caller.File; !strings.HasSuffix(file, "helper_test.go") {
+		t.Errorf("caller = %s, want helper_test.go", file)
+	}
+}
+
+func TestLogWithCaller(t *testing.T) {
+	logs := observeGlobals(t, zapcore.InfoLevel)
+	LogWithCaller("adapter/legacy.go", 42, zapcore.InfoLevel, "forwarded")
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	if c := entries[0].Caller; c.File != "adapter/legacy.go" || c.Line != 42 {
+		t.Errorf("caller = %s:%d, want adapter/legacy.go:42", c.File, c.Line)
+	}
+}
+
+func TestLogWithCallerLine(t *testing.T) {
+	out := useCapturedGlobals(t)
+	LogWithCaller("adapter/legacy.go", 42, zapcore.WarnLevel, "forwarded", zap.String("from", "legacy"))
+	LogWithCaller("adapter/legacy.go", 43, zapcore.DebugLevel, "disabled")
+
+	entries := out.Entries(t)
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	if e := entries[0]; e["caller_line"] != "adapter/legacy.go:42" || e["from"] != "legacy" || e["level"] != "WARN" {
+		t.Errorf("entry = %v, want caller_line adapter/legacy.go:42", e)
+	}
+}
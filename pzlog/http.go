@@ -0,0 +1,23 @@
+package pzlog
+
+import (
+	"go.uber.org/zap"
+	"net"
+	"net/http"
+)
+
+// RequestFields 返回与GinLogger相同key的请求字段method、path、query、ip和user-agent，用于不使用gin的HTTP处理函数。
+// ip取自RemoteAddr的主机部分，不解析X-Forwarded-For等代理请求头
+func RequestFields(r *http.Request) []zap.Field {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ip = host
+	}
+	return []zap.Field{
+		zap.String("method", r.Method),
+		zap.String("path", r.URL.Path),
+		zap.String("query", r.URL.RawQuery),
+		zap.String("ip", ip),
+		zap.String("user-agent", r.UserAgent()),
+	}
+}
@@ -0,0 +1,40 @@
+package pzlog
+
+import (
+	"go.uber.org/zap/zapcore"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRequestFields(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/users?page=2", nil)
+	req.RemoteAddr = "10.0.0.7:51234"
+	req.Header.Set("User-Agent", "curl/8.0")
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range RequestFields(req) {
+		f.AddTo(enc)
+	}
+	want := map[string]interface{}{
+		"method":     "POST",
+		"path":       "/api/users",
+		"query":      "page=2",
+		"ip":         "10.0.0.7",
+		"user-agent": "curl/8.0",
+	}
+	if !reflect.DeepEqual(enc.Fields, want) {
+		t.Errorf("fields = %v, want %v", enc.Fields, want)
+	}
+
+	// RemoteAddr不带端口时原样记录
+	req.RemoteAddr = "unix"
+	enc = zapcore.NewMapObjectEncoder()
+	for _, f := range RequestFields(req) {
+		f.AddTo(enc)
+	}
+	if enc.Fields["ip"] != "unix" {
+		t.Errorf("ip = %v, want unix", enc.Fields["ip"])
+	}
+}
@@ -0,0 +1,28 @@
+package pzlog
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"os"
+)
+
+// k8sEnvFields 通过downward API注入的环境变量及对应的字段名
+var k8sEnvFields = []struct {
+	env   string
+	field string
+}{
+	{"POD_NAME", "k8s.pod"},
+	{"POD_NAMESPACE", "k8s.namespace"},
+	{"NODE_NAME", "k8s.node"},
+}
+
+// k8sFields 返回Kubernetes的pod、命名空间和节点字段，未设置的环境变量不记录
+func k8sFields() []zapcore.Field {
+	var fields []zapcore.Field
+	for _, f := range k8sEnvFields {
+		if v := os.Getenv(f.env); v != "" {
+			fields = append(fields, zap.String(f.field, v))
+		}
+	}
+	return fields
+}
@@ -0,0 +1,28 @@
+package pzlog
+
+import (
+	"testing"
+)
+
+func TestK8sInfo(t *testing.T) {
+	t.Setenv("POD_NAME", "api-7d9f")
+	t.Setenv("POD_NAMESPACE", "prod")
+	t.Setenv("NODE_NAME", "")
+
+	config, out := newCapturedConfig()
+	config.K8sInfo = true
+	GetLogger(config).Info("started")
+	e := out.Entries(t)[0]
+	if e["k8s.pod"] != "api-7d9f" || e["k8s.namespace"] != "prod" {
+		t.Errorf("entry = %v, want k8s.pod and k8s.namespace", e)
+	}
+	if v, ok := e["k8s.node"]; ok {
+		t.Errorf("unset NODE_NAME logged as k8s.node = %v", v)
+	}
+
+	config, out = newCapturedConfig()
+	GetLogger(config).Info("started")
+	if v, ok := out.Entries(t)[0]["k8s.pod"]; ok {
+		t.Errorf("k8s.pod = %v logged without K8sInfo", v)
+	}
+}
@@ -0,0 +1,111 @@
+package pzlog
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultLatencyWindow = 1000
+	// latencyMinSamples 路由的样本数达到该值后才判断是否超过p99
+	latencyMinSamples = 100
+)
+
+// latencyTracker 按路由记录最近请求的耗时
+type latencyTracker struct {
+	mu     sync.Mutex
+	size   int
+	routes map[string]*latencyWindow
+}
+
+func newLatencyTracker(size int) *latencyTracker {
+	if size <= 0 {
+		size = defaultLatencyWindow
+	}
+	return &latencyTracker{size: size, routes: make(map[string]*latencyWindow)}
+}
+
+// observe 记录路由的一次耗时，返回该耗时是否超过记录前的p99，样本不足时ok为false
+func (t *latencyTracker) observe(route string, d time.Duration) (slow, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	w := t.routes[route]
+	if w == nil {
+		w = &latencyWindow{samples: make([]time.Duration, 0, t.size)}
+		t.routes[route] = w
+	}
+	if len(w.samples) >= latencyMinSamples {
+		slow, ok = d > w.p99, true
+	}
+	w.add(d)
+	return slow, ok
+}
+
+// latencyWindow 固定大小的耗时环形缓冲区，p99每recalc个样本重新计算一次
+type latencyWindow struct {
+	samples []time.Duration
+	next    int
+	p99     time.Duration
+	pending int
+}
+
+func (w *latencyWindow) add(d time.Duration) {
+	if len(w.samples) < cap(w.samples) {
+		w.samples = append(w.samples, d)
+	} else {
+		w.samples[w.next] = d
+		w.next = (w.next + 1) % len(w.samples)
+	}
+	w.pending++
+	if recalc := cap(w.samples) / 10; w.pending >= recalc || len(w.samples) == latencyMinSamples {
+		w.pending = 0
+		sorted := append([]time.Duration(nil), w.samples...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		w.p99 = sorted[len(sorted)*99/100]
+	}
+}
+
+// latencyBuckets 按上界从小到大排列的耗时分桶
+type latencyBuckets struct {
+	bounds []time.Duration
+	labels []string
+	// over 超过最大上界时的标签
+	over string
+}
+
+func newLatencyBuckets(bounds []time.Duration) *latencyBuckets {
+	if len(bounds) == 0 {
+		return nil
+	}
+	sorted := append([]time.Duration(nil), bounds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	b := &latencyBuckets{bounds: sorted, labels: make([]string, len(sorted))}
+	for i, d := range sorted {
+		b.labels[i] = "le_" + bucketDuration(d)
+	}
+	b.over = "gt_" + bucketDuration(sorted[len(sorted)-1])
+	return b
+}
+
+// label 返回耗时所在分桶的标签，例如le_100ms，超过所有上界时为gt_加最大上界
+func (b *latencyBuckets) label(d time.Duration) string {
+	i := sort.Search(len(b.bounds), func(i int) bool { return b.bounds[i] >= d })
+	if i == len(b.bounds) {
+		return b.over
+	}
+	return b.labels[i]
+}
+
+// bucketDuration 将分桶上界格式化为简短的文本，整秒为2s，整毫秒为100ms，其余使用time.Duration的格式
+func bucketDuration(d time.Duration) string {
+	switch {
+	case d > 0 && d%time.Second == 0:
+		return strconv.FormatInt(int64(d/time.Second), 10) + "s"
+	case d > 0 && d%time.Millisecond == 0:
+		return strconv.FormatInt(int64(d/time.Millisecond), 10) + "ms"
+	default:
+		return d.String()
+	}
+}
@@ -0,0 +1,81 @@
+package pzlog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyTracker(t *testing.T) {
+	tracker := newLatencyTracker(0)
+	for i := 1; i < latencyMinSamples; i++ {
+		if _, ok := tracker.observe("GET /a", time.Duration(i)*time.Millisecond); ok {
+			t.Fatalf("sample %d: ok with too few samples", i)
+		}
+	}
+	for i := latencyMinSamples; i <= 200; i++ {
+		tracker.observe("GET /a", time.Duration(i%100+1)*time.Millisecond)
+	}
+	if slow, ok := tracker.observe("GET /a", time.Second); !ok || !slow {
+		t.Errorf("1s: slow = %v, ok = %v, want slow", slow, ok)
+	}
+	if slow, ok := tracker.observe("GET /a", 10*time.Millisecond); !ok || slow {
+		t.Errorf("10ms: slow = %v, ok = %v, want not slow", slow, ok)
+	}
+	// 路由分别统计
+	if _, ok := tracker.observe("GET /b", time.Second); ok {
+		t.Error("GET /b: ok without samples of its own")
+	}
+}
+
+func TestLatencyTrackerSlowVsP99(t *testing.T) {
+	tracker := newLatencyTracker(0)
+	for i := 0; i < latencyMinSamples; i++ {
+		tracker.observe("GET /work", time.Millisecond)
+	}
+	tests := []struct {
+		latency time.Duration
+		slow    bool
+	}{
+		{50 * time.Millisecond, true},
+		{time.Millisecond, false},
+		{500 * time.Microsecond, false},
+	}
+	for _, tt := range tests {
+		if slow, ok := tracker.observe("GET /work", tt.latency); !ok || slow != tt.slow {
+			t.Errorf("%v: slow = %v, ok = %v, want slow = %v", tt.latency, slow, ok, tt.slow)
+		}
+	}
+}
+
+func TestLatencyBuckets(t *testing.T) {
+	buckets := newLatencyBuckets([]time.Duration{500 * time.Millisecond, 100 * time.Millisecond, 2 * time.Second})
+	tests := []struct {
+		latency time.Duration
+		want    string
+	}{
+		{time.Millisecond, "le_100ms"},
+		{100 * time.Millisecond, "le_100ms"},
+		{101 * time.Millisecond, "le_500ms"},
+		{time.Second, "le_2s"},
+		{3 * time.Second, "gt_2s"},
+	}
+	for _, tt := range tests {
+		if got := buckets.label(tt.latency); got != tt.want {
+			t.Errorf("label(%v) = %q, want %q", tt.latency, got, tt.want)
+		}
+	}
+	// 未排序的上界
+	unsorted := newLatencyBuckets([]time.Duration{time.Second, 20 * time.Millisecond})
+	if got := unsorted.label(30 * time.Millisecond); got != "le_1s" {
+		t.Errorf("label(30ms) = %q, want le_1s", got)
+	}
+	if got := unsorted.label(0); got != "le_20ms" {
+		t.Errorf("label(0) = %q, want le_20ms", got)
+	}
+	if newLatencyBuckets(nil) != nil {
+		t.Error("buckets created without bounds")
+	}
+	if got := bucketDuration(1500 * time.Microsecond); got != "1.5ms" {
+		t.Errorf("bucketDuration(1.5ms) = %q", got)
+	}
+}
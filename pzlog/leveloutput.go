@@ -0,0 +1,98 @@
+package pzlog
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"sync"
+	"sync/atomic"
+)
+
+// levelOutputs 运行时按级别重定向的输出，键为日志级别
+type levelOutputs struct {
+	enc zapcore.Encoder
+	// header csv格式时各输出开头的表头
+	header []byte
+	mu     sync.Mutex
+	cores  atomic.Pointer[map[zapcore.Level]zapcore.Core]
+}
+
+func newLevelOutputs(enc zapcore.Encoder, header []byte) *levelOutputs {
+	o := &levelOutputs{enc: enc, header: header}
+	o.cores.Store(&map[zapcore.Level]zapcore.Core{})
+	return o
+}
+
+// set 将level级别的日志重定向到ws，ws为nil时恢复写入原来的输出
+func (o *levelOutputs) set(level zapcore.Level, ws zapcore.WriteSyncer) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	old := *o.cores.Load()
+	cores := make(map[zapcore.Level]zapcore.Core, len(old)+1)
+	for l, c := range old {
+		cores[l] = c
+	}
+	if ws == nil {
+		delete(cores, level)
+	} else {
+		cores[level] = zapcore.NewCore(o.enc, withCSVHeader(ws, o.header), zap.LevelEnablerFunc(func(l zapcore.Level) bool { return l == level }))
+	}
+	o.cores.Store(&cores)
+}
+
+// levelOutputCore 将被重定向级别的日志写入SetLevelOutput设置的输出，其余日志写入原来的输出
+type levelOutputCore struct {
+	zapcore.Core
+	outputs *levelOutputs
+	// fields With添加的字段，写入重定向的输出时添加
+	fields []zapcore.Field
+}
+
+func (c *levelOutputCore) With(fields []zapcore.Field) zapcore.Core {
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+	all = append(all, fields...)
+	return &levelOutputCore{Core: c.Core.With(fields), outputs: c.outputs, fields: all}
+}
+
+func (c *levelOutputCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *levelOutputCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	core, ok := (*c.outputs.cores.Load())[entry.Level]
+	if !ok {
+		return c.Core.Write(entry, fields)
+	}
+	if len(c.fields) > 0 {
+		core = core.With(c.fields)
+	}
+	return core.Write(entry, fields)
+}
+
+func (c *levelOutputCore) Sync() error {
+	err := c.Core.Sync()
+	for _, core := range *c.outputs.cores.Load() {
+		if syncErr := core.Sync(); err == nil {
+			err = syncErr
+		}
+	}
+	return err
+}
+
+// SetLevelOutput 在运行时将最近一次GetLogger创建的Logger中level级别的日志重定向到ws(例如新的文件)，无需重启。
+// 只替换该级别的输出，其他级别不受影响；ws为nil时恢复写入原来的输出。Reconfigure之后重定向失效。
+// 重定向的日志使用主输出的编码格式，With添加的字段在每次写入时重新编码
+func SetLevelOutput(level zapcore.Level, ws zapcore.WriteSyncer) error {
+	currentSwapMu.Lock()
+	state := currentSwap
+	currentSwapMu.Unlock()
+	if state == nil {
+		return errors.New("pzlog: no logger, call GetLogger first")
+	}
+	state.root.Load().outputs.set(level, ws)
+	return nil
+}
@@ -0,0 +1,37 @@
+package pzlog
+
+import (
+	"bytes"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"strings"
+	"testing"
+)
+
+func TestSetLevelOutput(t *testing.T) {
+	config, out := newCapturedConfig()
+	logger := GetLogger(config).With(zap.String("svc", "api"))
+	logger.Error("before")
+
+	var errs bytes.Buffer
+	if err := SetLevelOutput(zapcore.ErrorLevel, zapcore.AddSync(&errs)); err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("info")
+	logger.Error("redirected")
+	if err := SetLevelOutput(zapcore.ErrorLevel, nil); err != nil {
+		t.Fatal(err)
+	}
+	logger.Error("restored")
+
+	var msgs []string
+	for _, e := range out.Entries(t) {
+		msgs = append(msgs, e["msg"].(string))
+	}
+	if got := strings.Join(msgs, ","); got != "before,info,restored" {
+		t.Errorf("original output got %s, want before,info,restored", got)
+	}
+	if got := errs.String(); strings.Count(got, "\n") != 1 || !strings.Contains(got, `"msg":"redirected"`) || !strings.Contains(got, `"svc":"api"`) {
+		t.Errorf("redirected output = %q, want only the redirected error with the With fields", got)
+	}
+}
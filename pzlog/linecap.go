@@ -0,0 +1,43 @@
+package pzlog
+
+import (
+	"bytes"
+	"go.uber.org/zap/zapcore"
+	"strconv"
+	"unicode/utf8"
+)
+
+// lineCapSyncer 对编码后的整行日志做硬性长度限制，超过max字节的行保留前max字节并追加"...(line truncated N bytes)"标记
+type lineCapSyncer struct {
+	zapcore.WriteSyncer
+	max int
+}
+
+func newLineCapSyncer(ws zapcore.WriteSyncer, max int) *lineCapSyncer {
+	return &lineCapSyncer{WriteSyncer: ws, max: max}
+}
+
+func (w *lineCapSyncer) Write(p []byte) (int, error) {
+	if len(p) <= w.max {
+		return w.WriteSyncer.Write(p)
+	}
+	line := bytes.TrimRight(p, "\r\n")
+	ending := p[len(line):]
+	if len(line) <= w.max {
+		return w.WriteSyncer.Write(p)
+	}
+	n := w.max
+	for n > 0 && !utf8.RuneStart(line[n]) {
+		n--
+	}
+	buf := make([]byte, 0, n+32+len(ending))
+	buf = append(buf, line[:n]...)
+	buf = append(buf, "...(line truncated "...)
+	buf = strconv.AppendInt(buf, int64(len(line)-n), 10)
+	buf = append(buf, " bytes)"...)
+	buf = append(buf, ending...)
+	if _, err := w.WriteSyncer.Write(buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,42 @@
+package pzlog
+
+import (
+	"bytes"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"strings"
+	"testing"
+)
+
+func TestMaxLineBytes(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.MaxLineBytes = 100
+	logger := GetLogger(config)
+	logger.Info("huge", zap.String("payload", strings.Repeat("x", 10000)))
+	logger.Info("small")
+
+	lines := out.Lines()
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	const marker = "...(line truncated "
+	i := strings.Index(lines[0], marker)
+	if i != 100 || !strings.HasPrefix(lines[0], `{"level":"INFO"`) || !strings.HasSuffix(lines[0], " bytes)") {
+		t.Errorf("line = %q, want the first 100 bytes followed by the marker", lines[0])
+	}
+	if strings.Contains(lines[1], marker) {
+		t.Errorf("small line truncated: %q", lines[1])
+	}
+}
+
+func TestLineCapSyncer(t *testing.T) {
+	var buf bytes.Buffer
+	w := newLineCapSyncer(zapcore.AddSync(&buf), 4)
+	// 不截断多字节字符，保留换行
+	if n, err := w.Write([]byte("ab日志\n")); err != nil || n != 9 {
+		t.Fatalf("Write = %d, %v", n, err)
+	}
+	if got, want := buf.String(), "ab...(line truncated 6 bytes)\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -1,11 +1,14 @@
 package pzlog
 
 import (
-	"github.com/gin-gonic/gin"
+	"errors"
+	"fmt"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"gopkg.in/natefinch/lumberjack.v2"
+	"io"
 	"os"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -16,7 +19,7 @@ const (
 
 var (
 	Logger *zap.Logger
-	m      = map[string]interface{}{
+	m      = map[string]zapcore.Level{
 		"debug":  zap.DebugLevel,
 		"info":   zap.InfoLevel,
 		"warn":   zap.WarnLevel,
@@ -28,16 +31,273 @@ var (
 )
 
 type PzlogConfig struct {
-	lumberjack.Logger
+	// Filename可以包含日期模板%Y、%m、%d、%H，例如./logs/app-%Y%m%d.log，日期变化后写入新的文件，时间取自Clock
+	lumberjack.Logger `yaml:",inline"`
 
 	TimeFormat string `json:"timeformat" yaml:"timeformat"`
 
 	LogLevel string `json:"loglevel" yaml:"loglevel"`
 
+	// 是否严格解析LogLevel，为true时无法识别的日志级别使GetLoggerE、MustGetLogger和Reconfigure返回错误，
+	// 默认无法识别时使用info。GetLogger不检查配置，总是使用info
+	StrictLevels bool `json:"strictlevels" yaml:"strictlevels"`
+
 	PrintConsole bool `json:"printconsole" yaml:"printconsole"`
 
-	// 日志格式，json或者console
+	// 日志格式，json、console、console-oneline、msgpack、loki、csv或者es-bulk(Elasticsearch _bulk接口的NDJSON)
 	Encoder string `json:"encoder" yaml:"encoder"`
+
+	// 服务名，loki格式下作为顶层的service字段输出
+	Service string `json:"service" yaml:"service"`
+
+	// 日志输出位置，file、stdout、stderr、callback或者none，默认file
+	Output string `json:"output" yaml:"output"`
+
+	// Output为callback时，每条编码后的日志都会交给该函数处理
+	Callback func([]byte) `json:"-" yaml:"-"`
+
+	// 日志采样配置，为nil时不采样
+	Sampling *SamplingConfig `json:"sampling" yaml:"sampling"`
+
+	// 启动期采样配置，创建Logger后的一段时间内更严格地采样，时间取自Clock，为nil时不启用
+	WarmupSampling *WarmupSamplingConfig `json:"warmupsampling" yaml:"warmupsampling"`
+
+	// 是否在level之外额外输出数值形式的level_num字段
+	LevelNumber bool `json:"levelnumber" yaml:"levelnumber"`
+
+	// 是否额外输出完整单词形式的日志级别level_verbose(debug、info、warning、error、dpanic、panic、fatal)，level字段保持不变
+	LevelVerbose bool `json:"levelverbose" yaml:"levelverbose"`
+
+	// 是否用创建的Logger替换zap的全局Logger(zap.L()和zap.S())，默认false，不修改全局Logger。
+	// FromContext使用的TraceIDContextKey、BaggageKeys、DatadogSpan、EventKey等设置也只在为true时生效
+	ReplaceGlobals bool `json:"replaceglobals" yaml:"replaceglobals"`
+
+	// 写入不低于该级别的日志后立即刷新输出，例如error，为空时不主动刷新
+	SyncOnLevel string `json:"synconlevel" yaml:"synconlevel"`
+
+	// 是否异步写入日志
+	Async bool `json:"async" yaml:"async"`
+
+	// 异步缓冲区满时的处理策略，drop-newest、drop-oldest或者block，默认drop-newest
+	BackpressureStrategy string `json:"backpressurestrategy" yaml:"backpressurestrategy"`
+
+	// 异步写入的缓冲条数，默认1024
+	AsyncBufferSize int `json:"asyncbuffersize" yaml:"asyncbuffersize"`
+
+	// 异步缓冲区溢出时输出丢弃条数警告的间隔，为0时不输出
+	AsyncDropReportInterval time.Duration `json:"asyncdropreportinterval" yaml:"asyncdropreportinterval"`
+
+	// 同名字段只保留最后一个值，调用时传入的字段可以覆盖With添加的默认字段
+	DedupFields bool `json:"dedupfields" yaml:"dedupfields"`
+
+	// 编码每条日志时同名字段只保留最后一个值，包括Uptime、LevelNumber等选项添加的字段，避免输出重复的key。
+	// With添加的字段在创建子Logger时已经编码，不参与去重，需要时配合DedupFields使用
+	DedupEntryFields bool `json:"dedupentryfields" yaml:"dedupentryfields"`
+
+	// 慢操作日志配置，不为nil时慢操作日志额外写入单独的文件
+	SlowLog *SlowLogConfig `json:"slowlog" yaml:"slowlog"`
+
+	// 将所有字段以key=value的形式追加到消息中，只输出时间、级别、调用位置和消息，适用于只接受纯文本消息的旧系统
+	FlattenFields bool `json:"flattenfields" yaml:"flattenfields"`
+
+	// 值为NaN或Inf的float字段的编码方式，null、string或者skip，为空时使用zap默认的编码
+	NaNHandling string `json:"nanhandling" yaml:"nanhandling"`
+
+	// 数组和切片字段最多输出的元素个数，超出部分以"…(+N more)"标记代替，为0时不限制
+	MaxArrayLength int `json:"maxarraylength" yaml:"maxarraylength"`
+
+	// 将调用位置拆分为caller_file(字符串)和caller_line(整数)两个字段输出
+	SplitCaller bool `json:"splitcaller" yaml:"splitcaller"`
+
+	// 捕获编码和写入日志时发生的panic，向stderr输出替代日志后继续运行
+	PanicSafe bool `json:"panicsafe" yaml:"panicsafe"`
+
+	// 控制台输出error及以上级别的日志时，若控制台为终端则输出响铃字符(\a)
+	ConsoleBell bool `json:"consolebell" yaml:"consolebell"`
+
+	// ConsoleBell开启时，控制台输出error及以上级别的日志后调用，可用于发送桌面通知
+	ConsoleNotify func(zapcore.Entry) `json:"-" yaml:"-"`
+
+	// 不输出值为nil或空(空字符串、空切片、空map)的字段
+	OmitEmpty bool `json:"omitempty" yaml:"omitempty"`
+
+	// 是否输出进程启动以来的毫秒数(uptime_ms)，基于单调时钟，不受系统时间调整影响
+	Uptime bool `json:"uptime" yaml:"uptime"`
+
+	// 消息和字符串字段中控制字符(换行和制表符除外)的处理方式，escape或者strip，为空时不处理
+	ControlChars string `json:"controlchars" yaml:"controlchars"`
+
+	// 运行环境，例如development、production
+	Env string `json:"env" yaml:"env"`
+
+	// Env为production(或prod)且日志级别为debug时，创建Logger后输出一条警告
+	WarnDebugInProduction bool `json:"warndebuginproduction" yaml:"warndebuginproduction"`
+
+	// 按字段路由日志的配置，不为nil时带有路由字段的日志只写入对应的输出
+	Route *RouteConfig `json:"route" yaml:"route"`
+
+	// 按级别限制每秒输出日志条数，为nil时不限速
+	RateLimit *RateLimitConfig `json:"ratelimit" yaml:"ratelimit"`
+
+	// 时间显示使用的时区(IANA名称，例如Asia/Shanghai)，为空时使用本地时区
+	TimeZone string `json:"timezone" yaml:"timezone"`
+
+	// PrintConsole开启时，限制每秒输出到控制台的日志条数，超出的日志只写入文件，为nil时不限速
+	ConsoleRateLimit *RateLimitConfig `json:"consoleratelimit" yaml:"consoleratelimit"`
+
+	// 对日志消息进行脱敏的正则表达式，匹配的内容替换为***，例如token=\S+
+	RedactMessage []string `json:"redactmessage" yaml:"redactmessage"`
+
+	// 写入日志失败(例如磁盘已满)时调用，相同的错误一分钟内只调用一次
+	OnWriteError func(error) `json:"-" yaml:"-"`
+
+	// PID文件路径，不为空时GetLogger写入当前进程的PID，Close时删除，供日志管理脚本发送信号
+	PIDFile string `json:"pidfile" yaml:"pidfile"`
+
+	// 保存跟踪ID的context键，不为nil时FromContext返回的Logger自动附加context中的跟踪ID，
+	// 用于gin之外通过context传递跟踪ID的代码
+	TraceIDContextKey interface{} `json:"-" yaml:"-"`
+
+	// 跟踪ID的字段名，默认trace_id
+	TraceIDField string `json:"traceidfield" yaml:"traceidfield"`
+
+	// 日志文件及其备份的总大小上限(MB)，超过时从最旧的备份开始删除，为0时不限制，
+	// 配置了Shards时统计所有分片，不能与Sinks或包含日期模板的文件名同时使用
+	MaxTotalSize int `json:"maxtotalsize" yaml:"maxtotalsize"`
+
+	// 每条日志附加Go版本及运行平台字段(go_version、goos、goarch)，便于排查问题
+	RuntimeInfo bool `json:"runtimeinfo" yaml:"runtimeinfo"`
+
+	// 每条日志附加从环境变量POD_NAME、POD_NAMESPACE、NODE_NAME(通过Kubernetes downward API注入)读取的
+	// k8s.pod、k8s.namespace、k8s.node字段，未设置的环境变量不记录
+	K8sInfo bool `json:"k8sinfo" yaml:"k8sinfo"`
+
+	// 时长字段的编码方式，seconds(浮点秒数)、ms(毫秒数)、nanos(纳秒数)或string(Go格式的字符串，例如"12.5ms")，默认seconds
+	DurationEncoding string `json:"durationencoding" yaml:"durationencoding"`
+
+	// 每写入该条数的日志后强制切割日志文件(与文件大小无关)，用于测试、CI等需要按条数分文件的场景，为0时不按条数切割。
+	// 备份文件名精确到毫秒，同一毫秒内多次切割时较早的备份会被覆盖
+	RotateEvery int `json:"rotateevery" yaml:"rotateevery"`
+
+	// 独立的日志输出，每个输出分别指定编码格式、输出位置和日志级别，不为空时取代Output和PrintConsole。
+	// PrintConsole相当于Output和stdout两个输出
+	Sinks []SinkConfig `json:"sinks" yaml:"sinks"`
+
+	// 日志输出格式的版本号，大于0时每条日志附加log_format_version字段，供日志消费方在字段调整后按版本区分处理。
+	// 与服务版本无关，只表示日志格式约定的版本
+	FormatVersion int `json:"formatversion" yaml:"formatversion"`
+
+	// FromContext返回的Logger需要附加的baggage成员(例如OpenTelemetry baggage中的tenant)，字段名为成员名，需要同时设置Baggage
+	BaggageKeys []string `json:"baggagekeys" yaml:"baggagekeys"`
+
+	// 从context中读取baggage成员的函数
+	Baggage BaggageFunc `json:"-" yaml:"-"`
+
+	// 从context中读取当前span的函数，设置后FromContext返回的Logger和GinLogger的请求日志附加Datadog格式的
+	// dd.trace_id和dd.span_id字段(低64位的十进制字符串)，用于在Datadog中关联日志和链路
+	DatadogSpan SpanContextFunc `json:"-" yaml:"-"`
+
+	// 是否为错误字段(zap.Error等)追加错误指纹字段error_fingerprint，便于聚合相似的错误，见ErrorFingerprint
+	ErrorFingerprint bool `json:"errorfingerprint" yaml:"errorfingerprint"`
+
+	// 编码缓冲区的最小容量(字节)，日志经常超过1KB时设置为常见的日志大小，避免缓冲区反复扩容，为0时使用zap的默认值
+	EncoderBufferSize int `json:"encoderbuffersize" yaml:"encoderbuffersize"`
+
+	// 日志文件的分片数，大于1时日志轮流写入多个文件(例如app.log分为app.0.log、app.1.log...)，各文件独立切割，
+	// 便于日志采集程序并行读取
+	Shards int `json:"shards" yaml:"shards"`
+
+	// 日志消息的最大字节数，超过时截断并追加"...(truncated N bytes)"，只作用于消息，不影响字段，为0时不限制
+	MaxMessageLength int `json:"maxmessagelength" yaml:"maxmessagelength"`
+
+	// 自定义采样逻辑，不为nil时只记录ShouldLog返回true的日志，可与Sampling同时使用
+	Sampler Sampler `json:"-" yaml:"-"`
+
+	// 是否在日志文件旁写入json格式的清单文件(例如./logs/pzlog.manifest.json)，记录日志文件、切割策略和格式版本，
+	// Reconfigure时更新，供运维工具读取
+	WriteManifest bool `json:"writemanifest" yaml:"writemanifest"`
+
+	// 是否只为带有WithCaller()标记字段的日志记录调用位置，开启后其余日志不再查找调用栈
+	CallerOnDemand bool `json:"callerondemand" yaml:"callerondemand"`
+
+	// Encoder为csv时的列，可以是ts、level、caller、msg、logger、stacktrace或者字段名，
+	// 其余字段以json对象的形式放在最后的fields列，默认ts、level、caller、msg。
+	// 每个输出和每个新日志文件(包括切割后的文件)的开头写入一次表头，追加写入已有文件时不重复写入
+	CSVColumns []string `json:"csvcolumns" yaml:"csvcolumns"`
+
+	// 日志时间的时钟，为nil时使用系统时间，可用于测试
+	Clock zapcore.Clock `json:"-" yaml:"-"`
+
+	// 系统时间跳变的检测阈值，大于0时相邻两次写入日志时的系统时间间隔与单调时钟间隔相差超过该值时，
+	// 在日志中追加clock_jump和clock_jump_ms(正数为向后跳)字段，用于排查虚拟机时钟跳变
+	ClockJumpThreshold time.Duration `json:"clockjumpthreshold" yaml:"clockjumpthreshold"`
+
+	// 是否额外输出布尔字段is_error，warn及以上级别为true，其余为false
+	IsErrorField bool `json:"iserrorfield" yaml:"iserrorfield"`
+
+	// 按cron表达式(分 时 日 月 周，例如"0 3 * * 0"表示每周日3点)切割日志文件，到达计划时间后的第一条日志写入前切割，
+	// 时间取自Clock
+	RotateCron string `json:"rotatecron" yaml:"rotatecron"`
+
+	// 编码后单行日志的最大字节数，超出的部分被截断(不区分消息和字段)并追加被截断字节数的标记，0表示不限制。
+	// 截断后的行不再是合法的json，仅作为防止异常日志撑爆磁盘或采集端的兜底
+	MaxLineBytes int `json:"maxlinebytes" yaml:"maxlinebytes"`
+
+	// 是否将字段的key转换为小写，用于区分大小写的下游索引。对象字段递归处理，数组和map内部的key不处理
+	LowercaseKeys bool `json:"lowercasekeys" yaml:"lowercasekeys"`
+
+	// LowercaseKeys为true时是否同时将日志消息转换为小写
+	LowercaseMessage bool `json:"lowercasemessage" yaml:"lowercasemessage"`
+
+	// 仅用于审计日志(NewAuditLogger、InitAudit)，为true时在写入json审计文件的同时以console格式输出到标准错误，便于实时查看
+	AuditConsole bool `json:"auditconsole" yaml:"auditconsole"`
+
+	// 返回当前功能开关的函数，设置时每条日志添加flags对象，结果缓存1秒
+	FlagSnapshot func() map[string]bool `json:"-" yaml:"-"`
+
+	// 不记录调用位置的包，调用函数的全名(例如github.com/foo/bar.Func)或源文件路径以其中任一前缀开头时去掉调用位置
+	SuppressCallerPrefixes []string `json:"suppresscallerprefixes" yaml:"suppresscallerprefixes"`
+
+	// 是否记录写日志的协程ID(goid)，用于关联交错的日志。协程ID通过解析runtime.Stack获取，每条日志增加约一微秒的开销
+	GoroutineID bool `json:"goroutineid" yaml:"goroutineid"`
+
+	// console格式日志级别的显示方式，short为单个大写字母(D、I、W、E、P、F，dpanic和panic均为P)，为空时显示完整的级别名称
+	ConsoleLevelFormat string `json:"consolelevelformat" yaml:"consolelevelformat"`
+
+	// json旁路日志文件，设置时每条日志在写入主输出的同时以json格式写入该文件，例如主输出为便于阅读的console格式，
+	// 旁路文件供程序解析。两者共用一次级别检查和同一组字段，只是分别编码，按主日志文件的配置切割
+	JSONSidecar string `json:"jsonsidecar" yaml:"jsonsidecar"`
+
+	// 字段无法编码(例如json不支持的类型、MarshalLogObject返回错误)时的处理方式：drop丢弃整条日志，
+	// fallback只输出消息和编码错误encode_error，panic直接panic(用于测试，不要与PanicSafe同时使用)。
+	// 为空时保持zap的默认行为，将无法编码的字段替换为<key>Error
+	OnEncodeError string `json:"onencodeerror" yaml:"onencodeerror"`
+
+	// Encoder为es-bulk时动作行中的索引名，为空时输出{"index":{}}，由_bulk请求的URL指定索引
+	ESIndex string `json:"esindex" yaml:"esindex"`
+
+	// LogEvent记录事件名的字段名，默认event
+	EventKey string `json:"eventkey" yaml:"eventkey"`
+
+	// 写入前按顺序执行的处理阶段，例如脱敏、截断、添加字段，前一阶段的输出作为后一阶段的输入。
+	// 处理阶段能看到Uptime、FlagSnapshot等选项添加的字段
+	Processors []EntryProcessor `json:"-" yaml:"-"`
+
+	// 是否为写入文件的每行json日志追加prev_hash和hash字段形成哈希链，主要用于审计日志的防篡改校验，
+	// 可通过VerifyHashChain校验。启动时从已有文件的最后一行延续哈希链，只支持json格式，不应与MaxLineBytes、Shards同时使用
+	HashChain bool `json:"hashchain" yaml:"hashchain"`
+
+	// 静默时段配置，时段内提高最低日志级别，时段结束后自动恢复，时间取自Clock，按TimeZone判断
+	QuietHours *QuietHoursConfig `json:"quiethours" yaml:"quiethours"`
+
+	// level 由setDefaultValue解析LogLevel得到的日志级别
+	level zapcore.Level
+
+	// invalidLevel 无法识别的LogLevel，用于StrictLevels
+	invalidLevel string
+
+	// location 由setDefaultValue加载TimeZone得到的时区，加载失败时为本地时区
+	location *time.Location
 }
 
 func NewDefaultConfig() *PzlogConfig {
@@ -52,6 +312,7 @@ func NewDefaultConfig() *PzlogConfig {
 		LogLevel:     "",
 		PrintConsole: false,
 		Encoder:      "",
+		Output:       "",
 	}
 }
 
@@ -75,56 +336,386 @@ func setDefaultValue(config *PzlogConfig) {
 	if config.Encoder == "" {
 		config.Encoder = "json"
 	}
-	_, ok := m[strings.ToLower(config.LogLevel)]
-	if config.LogLevel == "" || !ok {
+	if config.Output == "" {
+		config.Output = "file"
+	}
+	level, ok := parseLevel(config.LogLevel)
+	config.invalidLevel = ""
+	if !ok {
+		if config.LogLevel != "" {
+			config.invalidLevel = config.LogLevel
+		}
 		config.LogLevel = "info"
 	}
+	config.level = level
+	location, err := loadLocation(config.TimeZone)
+	if err != nil {
+		location = time.Local
+	}
+	config.location = location
 
 }
 
-func GinLogger() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		start := time.Now()
-		path := c.Request.URL.Path
-		query := c.Request.URL.RawQuery
-		c.Next()
-		cost := time.Since(start)
-		zap.L().Info(path,
-			zap.Int("status", c.Writer.Status()),
-			zap.String("method", c.Request.Method),
-			zap.String("path", path),
-			zap.String("query", query),
-			zap.String("ip", c.ClientIP()),
-			zap.String("user-agent", c.Request.UserAgent()),
-			zap.String("errors", c.Errors.ByType(gin.ErrorTypePrivate).String()),
-			zap.Duration("cost", cost),
-		)
+// validateConfig 检查配置中无效或相互矛盾的组合
+func validateConfig(config *PzlogConfig) error {
+	if config.StrictLevels && config.invalidLevel != "" {
+		return fmt.Errorf("pzlog: unknown log level %q", config.invalidLevel)
+	}
+	switch config.Encoder {
+	case "json", "console", "console-oneline", "msgpack", "loki", "csv", "es-bulk":
+	default:
+		return fmt.Errorf("pzlog: unknown encoder %q, must be json, console, console-oneline, msgpack, loki, csv or es-bulk", config.Encoder)
+	}
+	switch config.Output {
+	case "file", "stdout", "stderr", "none":
+	case "callback":
+		if config.Callback == nil {
+			return fmt.Errorf("pzlog: output %q requires a callback", config.Output)
+		}
+	default:
+		return fmt.Errorf("pzlog: unknown output %q, must be file, stdout, stderr, callback or none", config.Output)
+	}
+	if _, err := loadLocation(config.TimeZone); err != nil {
+		return fmt.Errorf("pzlog: invalid timezone %q: %w", config.TimeZone, err)
+	}
+	if config.SyncOnLevel != "" {
+		if _, ok := parseLevel(config.SyncOnLevel); !ok {
+			return fmt.Errorf("pzlog: unknown synconlevel %q", config.SyncOnLevel)
+		}
+	}
+	switch config.BackpressureStrategy {
+	case "", BackpressureDropNewest, BackpressureDropOldest, BackpressureBlock:
+	default:
+		return fmt.Errorf("pzlog: unknown backpressurestrategy %q, must be drop-newest, drop-oldest or block", config.BackpressureStrategy)
+	}
+	switch config.NaNHandling {
+	case "", NaNAsNull, NaNAsString, NaNSkip:
+	default:
+		return fmt.Errorf("pzlog: unknown nanhandling %q, must be null, string or skip", config.NaNHandling)
+	}
+	if config.RotateCron != "" {
+		if _, err := parseCron(config.RotateCron); err != nil {
+			return fmt.Errorf("pzlog: invalid rotatecron %q: %w", config.RotateCron, err)
+		}
+	}
+	if config.QuietHours != nil {
+		if _, _, err := config.QuietHours.window(); err != nil {
+			return fmt.Errorf("pzlog: quiethours: %w", err)
+		}
+		if _, err := config.QuietHours.level(); err != nil {
+			return fmt.Errorf("pzlog: quiethours: %w", err)
+		}
+	}
+	switch config.OnEncodeError {
+	case "", EncodeErrorDrop, EncodeErrorFallback, EncodeErrorPanic:
+	default:
+		return fmt.Errorf("pzlog: unknown onencodeerror %q, must be drop, fallback or panic", config.OnEncodeError)
+	}
+	if config.ConsoleLevelFormat != "" && config.ConsoleLevelFormat != ConsoleLevelShort {
+		return fmt.Errorf("pzlog: unknown consolelevelformat %q, must be short", config.ConsoleLevelFormat)
 	}
+	switch config.DurationEncoding {
+	case "", DurationSeconds, DurationMillis, DurationNanos, DurationString:
+	default:
+		return fmt.Errorf("pzlog: unknown durationencoding %q, must be seconds, ms, nanos or string", config.DurationEncoding)
+	}
+	switch config.ControlChars {
+	case "", ControlCharsEscape, ControlCharsStrip:
+	default:
+		return fmt.Errorf("pzlog: unknown controlchars %q, must be escape or strip", config.ControlChars)
+	}
+	for _, pattern := range config.RedactMessage {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("pzlog: invalid redactmessage pattern %q: %w", pattern, err)
+		}
+	}
+	for i := range config.Sinks {
+		if err := config.Sinks[i].validate(); err != nil {
+			return fmt.Errorf("pzlog: sinks[%d]: %w", i, err)
+		}
+	}
+	if len(config.Sinks) > 0 && config.PrintConsole {
+		return errors.New("pzlog: sinks conflicts with printconsole, add a stdout sink instead")
+	}
+	if len(config.Sinks) > 0 && config.JSONSidecar != "" {
+		return errors.New("pzlog: sinks conflicts with jsonsidecar, add a json file sink instead")
+	}
+	if len(config.Sinks) > 0 && config.MaxTotalSize > 0 {
+		return errors.New("pzlog: maxtotalsize is not supported with sinks, use maxbackups or maxage of each sink instead")
+	}
+	if isDatedFilename(config.Filename) && config.MaxTotalSize > 0 {
+		return errors.New("pzlog: maxtotalsize is not supported with dated filenames, use maxage instead")
+	}
+	if config.PrintConsole {
+		switch config.Output {
+		case "none":
+			return fmt.Errorf("pzlog: output %q conflicts with printconsole, logs would be both discarded and printed", config.Output)
+		case "stdout":
+			return fmt.Errorf("pzlog: output %q with printconsole would print every entry to stdout twice", config.Output)
+		}
+	}
+	return nil
 }
 
+// GetLogger 根据配置创建Logger，无效的配置项会被忽略
 func GetLogger(config *PzlogConfig) *zap.Logger {
 	if config == nil {
 		config = NewDefaultConfig()
 	}
 	setDefaultValue(config)
-	Encoder := getEncoder(config.Encoder)
-	WriteSyncer := getWriteSyncer(config)
-	LevelEnabler := getLevelEnabler(config)
+	return newLogger(config)
+}
+
+// GetLoggerE 根据配置创建Logger，配置无效时返回错误
+func GetLoggerE(config *PzlogConfig) (*zap.Logger, error) {
+	if config == nil {
+		config = NewDefaultConfig()
+	}
+	setDefaultValue(config)
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+	return newLogger(config), nil
+}
+
+// MustGetLogger 同GetLoggerE，配置无效时panic，适用于main函数中初始化
+func MustGetLogger(config *PzlogConfig) *zap.Logger {
+	logger, err := GetLoggerE(config)
+	if err != nil {
+		panic(err)
+	}
+	return logger
+}
+
+func newLogger(config *PzlogConfig) *zap.Logger {
+	root, _ := newCore(config)
+	applyGlobals(config, root.resources)
+	state := newSwapState(root)
+	state.callerOnDemand = config.CallerOnDemand
+	state.clock = config.Clock
+	currentSwapMu.Lock()
+	currentSwap = state
+	currentSwapMu.Unlock()
+	attachBootstrap(state)
+	var opts []zap.Option
+	if !config.CallerOnDemand {
+		opts = append(opts, zap.AddCaller())
+	}
+	if config.Clock != nil {
+		opts = append(opts, zap.WithClock(config.Clock))
+	}
+	logger := zap.New(newSwapCore(state), opts...)
+	if config.ReplaceGlobals {
+		zap.ReplaceGlobals(logger)
+	}
+	if config.PIDFile != "" {
+		if err := writePIDFile(config.PIDFile); err != nil {
+			logger.Warn("pzlog: failed to write pid file", zap.String("pidfile", config.PIDFile), zap.Error(err))
+		}
+	}
+	if config.WriteManifest {
+		if err := writeManifest(config); err != nil {
+			logger.Warn("pzlog: failed to write manifest", zap.String("manifest", manifestPath(config.Filename)), zap.Error(err))
+		}
+	}
+	if config.WarnDebugInProduction && config.level == zap.DebugLevel && isProduction(config.Env) {
+		logger.Warn("pzlog: debug level is enabled in production environment, this may hurt performance and leak sensitive data",
+			zap.String("env", config.Env), zap.String("loglevel", config.LogLevel))
+	}
+	return logger
+}
+
+// applyGlobals 启动属于Logger的日志目录清理器(记录到res)，ReplaceGlobals时再应用FromContext读取的跟踪ID、baggage等作用于整个包的设置，
+// 避免为其他组件创建的Logger覆盖全局Logger的设置
+func applyGlobals(config *PzlogConfig, res *coreResources) {
+	startDirSweeper(config, res)
+	if !config.ReplaceGlobals {
+		return
+	}
+	setTraceIDKey(config.TraceIDContextKey, config.TraceIDField)
+	setBaggage(config.BaggageKeys, config.Baggage)
+	setDatadogSpan(config.DatadogSpan)
+	setEventKey(config.EventKey)
+}
+
+// isProduction 判断是否为生产环境
+func isProduction(env string) bool {
+	switch strings.ToLower(env) {
+	case "prod", "production":
+		return true
+	}
+	return false
+}
+
+// BuildCore 根据配置组装core，返回core及控制其级别的AtomicLevel，可用于与zap.New及其他Option组合
+func BuildCore(config *PzlogConfig) (zapcore.Core, zap.AtomicLevel, error) {
+	if config == nil {
+		config = NewDefaultConfig()
+	}
+	setDefaultValue(config)
+	if err := validateConfig(config); err != nil {
+		return nil, zap.AtomicLevel{}, err
+	}
+	root, level := newCore(config)
+	return root.core, level, nil
+}
+
+// newCore 根据配置组装core，返回的rootCore同时记录写入状态的各输出、按级别重定向的输出及打开的资源
+func newCore(config *PzlogConfig) (*rootCore, zap.AtomicLevel) {
+	Encoder := newEncoder(config, config.Encoder)
+	LevelEnabler := zap.NewAtomicLevelAt(getLevelEnabler(config))
+	res := &coreResources{}
+	var newCore zapcore.Core
+	var sinks []*trackedSink
+	if len(config.Sinks) > 0 {
+		newCore, sinks = newSinksCore(config, LevelEnabler, res)
+	} else {
+		newCore, sinks = newOutputCore(config, Encoder, LevelEnabler, res)
+	}
+	outputs := newLevelOutputs(Encoder, csvHeader(config, config.Encoder))
+	newCore = &levelOutputCore{Core: newCore, outputs: outputs}
+	if len(config.Processors) > 0 {
+		newCore = &processorCore{Core: newCore, processors: config.Processors}
+	}
+	if config.Route != nil {
+		newCore = newRouteCore(newCore, config.Route, Encoder, csvHeader(config, config.Encoder), LevelEnabler, res)
+	}
+	if config.SlowLog != nil {
+		newCore = newSlowRouteCore(newCore, newSlowLogCore(config.SlowLog, Encoder, csvHeader(config, config.Encoder), LevelEnabler, res), config.SlowLog.Threshold)
+	}
+	if len(config.SuppressCallerPrefixes) > 0 {
+		newCore = &callerFilterCore{Core: newCore, prefixes: config.SuppressCallerPrefixes}
+	}
+	if config.CallerOnDemand {
+		newCore = &markerCallerCore{Core: newCore}
+	}
+	if config.ClockJumpThreshold > 0 {
+		newCore = newClockJumpCore(newCore, config.ClockJumpThreshold, time.Now, processMono)
+	}
+	if config.Uptime {
+		newCore = newFieldHookCore(newCore, uptimeFields)
+	}
+	if config.GoroutineID {
+		newCore = newFieldHookCore(newCore, goidFields)
+	}
+	if config.FlagSnapshot != nil {
+		newCore = newFieldHookCore(newCore, newFlagSnapshotCache(config.FlagSnapshot, clockNow(config)).flagFields)
+	}
+	if config.RuntimeInfo {
+		newCore = newCore.With(runtimeFields())
+	}
+	if config.K8sInfo {
+		if fs := k8sFields(); len(fs) > 0 {
+			newCore = newCore.With(fs)
+		}
+	}
+	if config.FormatVersion > 0 {
+		newCore = newCore.With([]zapcore.Field{zap.Int("log_format_version", config.FormatVersion)})
+	}
+	if config.ErrorFingerprint {
+		newCore = &errorFingerprintCore{Core: newCore}
+	}
+	if config.SyncOnLevel != "" {
+		if level, ok := parseLevel(config.SyncOnLevel); ok {
+			newCore = newSyncOnLevelCore(newCore, level)
+		}
+	}
+	if config.DedupFields {
+		newCore = newDedupCore(newCore)
+	}
+	if config.PanicSafe {
+		newCore = newPanicSafeCore(newCore, zapcore.Lock(os.Stderr))
+	}
+	if config.Sampling != nil {
+		newCore = newSamplerCore(newCore, config.Sampling, clockNow(config))
+	}
+	if config.WarmupSampling != nil {
+		newCore = newWarmupSamplerCore(newCore, config.WarmupSampling, clockNow(config))
+	}
+	if config.QuietHours != nil {
+		newCore = newQuietHoursCore(newCore, config.QuietHours, clockNow(config), config.location)
+	}
+	if config.Sampler != nil {
+		newCore = &customSamplerCore{Core: newCore, sampler: config.Sampler}
+	}
+	if config.RateLimit != nil && config.RateLimit.PerSecond > 0 {
+		newCore = newRateLimitCore(newCore, config.RateLimit, time.Now)
+	}
+	return &rootCore{core: newCore, sinks: sinks, outputs: outputs, resources: res}, LevelEnabler
+}
+
+// newOutputCore 根据Output和PrintConsole组装写入主输出(及控制台)的core
+func newOutputCore(config *PzlogConfig, Encoder zapcore.Encoder, LevelEnabler zap.AtomicLevel, res *coreResources) (zapcore.Core, []*trackedSink) {
+	var filename string
+	if config.Output == "file" {
+		filename = config.Filename
+	}
+	ws := getWriteSyncer(config, res)
+	if config.HashChain && filename != "" {
+		ws = newHashChainSyncer(ws, lastChainHash(filename))
+	}
+	if config.MaxLineBytes > 0 {
+		ws = newLineCapSyncer(ws, config.MaxLineBytes)
+	}
+	if config.OnWriteError != nil {
+		ws = newWriteErrorSyncer(ws, config.OnWriteError, time.Now)
+	}
+	mainSink := newTrackedSink(config.Output, filename, ws)
+	sinks := []*trackedSink{mainSink}
+	var WriteSyncer zapcore.WriteSyncer = mainSink
+	if config.Async {
+		mainSink.async = newAsyncWriteSyncer(mainSink, Encoder, config.AsyncBufferSize, config.BackpressureStrategy, config.AsyncDropReportInterval)
+		res.add(mainSink.async)
+		WriteSyncer = mainSink.async
+	}
 	//ConsoleEncoder := getConsoleEncoder(config.Encoder)
 	var newCore zapcore.Core
+	mainSink.level = newSinkLevel(LevelEnabler)
 	if config.PrintConsole {
+		consoleWS := withCSVHeader(zapcore.Lock(os.Stdout), csvHeader(config, config.Encoder))
+		if config.MaxLineBytes > 0 {
+			consoleWS = newLineCapSyncer(consoleWS, config.MaxLineBytes)
+		}
+		consoleSink := newTrackedSink("console", "", consoleWS)
+		consoleSink.level = newSinkLevel(LevelEnabler)
+		sinks = append(sinks, consoleSink)
+		var consoleCore zapcore.Core = &sinkCore{Core: zapcore.NewCore(Encoder, consoleSink, consoleSink.level)}
+		if config.ConsoleBell {
+			consoleCore = newConsoleBellCore(consoleCore, os.Stdout, config.ConsoleNotify)
+		}
+		if config.ConsoleRateLimit != nil && config.ConsoleRateLimit.PerSecond > 0 {
+			consoleCore = newRateLimitCore(consoleCore, config.ConsoleRateLimit, time.Now)
+		}
 		newCore = zapcore.NewTee(
-			zapcore.NewCore(Encoder, WriteSyncer, LevelEnabler),             // 写入文件
-			zapcore.NewCore(Encoder, zapcore.Lock(os.Stdout), LevelEnabler), // 写入控制台
+			&sinkCore{Core: zapcore.NewCore(Encoder, WriteSyncer, mainSink.level)}, // 写入文件
+			consoleCore, // 写入控制台
 		)
 	} else {
-		newCore = zapcore.NewCore(Encoder, WriteSyncer, LevelEnabler)
+		newCore = &sinkCore{Core: zapcore.NewCore(Encoder, WriteSyncer, mainSink.level)}
+		if config.ConsoleBell {
+			switch config.Output {
+			case "stdout":
+				newCore = newConsoleBellCore(newCore, os.Stdout, config.ConsoleNotify)
+			case "stderr":
+				newCore = newConsoleBellCore(newCore, os.Stderr, config.ConsoleNotify)
+			}
+		}
+	}
+	if config.JSONSidecar != "" {
+		sidecar := newTrackedSink("sidecar", config.JSONSidecar, getFileWriteSyncer(config, &config.Logger, config.JSONSidecar, nil, res))
+		sidecar.level = newSinkLevel(LevelEnabler)
+		sinks = append(sinks, sidecar)
+		newCore = zapcore.NewTee(newCore, &sinkCore{Core: zapcore.NewCore(newEncoder(config, "json"), sidecar, sidecar.level)})
 	}
-	return zap.New(newCore, zap.AddCaller())
+	return newCore, sinks
 }
 
 // GetEncoder 自定义的Encoder
-func getEncoder(types string) zapcore.Encoder {
+func getEncoder(types string, formatTime func(time.Time) string, encodeDuration zapcore.DurationEncoder, consoleLevel zapcore.LevelEncoder) zapcore.Encoder {
+	if types == "msgpack" {
+		return newMsgpackEncoder(formatTime)
+	}
+	encodeTime := timeEncoder(formatTime)
 	if types == "console" {
 		return zapcore.NewConsoleEncoder(
 			zapcore.EncoderConfig{
@@ -136,9 +727,9 @@ func getEncoder(types string) zapcore.Encoder {
 				MessageKey:     "msg",
 				StacktraceKey:  "stacktrace",
 				LineEnding:     zapcore.DefaultLineEnding,
-				EncodeLevel:    cEncodeLevel,
-				EncodeTime:     cEncodeTime,
-				EncodeDuration: zapcore.SecondsDurationEncoder,
+				EncodeLevel:    consoleLevel,
+				EncodeTime:     encodeTime,
+				EncodeDuration: encodeDuration,
 				EncodeCaller:   cEncodeCaller,
 			})
 	} else {
@@ -153,8 +744,8 @@ func getEncoder(types string) zapcore.Encoder {
 				StacktraceKey:  "stacktrace",
 				LineEnding:     zapcore.DefaultLineEnding,
 				EncodeLevel:    cEncodeLevel,
-				EncodeTime:     cEncodeTime,
-				EncodeDuration: zapcore.SecondsDurationEncoder,
+				EncodeTime:     encodeTime,
+				EncodeDuration: encodeDuration,
 				EncodeCaller:   cEncodeCaller,
 			})
 	}
@@ -169,38 +760,95 @@ func getConsoleEncoder(types string) zapcore.Encoder {
 	return zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
 }
 
-// getWriteSyncer 自定义的WriteSyncer
-func getWriteSyncer(config *PzlogConfig) zapcore.WriteSyncer {
+// getWriteSyncer 自定义的WriteSyncer，csv格式时在输出或每个新文件的开头写入表头，打开的文件记录到res
+func getWriteSyncer(config *PzlogConfig, res *coreResources) zapcore.WriteSyncer {
+	header := csvHeader(config, config.Encoder)
+	switch config.Output {
+	case "stdout":
+		return withCSVHeader(zapcore.Lock(os.Stdout), header)
+	case "stderr":
+		return withCSVHeader(zapcore.Lock(os.Stderr), header)
+	case "none":
+		return zapcore.AddSync(io.Discard)
+	case "callback":
+		if config.Callback != nil {
+			return withCSVHeader(zapcore.Lock(NewCallbackWriteSyncer(config.Callback)), header)
+		}
+	}
+	return getFilesWriteSyncer(config, &config.Logger, config.Filename, header, res)
+}
+
+// getFilesWriteSyncer 创建写入filename的WriteSyncer，配置了Shards时写入多个分片文件，
+// 单个文件的大小、备份数等限制取自limits，主输出和Sinks中的文件输出共用，打开的文件记录到res
+func getFilesWriteSyncer(config *PzlogConfig, limits *lumberjack.Logger, filename string, header []byte, res *coreResources) zapcore.WriteSyncer {
+	if config.Shards > 1 {
+		shards := make([]zapcore.WriteSyncer, config.Shards)
+		for i := range shards {
+			shards[i] = getFileWriteSyncer(config, limits, shardFilename(filename, i), header, res)
+		}
+		return newShardWriteSyncer(shards)
+	}
+	return getFileWriteSyncer(config, limits, filename, header, res)
+}
+
+// getFileWriteSyncer 创建写入filename的WriteSyncer，按配置切割，filename包含日期模板时按日期切换文件，打开的文件记录到res
+func getFileWriteSyncer(config *PzlogConfig, limits *lumberjack.Logger, filename string, header []byte, res *coreResources) zapcore.WriteSyncer {
+	if isDatedFilename(filename) {
+		dated := newDatedWriteSyncer(filename, clockNow(config), func(name string) (zapcore.WriteSyncer, *lumberjack.Logger) {
+			return newFileWriteSyncer(config, limits, name, header)
+		})
+		res.add(dated)
+		return dated
+	}
+	ws, logger := newFileWriteSyncer(config, limits, filename, header)
+	res.add(logger)
+	return ws
+}
+
+// newFileWriteSyncer 创建写入filename的lumberjack，使用limits的大小、备份数等限制，并按配置添加按条数和按cron表达式的切割，
+// header不为nil时在每个新文件的开头写入header(csv表头)
+func newFileWriteSyncer(config *PzlogConfig, limits *lumberjack.Logger, filename string, header []byte) (zapcore.WriteSyncer, *lumberjack.Logger) {
 	lumberJackLogger := &lumberjack.Logger{
-		Filename:   config.Filename,
-		MaxSize:    config.MaxSize,
-		MaxBackups: config.MaxBackups,
-		MaxAge:     config.MaxAge,
+		Filename:   filename,
+		MaxSize:    limits.MaxSize,
+		MaxBackups: limits.MaxBackups,
+		MaxAge:     limits.MaxAge,
+		LocalTime:  limits.LocalTime,
+		Compress:   limits.Compress,
+	}
+	file := withCSVHeaderFile(lumberJackLogger, header)
+	var ws zapcore.WriteSyncer = zapcore.AddSync(file)
+	if config.RotateEvery > 0 {
+		ws = newCountRotateSyncer(file, config.RotateEvery)
+	}
+	if config.RotateCron != "" {
+		if schedule, err := parseCron(config.RotateCron); err == nil {
+			ws = newCronRotateSyncer(ws, file, schedule, clockNow(config))
+		}
+	}
+	return ws, lumberJackLogger
+}
+
+// clockNow 返回配置的时钟，未配置时使用系统时间
+func clockNow(config *PzlogConfig) func() time.Time {
+	if config.Clock != nil {
+		return config.Clock.Now
+	}
+	return time.Now
+}
+
+// parseLevel 解析日志级别字符串(不区分大小写)，无法识别时返回InfoLevel和false
+func parseLevel(text string) (zapcore.Level, bool) {
+	level, ok := m[strings.ToLower(text)]
+	if !ok {
+		return zap.InfoLevel, false
 	}
-	return zapcore.AddSync(lumberJackLogger)
+	return level, true
 }
 
 // GetLevelEnabler 自定义的LevelEnabler
 func getLevelEnabler(config *PzlogConfig) zapcore.Level {
-	level := strings.ToLower(config.LogLevel)
-	switch level {
-	case "debug":
-		return zap.DebugLevel
-	case "info":
-		return zap.InfoLevel
-	case "warn":
-		return zap.WarnLevel
-	case "error":
-		return zap.ErrorLevel
-	case "dpanic":
-		return zap.DPanicLevel
-	case "panic":
-		return zap.PanicLevel
-	case "fatal":
-		return zap.FatalLevel
-	default:
-		return zap.InfoLevel
-	}
+	return config.level
 }
 
 // cEncodeLevel 自定义日志级别显示
@@ -208,6 +856,80 @@ func cEncodeLevel(level zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
 	enc.AppendString(level.CapitalString())
 }
 
+// shortEncodeLevel 使用单个大写字母显示日志级别，例如I、W、E，dpanic和panic均为P
+func shortEncodeLevel(level zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	if level == zapcore.DPanicLevel {
+		level = zapcore.PanicLevel
+	}
+	enc.AppendString(level.CapitalString()[:1])
+}
+
+// ConsoleLevelShort console格式使用单个字母的日志级别
+const ConsoleLevelShort = "short"
+
+// consoleLevelEncoder 根据配置返回console格式的日志级别编码函数
+func consoleLevelEncoder(config *PzlogConfig) zapcore.LevelEncoder {
+	if config.ConsoleLevelFormat == ConsoleLevelShort {
+		return shortEncodeLevel
+	}
+	return cEncodeLevel
+}
+
+// loadLocation 加载时区，为空时返回本地时区
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// timeFormatter 按配置的时间格式和时区格式化时间
+func timeFormatter(config *PzlogConfig) func(time.Time) string {
+	format := config.TimeFormat
+	if format == "" {
+		format = logTmFmt
+	}
+	location := config.location
+	if location == nil {
+		location = time.Local
+	}
+	return func(t time.Time) string {
+		return t.In(location).Format(format)
+	}
+}
+
+// 时长字段的编码方式
+const (
+	// DurationSeconds 浮点秒数
+	DurationSeconds = "seconds"
+	// DurationMillis 整数毫秒数
+	DurationMillis = "ms"
+	// DurationNanos 整数纳秒数
+	DurationNanos = "nanos"
+	// DurationString Go格式的字符串，例如"12.5ms"
+	DurationString = "string"
+)
+
+// durationEncoder 根据配置返回时长字段的编码函数，无法识别时使用秒数
+func durationEncoder(config *PzlogConfig) zapcore.DurationEncoder {
+	switch config.DurationEncoding {
+	case DurationMillis:
+		return zapcore.MillisDurationEncoder
+	case DurationNanos:
+		return zapcore.NanosDurationEncoder
+	case DurationString:
+		return zapcore.StringDurationEncoder
+	}
+	return zapcore.SecondsDurationEncoder
+}
+
+// timeEncoder 使用formatTime显示时间
+func timeEncoder(formatTime func(time.Time) string) zapcore.TimeEncoder {
+	return func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+		enc.AppendString(formatTime(t))
+	}
+}
+
 // cEncodeTime 自定义时间格式显示
 func cEncodeTime(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
 	enc.AppendString(t.Format(logTmFmt))
@@ -0,0 +1,327 @@
+package pzlog
+
+import (
+	"encoding/json"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// captured 收集Output为callback时写入的日志
+type captured struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (c *captured) write(line []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lines = append(c.lines, string(line))
+}
+
+// Lines 返回已写入的日志行(不含换行)
+func (c *captured) Lines() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	lines := make([]string, len(c.lines))
+	for i, l := range c.lines {
+		lines[i] = strings.TrimRight(l, "\n")
+	}
+	return lines
+}
+
+// Entries 将已写入的json日志解析为map
+func (c *captured) Entries(t *testing.T) []map[string]interface{} {
+	t.Helper()
+	var entries []map[string]interface{}
+	for _, line := range c.Lines() {
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("invalid json line %q: %v", line, err)
+		}
+		entries = append(entries, m)
+	}
+	return entries
+}
+
+// newCapturedConfig 返回写入内存的配置
+func newCapturedConfig() (*PzlogConfig, *captured) {
+	c := &captured{}
+	config := NewDefaultConfig()
+	config.Output = "callback"
+	config.Callback = c.write
+	return config, c
+}
+
+func TestGetLoggerEInvalidCombinations(t *testing.T) {
+	tests := []struct {
+		name   string
+		config func(*PzlogConfig)
+		want   string
+	}{
+		{"none with printconsole", func(c *PzlogConfig) { c.Output = "none"; c.PrintConsole = true }, "conflicts with printconsole"},
+		{"stdout with printconsole", func(c *PzlogConfig) { c.Output = "stdout"; c.PrintConsole = true }, "twice"},
+		{"unknown encoder", func(c *PzlogConfig) { c.Encoder = "xml" }, "unknown encoder"},
+		{"unknown output", func(c *PzlogConfig) { c.Output = "kafka" }, "unknown output"},
+		{"callback without func", func(c *PzlogConfig) { c.Output = "callback" }, "requires a callback"},
+		{"invalid timezone", func(c *PzlogConfig) { c.Output = "none"; c.TimeZone = "Mars/Olympus" }, "invalid timezone"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := NewDefaultConfig()
+			tt.config(config)
+			_, err := GetLoggerE(config)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.HasPrefix(err.Error(), "pzlog: ") || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q should contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetLoggerEValid(t *testing.T) {
+	config, out := newCapturedConfig()
+	logger, err := GetLoggerE(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("hello")
+	if lines := out.Lines(); len(lines) != 1 || !strings.Contains(lines[0], `"msg":"hello"`) {
+		t.Errorf("unexpected output %q", lines)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		text  string
+		level zapcore.Level
+		ok    bool
+	}{
+		{"debug", zapcore.DebugLevel, true},
+		{"info", zapcore.InfoLevel, true},
+		{"warn", zapcore.WarnLevel, true},
+		{"error", zapcore.ErrorLevel, true},
+		{"dpanic", zapcore.DPanicLevel, true},
+		{"panic", zapcore.PanicLevel, true},
+		{"fatal", zapcore.FatalLevel, true},
+		{"DEBUG", zapcore.DebugLevel, true},
+		{"Warn", zapcore.WarnLevel, true},
+		{"", zapcore.InfoLevel, false},
+		{"verbose", zapcore.InfoLevel, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			level, ok := parseLevel(tt.text)
+			if level != tt.level || ok != tt.ok {
+				t.Errorf("parseLevel(%q) = %v, %v, want %v, %v", tt.text, level, ok, tt.level, tt.ok)
+			}
+			config := NewDefaultConfig()
+			config.LogLevel = tt.text
+			setDefaultValue(config)
+			if config.level != tt.level {
+				t.Errorf("config level = %v, want %v", config.level, tt.level)
+			}
+			if getLevelEnabler(config) != tt.level {
+				t.Errorf("getLevelEnabler = %v, want %v", getLevelEnabler(config), tt.level)
+			}
+		})
+	}
+}
+
+func TestStrictLevels(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		config, out := newCapturedConfig()
+		config.LogLevel = "warning"
+		config.StrictLevels = strict
+		logger, err := GetLoggerE(config)
+		if strict {
+			if err == nil || !strings.Contains(err.Error(), `unknown log level "warning"`) {
+				t.Errorf("strict: err = %v, want unknown log level", err)
+			}
+		} else {
+			if err != nil {
+				t.Fatalf("lenient: err = %v", err)
+			}
+			logger.Debug("debug")
+			logger.Info("info")
+			if lines := out.Lines(); len(lines) != 1 || !strings.Contains(lines[0], `"info"`) {
+				t.Errorf("lenient: lines = %q, want the info level", lines)
+			}
+		}
+
+		// GetLogger总是宽松处理
+		config, out = newCapturedConfig()
+		config.LogLevel = "warning"
+		config.StrictLevels = strict
+		GetLogger(config).Info("info")
+		if len(out.Lines()) != 1 {
+			t.Errorf("strict %v: GetLogger did not fall back to info", strict)
+		}
+	}
+	config, _ := newCapturedConfig()
+	config.StrictLevels = true
+	if _, err := GetLoggerE(config); err != nil {
+		t.Errorf("strict with a valid level: err = %v", err)
+	}
+}
+
+func BenchmarkParseLevel(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		parseLevel("warn")
+	}
+}
+
+func BenchmarkSetDefaultValue(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		config := &PzlogConfig{LogLevel: "warn", TimeZone: "UTC"}
+		setDefaultValue(config)
+		_ = getLevelEnabler(config)
+	}
+}
+
+func BenchmarkDisabledLevelCheck(b *testing.B) {
+	config, _ := newCapturedConfig()
+	config.LogLevel = "warn"
+	logger := GetLogger(config)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			logger.Debug("disabled")
+		}
+	})
+}
+
+func TestReplaceGlobals(t *testing.T) {
+	prev := zap.L()
+	defer zap.ReplaceGlobals(prev)
+
+	config, _ := newCapturedConfig()
+	logger := GetLogger(config)
+	if zap.L() != prev || zap.L() == logger {
+		t.Error("zap.L() was replaced with ReplaceGlobals false")
+	}
+
+	config, _ = newCapturedConfig()
+	config.ReplaceGlobals = true
+	logger = GetLogger(config)
+	if zap.L() != logger {
+		t.Error("zap.L() was not replaced with ReplaceGlobals true")
+	}
+}
+
+func TestBuildCore(t *testing.T) {
+	config := NewDefaultConfig()
+	config.Filename = filepath.Join(t.TempDir(), "core.log")
+	config.LogLevel = "warn"
+	core, level, err := BuildCore(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := zap.New(core, zap.Fields(zap.String("custom", "yes")))
+	logger.Info("filtered")
+	logger.Warn("composed")
+	level.SetLevel(zapcore.InfoLevel)
+	logger.Info("after level change")
+	_ = logger.Sync()
+
+	data := readFile(t, config.Filename)
+	if strings.Contains(data, "filtered") {
+		t.Error("entry below the configured level written")
+	}
+	if !strings.Contains(data, `"msg":"composed"`) || !strings.Contains(data, `"custom":"yes"`) {
+		t.Errorf("composed logger output = %q", data)
+	}
+	if !strings.Contains(data, "after level change") {
+		t.Error("atomic level does not control the returned core")
+	}
+
+	config = NewDefaultConfig()
+	config.Encoder = "xml"
+	if _, _, err := BuildCore(config); err == nil {
+		t.Error("expected error for invalid config")
+	}
+}
+
+func TestWarnDebugInProduction(t *testing.T) {
+	tests := []struct {
+		level string
+		env   string
+		warn  bool
+	}{
+		{"debug", "production", true},
+		{"debug", "Prod", true},
+		{"info", "production", false},
+		{"debug", "development", false},
+	}
+	for _, tt := range tests {
+		config, out := newCapturedConfig()
+		config.WarnDebugInProduction = true
+		config.LogLevel = tt.level
+		config.Env = tt.env
+		GetLogger(config)
+		warned := false
+		for _, line := range out.Lines() {
+			if strings.Contains(line, "debug level is enabled in production") {
+				warned = true
+			}
+		}
+		if warned != tt.warn {
+			t.Errorf("level %s, env %s: warned = %v, want %v", tt.level, tt.env, warned, tt.warn)
+		}
+	}
+}
+
+func TestMustGetLogger(t *testing.T) {
+	config, out := newCapturedConfig()
+	MustGetLogger(config).Info("ok")
+	if len(out.Lines()) != 1 {
+		t.Errorf("got %d lines, want 1", len(out.Lines()))
+	}
+
+	defer func() {
+		r := recover()
+		err, ok := r.(error)
+		if !ok || !strings.Contains(err.Error(), "unknown encoder") {
+			t.Errorf("recovered %v, want config error", r)
+		}
+	}()
+	config = NewDefaultConfig()
+	config.Encoder = "xml"
+	MustGetLogger(config)
+	t.Error("MustGetLogger did not panic")
+}
+
+func TestTimeZone(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.TimeZone = "Asia/Shanghai"
+	config.TimeFormat = "2006-01-02T15:04:05-07:00"
+	logger := GetLogger(config)
+	if ce := logger.Check(zapcore.InfoLevel, "zoned"); ce != nil {
+		ce.Time = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		ce.Write()
+	}
+	entries := out.Entries(t)
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	if ts := entries[0]["ts"]; ts != "2024-01-01T08:00:00+08:00" {
+		t.Errorf("ts = %v, want 2024-01-01T08:00:00+08:00", ts)
+	}
+
+	// 无效的时区回退到本地时区
+	config = NewDefaultConfig()
+	config.TimeZone = "Mars/Olympus"
+	setDefaultValue(config)
+	if config.location != time.Local {
+		t.Errorf("location = %v, want Local", config.location)
+	}
+}
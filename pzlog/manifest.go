@@ -0,0 +1,95 @@
+package pzlog
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// manifest 描述当前日志输出的清单，供运维工具读取
+type manifest struct {
+	PID           int              `json:"pid"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+	Files         []string         `json:"files"`
+	Encoder       string           `json:"encoder"`
+	Level         string           `json:"level"`
+	FormatVersion int              `json:"format_version"`
+	Rotation      manifestRotation `json:"rotation"`
+}
+
+// manifestRotation 日志切割策略
+type manifestRotation struct {
+	MaxSizeMB      int `json:"max_size_mb"`
+	MaxBackups     int `json:"max_backups"`
+	MaxAgeDays     int `json:"max_age_days"`
+	RotateEvery    int `json:"rotate_every"`
+	MaxTotalSizeMB int `json:"max_total_size_mb"`
+}
+
+// manifestPath 返回清单文件的路径，例如./logs/pzlog.log的清单为./logs/pzlog.manifest.json
+func manifestPath(filename string) string {
+	return strings.TrimSuffix(filename, filepath.Ext(filename)) + ".manifest.json"
+}
+
+// manifestFiles 返回配置写入的日志文件
+func manifestFiles(config *PzlogConfig) []string {
+	files := []string{}
+	if len(config.Sinks) > 0 {
+		for i := range config.Sinks {
+			sink := &config.Sinks[i]
+			if sink.output() != "file" {
+				continue
+			}
+			if sink.Filename != "" {
+				files = append(files, sink.Filename)
+			} else {
+				files = append(files, config.Filename)
+			}
+		}
+		return files
+	}
+	if config.Output != "file" {
+		return files
+	}
+	if config.Shards > 1 {
+		for i := 0; i < config.Shards; i++ {
+			files = append(files, shardFilename(config.Filename, i))
+		}
+		return files
+	}
+	return append(files, config.Filename)
+}
+
+// writeManifest 将当前配置的日志文件、切割策略和格式版本写入清单文件，先写临时文件再重命名，读取方不会读到不完整的内容
+func writeManifest(config *PzlogConfig) error {
+	m := manifest{
+		PID:           os.Getpid(),
+		UpdatedAt:     time.Now(),
+		Files:         manifestFiles(config),
+		Encoder:       config.Encoder,
+		Level:         config.level.String(),
+		FormatVersion: config.FormatVersion,
+		Rotation: manifestRotation{
+			MaxSizeMB:      config.MaxSize,
+			MaxBackups:     config.MaxBackups,
+			MaxAgeDays:     config.MaxAge,
+			RotateEvery:    config.RotateEvery,
+			MaxTotalSizeMB: config.MaxTotalSize,
+		},
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := manifestPath(config.Filename)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
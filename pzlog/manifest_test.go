@@ -0,0 +1,60 @@
+package pzlog
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// readManifest 读取并解析清单文件
+func readManifest(t *testing.T, path string) manifest {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("invalid manifest %q: %v", data, err)
+	}
+	return m
+}
+
+func TestWriteManifest(t *testing.T) {
+	dir := t.TempDir()
+	config := NewDefaultConfig()
+	config.Filename = filepath.Join(dir, "app.log")
+	config.WriteManifest = true
+	config.FormatVersion = 2
+	config.RotateEvery = 100
+	GetLogger(config)
+	defer func() { _ = Close() }()
+
+	path := filepath.Join(dir, "app.manifest.json")
+	m := readManifest(t, path)
+	if m.PID != os.Getpid() || m.Encoder != "json" || m.Level != "info" || m.FormatVersion != 2 {
+		t.Errorf("manifest = %+v", m)
+	}
+	if !reflect.DeepEqual(m.Files, []string{config.Filename}) {
+		t.Errorf("files = %q, want %s", m.Files, config.Filename)
+	}
+	if m.Rotation.MaxSizeMB != 100 || m.Rotation.MaxBackups != 10 || m.Rotation.RotateEvery != 100 {
+		t.Errorf("rotation = %+v", m.Rotation)
+	}
+
+	next := NewDefaultConfig()
+	next.Filename = config.Filename
+	next.WriteManifest = true
+	next.LogLevel = "warn"
+	next.Shards = 2
+	if err := Reconfigure(next); err != nil {
+		t.Fatal(err)
+	}
+	m2 := readManifest(t, path)
+	want := []string{filepath.Join(dir, "app.0.log"), filepath.Join(dir, "app.1.log")}
+	if m2.Level != "warn" || !reflect.DeepEqual(m2.Files, want) || m2.UpdatedAt.Before(m.UpdatedAt) {
+		t.Errorf("manifest after Reconfigure = %+v, want warn level and files %q", m2, want)
+	}
+}
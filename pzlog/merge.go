@@ -0,0 +1,44 @@
+package pzlog
+
+import "reflect"
+
+// MergeConfig 合并两个配置，返回新的配置，base和override不会被修改。
+// override中非零值的字段覆盖base中的对应字段(包括内嵌的lumberjack.Logger的字段)，零值字段保留base的值。
+// 由于无法区分bool字段未设置和设置为false，bool字段只能被override覆盖为true，不能覆盖为false；
+// 指针、切片、函数等字段为浅拷贝。
+func MergeConfig(base, override *PzlogConfig) *PzlogConfig {
+	merged := &PzlogConfig{}
+	if base != nil {
+		mergeStruct(reflect.ValueOf(merged).Elem(), reflect.ValueOf(base).Elem())
+	}
+	if override != nil {
+		mergeStruct(reflect.ValueOf(merged).Elem(), reflect.ValueOf(override).Elem())
+	}
+	return merged
+}
+
+// mergeStruct 将src中非零值的导出字段复制到dst，内嵌结构体逐字段合并
+func mergeStruct(dst, src reflect.Value) {
+	t := src.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			mergeStruct(dst.Field(i), src.Field(i))
+			continue
+		}
+		if !f.IsExported() || src.Field(i).IsZero() {
+			continue
+		}
+		dst.Field(i).Set(src.Field(i))
+	}
+}
+
+// EffectiveConfig 返回填充默认值之后实际生效的配置副本，不修改传入的配置
+func EffectiveConfig(config *PzlogConfig) (effective PzlogConfig) {
+	if config == nil {
+		config = NewDefaultConfig()
+	}
+	mergeStruct(reflect.ValueOf(&effective).Elem(), reflect.ValueOf(config).Elem())
+	setDefaultValue(&effective)
+	return
+}
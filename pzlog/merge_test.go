@@ -0,0 +1,67 @@
+package pzlog
+
+import (
+	"gopkg.in/natefinch/lumberjack.v2"
+	"testing"
+)
+
+func TestMergeConfig(t *testing.T) {
+	base := &PzlogConfig{
+		Logger:       lumberjack.Logger{Filename: "base.log", MaxSize: 100, MaxBackups: 3},
+		Encoder:      "json",
+		LogLevel:     "info",
+		PrintConsole: true,
+	}
+	override := &PzlogConfig{
+		Logger:   lumberjack.Logger{MaxSize: 10},
+		LogLevel: "debug",
+	}
+	override.Logger.Compress = true
+
+	merged := MergeConfig(base, override)
+	if merged == base || merged == override {
+		t.Fatal("MergeConfig returned one of its arguments")
+	}
+	if merged.Filename != "base.log" || merged.MaxBackups != 3 {
+		t.Errorf("base lumberjack fields lost: %q, %d", merged.Filename, merged.MaxBackups)
+	}
+	if merged.MaxSize != 10 || !merged.Logger.Compress {
+		t.Errorf("override lumberjack fields not applied: %d, %v", merged.MaxSize, merged.Logger.Compress)
+	}
+	if merged.LogLevel != "debug" || merged.Encoder != "json" {
+		t.Errorf("LogLevel = %q, Encoder = %q", merged.LogLevel, merged.Encoder)
+	}
+	// bool字段不能被覆盖为false
+	if !merged.PrintConsole {
+		t.Error("PrintConsole was reset by a zero override")
+	}
+	if base.LogLevel != "info" || base.MaxSize != 100 {
+		t.Error("base was modified")
+	}
+
+	if got := MergeConfig(nil, override); got.LogLevel != "debug" {
+		t.Errorf("MergeConfig(nil, override).LogLevel = %q", got.LogLevel)
+	}
+	if got := MergeConfig(base, nil); got.Filename != "base.log" {
+		t.Errorf("MergeConfig(base, nil).Filename = %q", got.Filename)
+	}
+}
+
+func TestEffectiveConfig(t *testing.T) {
+	in := &PzlogConfig{}
+	effective := EffectiveConfig(in)
+	if effective.Filename != "./logs/pzlog.log" || effective.TimeFormat != logTmFmt {
+		t.Errorf("Filename = %q, TimeFormat = %q", effective.Filename, effective.TimeFormat)
+	}
+	if effective.Encoder != "json" || effective.Output != "file" || effective.LogLevel != "info" {
+		t.Errorf("Encoder = %q, Output = %q, LogLevel = %q", effective.Encoder, effective.Output, effective.LogLevel)
+	}
+	if in.Encoder != "" || in.Filename != "" {
+		t.Error("input config was modified")
+	}
+
+	effective = EffectiveConfig(nil)
+	if effective.MaxSize != 100 || effective.MaxBackups != 10 || effective.MaxAge != 30 {
+		t.Errorf("nil config: MaxSize = %d, MaxBackups = %d, MaxAge = %d", effective.MaxSize, effective.MaxBackups, effective.MaxAge)
+	}
+}
@@ -0,0 +1,130 @@
+//go:build pzlog_mmap && (linux || darwin)
+
+package pzlog
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// defaultMmapChunkSize 映射文件每次增长的大小
+const defaultMmapChunkSize = 4 << 20
+
+// MmapWriteSyncer 通过内存映射写入日志文件的WriteSyncer，需要使用pzlog_mmap构建标签。
+// 写入只是内存拷贝，没有每次写入的系统调用，进程崩溃后已写入的内容仍由操作系统写回文件；
+// Sync调用msync，用于在机器掉电时也不丢失日志。文件按chunkSize预先扩展，未写入的部分为0，
+// 重新打开时从最后一个非0字节之后继续写入，Close时截断多余的部分。
+// 可以通过zapcore.NewCore与Encoder组合，或者作为Output为callback时的写入目标
+type MmapWriteSyncer struct {
+	mu    sync.Mutex
+	file  *os.File
+	data  []byte
+	off   int
+	chunk int
+}
+
+// NewMmapWriteSyncer 打开或创建filename并映射到内存，chunkSize为文件每次增长的字节数，小于等于0时为4MB
+func NewMmapWriteSyncer(filename string, chunkSize int) (*MmapWriteSyncer, error) {
+	if chunkSize <= 0 {
+		chunkSize = defaultMmapChunkSize
+	}
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	w := &MmapWriteSyncer{file: f, chunk: chunkSize}
+	size := int(info.Size())
+	if size == 0 {
+		size = chunkSize
+	}
+	if err := w.remap(size); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	// 上次未正常关闭时文件末尾是预先扩展的0，从最后一个非0字节之后继续写入
+	w.off = len(bytes.TrimRight(w.data, "\x00"))
+	return w, nil
+}
+
+// remap 将文件扩展到size字节并重新映射
+func (w *MmapWriteSyncer) remap(size int) error {
+	if w.data != nil {
+		if err := syscall.Munmap(w.data); err != nil {
+			return err
+		}
+		w.data = nil
+	}
+	if err := w.file.Truncate(int64(size)); err != nil {
+		return err
+	}
+	data, err := syscall.Mmap(int(w.file.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return err
+	}
+	w.data = data
+	return nil
+}
+
+func (w *MmapWriteSyncer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.data == nil {
+		return 0, errors.New("pzlog: mmap write syncer is closed")
+	}
+	if need := w.off + len(p); need > len(w.data) {
+		size := len(w.data) + w.chunk
+		for size < need {
+			size += w.chunk
+		}
+		if err := w.remap(size); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(w.data[w.off:], p)
+	w.off += n
+	return n, nil
+}
+
+// Sync 将映射的内容同步写入磁盘
+func (w *MmapWriteSyncer) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.data == nil {
+		return nil
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_MSYNC, uintptr(unsafe.Pointer(&w.data[0])), uintptr(len(w.data)), syscall.MS_SYNC)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// Close 同步并解除映射，将文件截断到实际写入的长度
+func (w *MmapWriteSyncer) Close() error {
+	if err := w.Sync(); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.data == nil {
+		return nil
+	}
+	if err := syscall.Munmap(w.data); err != nil {
+		return err
+	}
+	w.data = nil
+	if err := w.file.Truncate(int64(w.off)); err != nil {
+		_ = w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
@@ -0,0 +1,67 @@
+//go:build pzlog_mmap && (linux || darwin)
+
+package pzlog
+
+import (
+	"bytes"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// mmapCrashEnv 设置时测试进程作为子进程写入该文件后直接退出，模拟崩溃
+const mmapCrashEnv = "PZLOG_MMAP_CRASH_FILE"
+
+func TestMmapWriteSyncerCrash(t *testing.T) {
+	if filename := os.Getenv(mmapCrashEnv); filename != "" {
+		ws, err := NewMmapWriteSyncer(filename, 256)
+		if err != nil {
+			t.Fatal(err)
+		}
+		logger := zap.New(zapcore.NewCore(testJSONEncoder(), ws, zapcore.InfoLevel))
+		for i := 0; i < 20; i++ {
+			logger.Info("before crash", zap.Int("n", i))
+		}
+		// 不调用Sync和Close，直接退出
+		os.Exit(3)
+	}
+
+	filename := filepath.Join(t.TempDir(), "app.log")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestMmapWriteSyncerCrash$")
+	cmd.Env = append(os.Environ(), mmapCrashEnv+"="+filename)
+	if err := cmd.Run(); err == nil {
+		t.Fatal("child process exited normally, want a crash")
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(bytes.TrimRight(data, "\x00\n")), "\n")
+	if len(lines) != 20 || !strings.Contains(lines[19], `"n":19`) {
+		t.Fatalf("recovered %d lines, want all 20 entries written before the crash", len(lines))
+	}
+
+	// 重新打开时从已写入的内容之后继续写入，Close后截断多余的部分
+	ws, err := NewMmapWriteSyncer(filename, 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ws.Write([]byte("after restart\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got := readFile(t, filename)
+	if !strings.HasPrefix(got, string(bytes.TrimRight(data, "\x00"))) || !strings.HasSuffix(got, "}\nafter restart\n") {
+		t.Errorf("file after restart = %q, want the old entries followed by the new one and no padding", got)
+	}
+	if _, err := ws.Write([]byte("closed")); err == nil {
+		t.Error("write after Close succeeded")
+	}
+}
@@ -0,0 +1,20 @@
+package pzlog
+
+import (
+	"go.uber.org/zap"
+	"sync"
+)
+
+// warnedKeys WarnOnce已记录过的key
+var warnedKeys sync.Map
+
+// WarnOnce 以warn级别记录日志，相同的key在进程生命周期内只记录一次，适用于弃用警告等可能大量重复的日志
+func WarnOnce(key, msg string, fields ...zap.Field) {
+	if _, loaded := warnedKeys.LoadOrStore(key, struct{}{}); loaded {
+		return
+	}
+	fs := make([]zap.Field, 0, len(fields)+1)
+	fs = append(fs, zap.String("once_key", key))
+	fs = append(fs, fields...)
+	zap.L().WithOptions(zap.AddCallerSkip(1)).Warn(msg, fs...)
+}
@@ -0,0 +1,31 @@
+package pzlog
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"strings"
+	"testing"
+)
+
+func TestWarnOnce(t *testing.T) {
+	warnedKeys.Delete("TestWarnOnce.old-api")
+	warnedKeys.Delete("TestWarnOnce.old-flag")
+	logs := observeGlobals(t, zapcore.DebugLevel)
+	for i := 0; i < 3; i++ {
+		WarnOnce("TestWarnOnce.old-api", "old api is deprecated", zap.Int("call", i))
+	}
+	WarnOnce("TestWarnOnce.old-flag", "old flag is deprecated")
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want one per key", len(entries))
+	}
+	first := entries[0]
+	fields := first.ContextMap()
+	if first.Level != zapcore.WarnLevel || fields["once_key"] != "TestWarnOnce.old-api" || fields["call"] != int64(0) {
+		t.Errorf("entry = %v %v, want the first warn call", first.Level, fields)
+	}
+	if !strings.HasSuffix(first.Caller.File, "once_test.go") {
+		t.Errorf("caller = %s, want the WarnOnce call site", first.Caller.File)
+	}
+}
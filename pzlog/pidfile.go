@@ -0,0 +1,53 @@
+package pzlog
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+var (
+	// pidFile GetLogger写入的PID文件路径，Close时删除
+	pidFile   string
+	pidFileMu sync.Mutex
+)
+
+// writePIDFile 将当前进程的PID写入文件，并记录路径供Close删除
+func writePIDFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644); err != nil {
+		return err
+	}
+	pidFileMu.Lock()
+	pidFile = path
+	pidFileMu.Unlock()
+	return nil
+}
+
+// Close 刷新最近一次GetLogger创建的Logger的缓冲并关闭其打开的日志文件和日志目录清理器，并删除配置的PID文件，应在程序退出前调用
+func Close() error {
+	var err error
+	currentSwapMu.Lock()
+	state := currentSwap
+	currentSwapMu.Unlock()
+	if state != nil {
+		root := state.root.Load()
+		err = root.core.Sync()
+		if closeErr := root.resources.close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	pidFileMu.Lock()
+	path := pidFile
+	pidFile = ""
+	pidFileMu.Unlock()
+	if path != "" {
+		if rmErr := os.Remove(path); rmErr != nil && !os.IsNotExist(rmErr) && err == nil {
+			err = rmErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,28 @@
+package pzlog
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestPIDFile(t *testing.T) {
+	config, _ := newCapturedConfig()
+	config.PIDFile = filepath.Join(t.TempDir(), "run", "app.pid")
+	GetLogger(config)
+
+	if got := readFile(t, config.PIDFile); got != strconv.Itoa(os.Getpid())+"\n" {
+		t.Errorf("pid file = %q, want %d", got, os.Getpid())
+	}
+	if err := Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(config.PIDFile); !os.IsNotExist(err) {
+		t.Errorf("pid file not removed on Close: %v", err)
+	}
+	// 重复Close不报错
+	if err := Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
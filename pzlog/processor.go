@@ -0,0 +1,45 @@
+package pzlog
+
+import (
+	"go.uber.org/zap/zapcore"
+)
+
+// EntryProcessor 日志处理阶段，可以修改entry(例如消息)和字段，返回处理后的字段。
+// With添加的字段在创建子Logger时已经编码，不会传给Process
+type EntryProcessor interface {
+	Process(entry *zapcore.Entry, fields []zapcore.Field) []zapcore.Field
+}
+
+// EntryProcessorFunc 将函数转换为EntryProcessor
+type EntryProcessorFunc func(entry *zapcore.Entry, fields []zapcore.Field) []zapcore.Field
+
+func (f EntryProcessorFunc) Process(entry *zapcore.Entry, fields []zapcore.Field) []zapcore.Field {
+	return f(entry, fields)
+}
+
+// processorCore 写入前按顺序执行各处理阶段，前一阶段的输出作为后一阶段的输入
+type processorCore struct {
+	zapcore.Core
+	processors []EntryProcessor
+}
+
+func (c *processorCore) With(fields []zapcore.Field) zapcore.Core {
+	return &processorCore{Core: c.Core.With(fields), processors: c.processors}
+}
+
+func (c *processorCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *processorCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	// 复制字段，避免处理阶段修改调用方的切片
+	fs := make([]zapcore.Field, len(fields))
+	copy(fs, fields)
+	for _, p := range c.processors {
+		fs = p.Process(&entry, fs)
+	}
+	return c.Core.Write(entry, fs)
+}
@@ -0,0 +1,43 @@
+package pzlog
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"strings"
+	"testing"
+)
+
+func TestProcessors(t *testing.T) {
+	var order []string
+	redact := EntryProcessorFunc(func(entry *zapcore.Entry, fields []zapcore.Field) []zapcore.Field {
+		order = append(order, "redact")
+		entry.Message = strings.ReplaceAll(entry.Message, "secret", "***")
+		for i, f := range fields {
+			if f.Key == "password" {
+				fields[i] = zap.String("password", "***")
+			}
+		}
+		return fields
+	})
+	// 后一阶段看到前一阶段的结果
+	addFields := EntryProcessorFunc(func(entry *zapcore.Entry, fields []zapcore.Field) []zapcore.Field {
+		order = append(order, "add")
+		return append(fields, zap.Bool("redacted", strings.Contains(entry.Message, "***")))
+	})
+
+	config, out := newCapturedConfig()
+	config.Processors = []EntryProcessor{redact, addFields}
+	fields := []zap.Field{zap.String("password", "hunter2")}
+	GetLogger(config).Info("login secret", fields...)
+
+	if got := strings.Join(order, ","); got != "redact,add" {
+		t.Errorf("processors ran as %s, want redact,add", got)
+	}
+	e := out.Entries(t)[0]
+	if e["msg"] != "login ***" || e["password"] != "***" || e["redacted"] != true {
+		t.Errorf("entry = %v, want both processors applied in order", e)
+	}
+	if fields[0].String != "hunter2" {
+		t.Error("processor modified the caller's fields")
+	}
+}
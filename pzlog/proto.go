@@ -0,0 +1,68 @@
+//go:build pzlog_protobuf
+
+package pzlog
+
+import (
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/protobuf/proto"
+	"sync"
+)
+
+// ProtoBuilder 将日志及其字段转换为protobuf消息，例如自定义gRPC日志服务的LogEntry
+type ProtoBuilder func(entry zapcore.Entry, fields map[string]interface{}) (proto.Message, error)
+
+// ProtoSender 发送protobuf消息，通常包装gRPC客户端流的Send方法
+type ProtoSender func(msg proto.Message) error
+
+// protoCore 将日志转换为protobuf消息并通过流发送，需要使用pzlog_protobuf构建标签
+type protoCore struct {
+	zapcore.LevelEnabler
+	fields *mapEncoder
+	build  ProtoBuilder
+	send   ProtoSender
+	// mu 保证对同一个流的Send调用串行
+	mu *sync.Mutex
+}
+
+// NewProtoCore 创建发送protobuf消息的core，可以通过zapcore.NewTee与BuildCore返回的core组合
+func NewProtoCore(build ProtoBuilder, send ProtoSender, level zapcore.LevelEnabler) zapcore.Core {
+	return &protoCore{
+		LevelEnabler: level,
+		fields:       newMapEncoder(),
+		build:        build,
+		send:         send,
+		mu:           &sync.Mutex{},
+	}
+}
+
+func (c *protoCore) With(fields []zapcore.Field) zapcore.Core {
+	return &protoCore{
+		LevelEnabler: c.LevelEnabler,
+		fields:       c.fields.withFields(fields),
+		build:        c.build,
+		send:         c.send,
+		mu:           c.mu,
+	}
+}
+
+func (c *protoCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *protoCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	enc := c.fields.withFields(fields)
+	msg, err := c.build(entry, enc.Fields)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.send(msg)
+}
+
+func (c *protoCore) Sync() error {
+	return nil
+}
@@ -0,0 +1,142 @@
+//go:build pzlog_protobuf
+
+package pzlog
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"io"
+	"net"
+	"sync"
+	"testing"
+)
+
+// mockLogStream 模拟gRPC客户端流，保存序列化后的消息
+type mockLogStream struct {
+	received [][]byte
+}
+
+func (s *mockLogStream) Send(msg proto.Message) error {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	s.received = append(s.received, data)
+	return nil
+}
+
+func TestProtoCore(t *testing.T) {
+	build := func(entry zapcore.Entry, fields map[string]interface{}) (proto.Message, error) {
+		fields["msg"] = entry.Message
+		fields["level"] = entry.Level.String()
+		return structpb.NewStruct(fields)
+	}
+	stream := &mockLogStream{}
+	logger := zap.New(NewProtoCore(build, stream.Send, zapcore.InfoLevel)).With(zap.String("service", "api"))
+	logger.Debug("dropped")
+	logger.Info("shipped", zap.Int("n", 2))
+
+	if len(stream.received) != 1 {
+		t.Fatalf("received %d messages, want 1", len(stream.received))
+	}
+	var got structpb.Struct
+	if err := proto.Unmarshal(stream.received[0], &got); err != nil {
+		t.Fatalf("malformed protobuf message: %v", err)
+	}
+	m := got.AsMap()
+	if m["msg"] != "shipped" || m["level"] != "info" || m["service"] != "api" || m["n"] != float64(2) {
+		t.Errorf("message = %v", m)
+	}
+}
+
+// mockLogServer 模拟日志服务，Push为客户端流方法，接收的每条消息为structpb.Struct
+type mockLogServer struct {
+	mu       sync.Mutex
+	received []*structpb.Struct
+}
+
+// logServiceDesc 手写的服务描述，避免测试依赖生成的代码
+var logServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pzlog.test.LogService",
+	HandlerType: (*interface{})(nil),
+	Streams: []grpc.StreamDesc{{
+		StreamName:    "Push",
+		ClientStreams: true,
+		Handler: func(srv interface{}, stream grpc.ServerStream) error {
+			s := srv.(*mockLogServer)
+			for {
+				msg := &structpb.Struct{}
+				if err := stream.RecvMsg(msg); err != nil {
+					if errors.Is(err, io.EOF) {
+						return stream.SendMsg(&emptypb.Empty{})
+					}
+					return err
+				}
+				s.mu.Lock()
+				s.received = append(s.received, msg)
+				s.mu.Unlock()
+			}
+		},
+	}},
+}
+
+func TestProtoCoreGRPC(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	mock := &mockLogServer{}
+	server.RegisterService(&logServiceDesc, mock)
+	go func() { _ = server.Serve(lis) }()
+	defer server.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	stream, err := conn.NewStream(ctx, &logServiceDesc.Streams[0], "/pzlog.test.LogService/Push")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	build := func(entry zapcore.Entry, fields map[string]interface{}) (proto.Message, error) {
+		fields["msg"] = entry.Message
+		fields["level"] = entry.Level.String()
+		return structpb.NewStruct(fields)
+	}
+	send := func(msg proto.Message) error { return stream.SendMsg(msg) }
+	logger := zap.New(NewProtoCore(build, send, zapcore.InfoLevel)).With(zap.String("service", "api"))
+	logger.Debug("dropped")
+	logger.Info("first", zap.Int("n", 1))
+	logger.Warn("second", zap.Bool("retry", true))
+
+	if err := stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.RecvMsg(&emptypb.Empty{}); err != nil {
+		t.Fatal(err)
+	}
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	if len(mock.received) != 2 {
+		t.Fatalf("server received %d messages, want 2", len(mock.received))
+	}
+	first, second := mock.received[0].AsMap(), mock.received[1].AsMap()
+	if first["msg"] != "first" || first["level"] != "info" || first["service"] != "api" || first["n"] != float64(1) {
+		t.Errorf("first message = %v", first)
+	}
+	if second["msg"] != "second" || second["level"] != "warn" || second["retry"] != true {
+		t.Errorf("second message = %v", second)
+	}
+}
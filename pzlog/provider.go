@@ -0,0 +1,104 @@
+package pzlog
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrKeyNotFound ConfigProvider中不存在指定的键时返回该错误
+var ErrKeyNotFound = errors.New("pzlog: key not found")
+
+// ConfigProvider 键值配置源，例如Consul、etcd
+type ConfigProvider interface {
+	// Get 返回键对应的值，键不存在时返回ErrKeyNotFound
+	Get(key string) (string, error)
+}
+
+// ConfigFromProvider 从键值配置源读取配置，键为prefix加上配置字段的json标签名，例如 prefix+"loglevel"。
+// 不存在的键使用NewDefaultConfig中的默认值；只支持字符串、布尔、整数和时长类型的字段。
+func ConfigFromProvider(p ConfigProvider, prefix string) (*PzlogConfig, error) {
+	config := NewDefaultConfig()
+	if err := loadFromProvider(reflect.ValueOf(config).Elem(), p, prefix); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func loadFromProvider(v reflect.Value, p ConfigProvider, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if err := loadFromProvider(v.Field(i), p, prefix); err != nil {
+				return err
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		field := v.Field(i)
+		if !providerKind(field) {
+			continue
+		}
+		key := prefix + name
+		value, err := p.Get(key)
+		if errors.Is(err, ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("pzlog: get %q: %w", key, err)
+		}
+		if err := setFieldString(field, value); err != nil {
+			return fmt.Errorf("pzlog: invalid value %q for %q: %w", value, key, err)
+		}
+	}
+	return nil
+}
+
+// providerKind 判断字段是否可以从字符串设置
+func providerKind(field reflect.Value) bool {
+	switch field.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+// setFieldString 将字符串解析后设置到字段
+func setFieldString(field reflect.Value, value string) error {
+	value = strings.TrimSpace(value)
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		if field.Type() == reflect.TypeOf(time.Duration(0)) {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return err
+			}
+			field.SetInt(int64(d))
+			return nil
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	}
+	return nil
+}
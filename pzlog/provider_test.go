@@ -0,0 +1,62 @@
+package pzlog
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mapProvider 内存中的ConfigProvider
+type mapProvider map[string]string
+
+func (p mapProvider) Get(key string) (string, error) {
+	if v, ok := p[key]; ok {
+		return v, nil
+	}
+	return "", ErrKeyNotFound
+}
+
+type failingProvider struct{}
+
+func (failingProvider) Get(string) (string, error) {
+	return "", errors.New("connection refused")
+}
+
+func TestConfigFromProvider(t *testing.T) {
+	p := mapProvider{
+		"pzlog/loglevel":                "debug",
+		"pzlog/encoder":                 "console",
+		"pzlog/printconsole":            "true",
+		"pzlog/maxsize":                 " 20 ",
+		"pzlog/filename":                "/var/log/app.log",
+		"pzlog/asyncdropreportinterval": "30s",
+		"other/loglevel":                "error",
+	}
+	config, err := ConfigFromProvider(p, "pzlog/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.LogLevel != "debug" || config.Encoder != "console" || !config.PrintConsole {
+		t.Errorf("LogLevel = %q, Encoder = %q, PrintConsole = %v", config.LogLevel, config.Encoder, config.PrintConsole)
+	}
+	if config.MaxSize != 20 || config.Filename != "/var/log/app.log" {
+		t.Errorf("MaxSize = %d, Filename = %q", config.MaxSize, config.Filename)
+	}
+	if config.AsyncDropReportInterval != 30*time.Second {
+		t.Errorf("AsyncDropReportInterval = %v", config.AsyncDropReportInterval)
+	}
+	// 不存在的键使用默认值
+	if config.MaxBackups != 10 || config.MaxAge != 30 {
+		t.Errorf("MaxBackups = %d, MaxAge = %d, want defaults", config.MaxBackups, config.MaxAge)
+	}
+}
+
+func TestConfigFromProviderErrors(t *testing.T) {
+	if _, err := ConfigFromProvider(mapProvider{"maxsize": "big"}, ""); err == nil || !strings.Contains(err.Error(), `"maxsize"`) {
+		t.Errorf("invalid value error = %v", err)
+	}
+	if _, err := ConfigFromProvider(failingProvider{}, ""); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("provider error = %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package pzlog
+
+import (
+	"fmt"
+	"go.uber.org/zap/zapcore"
+	"time"
+)
+
+// QuietHoursConfig 静默时段配置，时段内只记录不低于Level的日志，例如夜间批处理任务期间只记录warn及以上的日志
+type QuietHoursConfig struct {
+	// 开始时间，格式为HH:MM，例如22:00
+	Start string `json:"start" yaml:"start"`
+
+	// 结束时间，格式为HH:MM，例如06:00，早于Start时表示跨越午夜
+	End string `json:"end" yaml:"end"`
+
+	// 时段内的最低日志级别，默认warn
+	Level string `json:"level" yaml:"level"`
+}
+
+// window 解析开始和结束时间，返回当天零点起的分钟数
+func (q *QuietHoursConfig) window() (start, end int, err error) {
+	if start, err = parseClockMinutes(q.Start); err != nil {
+		return 0, 0, fmt.Errorf("start: %w", err)
+	}
+	if end, err = parseClockMinutes(q.End); err != nil {
+		return 0, 0, fmt.Errorf("end: %w", err)
+	}
+	return start, end, nil
+}
+
+// level 返回时段内的最低日志级别
+func (q *QuietHoursConfig) level() (zapcore.Level, error) {
+	if q.Level == "" {
+		return zapcore.WarnLevel, nil
+	}
+	l, ok := parseLevel(q.Level)
+	if !ok {
+		return 0, fmt.Errorf("unknown level %q", q.Level)
+	}
+	return l, nil
+}
+
+// parseClockMinutes 将HH:MM转换为零点起的分钟数
+func parseClockMinutes(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, must be HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// quietHoursCore 在静默时段内提高最低日志级别，时段结束后恢复，判断时使用注入的时钟
+type quietHoursCore struct {
+	zapcore.Core
+	start, end int
+	level      zapcore.Level
+	now        func() time.Time
+	location   *time.Location
+}
+
+func newQuietHoursCore(core zapcore.Core, config *QuietHoursConfig, now func() time.Time, location *time.Location) zapcore.Core {
+	start, end, err := config.window()
+	if err != nil {
+		return core
+	}
+	level, err := config.level()
+	if err != nil {
+		return core
+	}
+	if location == nil {
+		location = time.Local
+	}
+	return &quietHoursCore{Core: core, start: start, end: end, level: level, now: now, location: location}
+}
+
+func (c *quietHoursCore) Enabled(level zapcore.Level) bool {
+	if c.quiet() && level < c.level {
+		return false
+	}
+	return c.Core.Enabled(level)
+}
+
+func (c *quietHoursCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.Core = c.Core.With(fields)
+	return &clone
+}
+
+func (c *quietHoursCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.quiet() && entry.Level < c.level {
+		return ce
+	}
+	return c.Core.Check(entry, ce)
+}
+
+// quiet 判断当前是否处于静默时段，开始时间等于结束时间时视为全天
+func (c *quietHoursCore) quiet() bool {
+	t := c.now().In(c.location)
+	m := t.Hour()*60 + t.Minute()
+	if c.start < c.end {
+		return m >= c.start && m < c.end
+	}
+	return m >= c.start || m < c.end
+}
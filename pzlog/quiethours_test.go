@@ -0,0 +1,64 @@
+package pzlog
+
+import (
+	"go.uber.org/zap/zapcore"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuietHours(t *testing.T) {
+	clock := &fakeClock{}
+	config, out := newCapturedConfig()
+	config.Clock = clock
+	config.TimeZone = "UTC"
+	config.QuietHours = &QuietHoursConfig{Start: "22:00", End: "06:00"}
+	logger := GetLogger(config)
+
+	day := func(d, h, m int) time.Time { return time.Date(2024, 1, d, h, m, 0, 0, time.UTC) }
+	steps := []struct {
+		at    time.Time
+		quiet bool
+	}{
+		{day(1, 21, 59), false},
+		{day(1, 22, 0), true},
+		{day(2, 1, 0), true},  // 跨越午夜
+		{day(2, 6, 0), false}, // 时段结束后恢复
+		{day(2, 16, 0), false},
+	}
+	for _, step := range steps {
+		clock.t = step.at
+		if enabled := logger.Core().Enabled(zapcore.InfoLevel); enabled == step.quiet {
+			t.Errorf("%s: info enabled = %v, want %v", clock.t.Format("15:04"), enabled, !step.quiet)
+		}
+		if !logger.Core().Enabled(zapcore.WarnLevel) {
+			t.Errorf("%s: warn disabled", clock.t.Format("15:04"))
+		}
+		logger.Info("info " + clock.t.Format("15:04"))
+		logger.Warn("warn " + clock.t.Format("15:04"))
+	}
+
+	var msgs []string
+	for _, e := range out.Entries(t) {
+		msgs = append(msgs, e["msg"].(string))
+	}
+	want := "info 21:59,warn 21:59,warn 22:00,warn 01:00,info 06:00,warn 06:00,info 16:00,warn 16:00"
+	if got := strings.Join(msgs, ","); got != want {
+		t.Errorf("logged %s, want %s", got, want)
+	}
+}
+
+func TestQuietHoursConfig(t *testing.T) {
+	for _, q := range []*QuietHoursConfig{
+		{Start: "25:00", End: "06:00"},
+		{Start: "22:00", End: "6am"},
+		{Start: "22:00", End: "06:00", Level: "loud"},
+	} {
+		config := NewDefaultConfig()
+		config.Output = "none"
+		config.QuietHours = q
+		if _, err := GetLoggerE(config); err == nil || !strings.Contains(err.Error(), "quiethours") {
+			t.Errorf("%+v: err = %v, want a quiethours error", *q, err)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package pzlog
+
+import (
+	"go.uber.org/zap/zapcore"
+	"sync"
+	"time"
+)
+
+// RateLimitConfig 按级别限制每秒输出日志条数的配置，使用漏桶算法，每个级别独立计算
+type RateLimitConfig struct {
+	// 每个级别每秒最多输出的日志条数
+	PerSecond int `json:"persecond" yaml:"persecond"`
+
+	// 允许的突发条数，默认等于PerSecond
+	Burst int `json:"burst" yaml:"burst"`
+}
+
+// leakyBucket 漏桶，level为Check时的日志级别
+type leakyBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	level  float64
+	last   time.Time
+	inited bool
+}
+
+// allow 判断是否可以再放入一条日志
+func (b *leakyBucket) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.inited {
+		b.level -= now.Sub(b.last).Seconds() * b.rate
+		if b.level < 0 {
+			b.level = 0
+		}
+	}
+	b.inited = true
+	b.last = now
+	if b.level+1 > b.burst {
+		return false
+	}
+	b.level++
+	return true
+}
+
+// rateLimitCore 按级别限速的core，超过速率的日志被丢弃。
+// 作为最外层时在Check中限速并交给内层的Check；作为内层(例如控制台core)时外层的core(例如levelOutputCore)直接调用Write，
+// 不会调用Check，此时在Write中限速。两条路径不会同时经过，每条日志只计数一次
+type rateLimitCore struct {
+	zapcore.Core
+	buckets *[zapcore.FatalLevel - zapcore.DebugLevel + 1]leakyBucket
+	now     func() time.Time
+}
+
+func newRateLimitCore(core zapcore.Core, config *RateLimitConfig, now func() time.Time) zapcore.Core {
+	burst := config.Burst
+	if burst <= 0 {
+		burst = config.PerSecond
+	}
+	buckets := &[zapcore.FatalLevel - zapcore.DebugLevel + 1]leakyBucket{}
+	for i := range buckets {
+		buckets[i].rate = float64(config.PerSecond)
+		buckets[i].burst = float64(burst)
+	}
+	return &rateLimitCore{Core: core, buckets: buckets, now: now}
+}
+
+func (c *rateLimitCore) With(fields []zapcore.Field) zapcore.Core {
+	return &rateLimitCore{Core: c.Core.With(fields), buckets: c.buckets, now: c.now}
+}
+
+func (c *rateLimitCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(entry.Level) || !c.allow(entry.Level) {
+		return ce
+	}
+	return c.Core.Check(entry, ce)
+}
+
+func (c *rateLimitCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if !c.allow(entry.Level) {
+		return nil
+	}
+	return c.Core.Write(entry, fields)
+}
+
+// allow 判断level级别是否还可以输出一条日志
+func (c *rateLimitCore) allow(level zapcore.Level) bool {
+	if level < zapcore.DebugLevel || level > zapcore.FatalLevel {
+		return true
+	}
+	return c.buckets[level-zapcore.DebugLevel].allow(c.now())
+}
@@ -0,0 +1,155 @@
+package pzlog
+
+import (
+	"bytes"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock 测试用的可调时钟，同时满足zapcore.Clock，可用于PzlogConfig.Clock
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.t
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+func (c *fakeClock) NewTicker(d time.Duration) *time.Ticker {
+	return time.NewTicker(d)
+}
+
+func TestRateLimitCore(t *testing.T) {
+	var buf bytes.Buffer
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(&buf), zapcore.DebugLevel)
+	logger := zap.New(newRateLimitCore(core, &RateLimitConfig{PerSecond: 100}, clock.Now))
+
+	count := func() int {
+		n := strings.Count(buf.String(), "\n")
+		buf.Reset()
+		return n
+	}
+	for sec := 0; sec < 3; sec++ {
+		for i := 0; i < 1000; i++ {
+			logger.Info("flood")
+			clock.Add(time.Millisecond)
+		}
+		// 每秒漏出100条，第一秒另有100条突发容量
+		want := 100
+		if sec == 0 {
+			want = 200
+		}
+		if got := count(); got < want-1 || got > want {
+			t.Errorf("second %d: emitted %d, want about %d", sec, got, want)
+		}
+	}
+
+	// 每个级别独立计算
+	for i := 0; i < 1000; i++ {
+		logger.Info("flood")
+	}
+	count()
+	logger.Warn("other level")
+	if got := count(); got != 1 {
+		t.Errorf("warn limited by the info bucket, emitted %d", got)
+	}
+}
+
+func TestRateLimitConfig(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.RateLimit = &RateLimitConfig{PerSecond: 5}
+	logger := GetLogger(config)
+	for i := 0; i < 50; i++ {
+		logger.Info("flood")
+	}
+	if got := len(out.Lines()); got != 5 {
+		t.Errorf("emitted %d entries, want 5", got)
+	}
+}
+
+// redirectStdout 将os.Stdout重定向到临时文件，返回读取其内容的函数
+func redirectStdout(t *testing.T) func() string {
+	t.Helper()
+	f, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := os.Stdout
+	os.Stdout = f
+	t.Cleanup(func() {
+		os.Stdout = prev
+		_ = f.Close()
+	})
+	return func() string {
+		return readFile(t, f.Name())
+	}
+}
+
+func TestConsoleRateLimit(t *testing.T) {
+	stdout := redirectStdout(t)
+	config := NewDefaultConfig()
+	config.Filename = filepath.Join(t.TempDir(), "app.log")
+	config.PrintConsole = true
+	config.ConsoleRateLimit = &RateLimitConfig{PerSecond: 5}
+	setDefaultValue(config)
+	res := &coreResources{}
+	defer res.close()
+	core, _ := newOutputCore(config, newEncoder(config, config.Encoder), zap.NewAtomicLevelAt(zapcore.InfoLevel), res)
+	logger := zap.New(core)
+	for i := 0; i < 50; i++ {
+		logger.Info("flood")
+	}
+	_ = logger.Sync()
+
+	if got := strings.Count(readFile(t, config.Filename), "\n"); got != 50 {
+		t.Errorf("file got %d entries, want 50", got)
+	}
+	if got := strings.Count(stdout(), "\n"); got != 5 {
+		t.Errorf("stdout got %d entries, want 5", got)
+	}
+}
+
+// 通过GetLogger创建时控制台core位于levelOutputCore等包装之内，只会被调用Write
+func TestConsoleRateLimitGetLogger(t *testing.T) {
+	stdout := redirectStdout(t)
+	config := NewDefaultConfig()
+	config.Filename = filepath.Join(t.TempDir(), "app.log")
+	config.PrintConsole = true
+	config.ConsoleRateLimit = &RateLimitConfig{PerSecond: 2}
+	logger := GetLogger(config)
+	defer func() { _ = Close() }()
+	for i := 0; i < 10; i++ {
+		logger.Info("flood")
+	}
+	_ = logger.Sync()
+
+	if got := strings.Count(readFile(t, config.Filename), "\n"); got != 10 {
+		t.Errorf("file got %d entries, want 10", got)
+	}
+	if got := strings.Count(stdout(), "\n"); got != 2 {
+		t.Errorf("stdout got %d entries, want 2", got)
+	}
+}
+
+// 作为最外层时仍然经过内层core在Check中的过滤(例如Sampler)
+func TestRateLimitKeepsInnerChecks(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.RateLimit = &RateLimitConfig{PerSecond: 100}
+	config.Sampler = SamplerFunc(func(entry zapcore.Entry) bool { return entry.Message != "noise" })
+	logger := GetLogger(config)
+	logger.Info("noise")
+	logger.Info("kept")
+	if lines := out.Lines(); len(lines) != 1 || !strings.Contains(lines[0], `"kept"`) {
+		t.Errorf("lines = %q, want only the entry kept by the sampler", lines)
+	}
+}
@@ -0,0 +1,105 @@
+package pzlog
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"sync"
+)
+
+// recentPathsMaxClients 按客户端记录时最多保存的客户端数，超过时清空重新记录
+const recentPathsMaxClients = 4096
+
+// recentPaths 按客户端(或全局)记录最近的请求路径
+type recentPaths struct {
+	mu    sync.Mutex
+	size  int
+	rings map[string]*pathRing
+}
+
+func newRecentPaths(size int) *recentPaths {
+	return &recentPaths{size: size, rings: make(map[string]*pathRing)}
+}
+
+// add 记录一次请求路径，返回记录前该键最近的请求路径(从旧到新)
+func (r *recentPaths) add(key, path string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ring, ok := r.rings[key]
+	if !ok {
+		if len(r.rings) >= recentPathsMaxClients {
+			r.rings = make(map[string]*pathRing)
+		}
+		ring = &pathRing{paths: make([]string, 0, r.size)}
+		r.rings[key] = ring
+	}
+	prev := ring.list()
+	ring.push(path)
+	return prev
+}
+
+// pathRing 固定容量的路径环形缓冲
+type pathRing struct {
+	paths []string
+	next  int
+}
+
+func (r *pathRing) push(path string) {
+	if len(r.paths) < cap(r.paths) {
+		r.paths = append(r.paths, path)
+		return
+	}
+	r.paths[r.next] = path
+	r.next = (r.next + 1) % len(r.paths)
+}
+
+// list 按从旧到新的顺序返回路径
+func (r *pathRing) list() []string {
+	out := make([]string, 0, len(r.paths))
+	out = append(out, r.paths[r.next:]...)
+	out = append(out, r.paths[:r.next]...)
+	return out
+}
+
+// withRecentPaths 返回保存了Logger的context，通过FromContext(返回的context)记录的error及以上级别的日志附加recent_paths字段，
+// Logger取自ctx中保存的Logger，没有时为zap.L()
+func withRecentPaths(ctx context.Context, paths []string) context.Context {
+	base, ok := ctx.Value(loggerKey{}).(*zap.Logger)
+	if !ok || base == nil {
+		base = zap.L()
+	}
+	fields := []zapcore.Field{zap.Strings("recent_paths", paths)}
+	logger := base.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &levelFieldsCore{Core: core, level: zapcore.ErrorLevel, fields: fields}
+	}))
+	return WithLogger(ctx, logger)
+}
+
+// levelFieldsCore 为不低于指定级别的日志附加字段
+type levelFieldsCore struct {
+	zapcore.Core
+	level  zapcore.Level
+	fields []zapcore.Field
+}
+
+func (c *levelFieldsCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelFieldsCore{Core: c.Core.With(fields), level: c.level, fields: c.fields}
+}
+
+// Check 交给内层core检查，保留内层core的采样等过滤，不低于指定级别时内层core附加字段
+func (c *levelFieldsCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if entry.Level >= c.level {
+		return c.Core.With(c.fields).Check(entry, ce)
+	}
+	return c.Core.Check(entry, ce)
+}
+
+func (c *levelFieldsCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if entry.Level >= c.level {
+		all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+		all = append(all, c.fields...)
+		all = append(all, fields...)
+		fields = all
+	}
+	return c.Core.Write(entry, fields)
+}
@@ -0,0 +1,21 @@
+package pzlog
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRecentPathsRing(t *testing.T) {
+	r := newRecentPaths(2)
+	if prev := r.add("", "/a"); len(prev) != 0 {
+		t.Errorf("first add returned %v, want none", prev)
+	}
+	r.add("", "/b")
+	r.add("", "/c")
+	if prev := r.add("", "/d"); !reflect.DeepEqual(prev, []string{"/b", "/c"}) {
+		t.Errorf("prev = %v, want the last two paths from old to new", prev)
+	}
+	if prev := r.add("other", "/x"); len(prev) != 0 {
+		t.Errorf("other key prev = %v, want none", prev)
+	}
+}
@@ -0,0 +1,92 @@
+package pzlog
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// RequestBodyKey gin上下文中保存已缓冲的请求体([]byte)的键。GinLogger读取请求体时会保存，
+// 其他缓冲了请求体的中间件也可以保存，GinRecovery记录panic时附带脱敏并截断后的请求体
+const RequestBodyKey = "request_body"
+
+// GinRecovery 返回gin的panic恢复中间件，panic以error级别记录到zap.L()并返回500，stack为true时记录调用栈。
+// 上游中间件缓冲了请求体(见RequestBodyKey)时，按默认的敏感字段脱敏并截断到4096字节后记录为body字段。
+// 客户端断开连接(broken pipe)导致的panic不返回状态码，只记录错误
+func GinRecovery(stack bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			fields := []zap.Field{
+				zap.Any("error", r),
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+				zap.Any("headers", redactHeaders(c.Request.Header)),
+			}
+			if body, ok := c.Get(RequestBodyKey); ok {
+				if data, ok := body.([]byte); ok && len(data) > 0 {
+					fields = append(fields, zap.ByteString("body", (&GinConfig{}).redactBody(data)))
+				}
+			}
+			if brokenPipe(r) {
+				zap.L().Error("broken connection", fields...)
+				_ = c.Error(errorFromPanic(r))
+				c.Abort()
+				return
+			}
+			if stack {
+				fields = append(fields, zap.Stack("stacktrace"))
+			}
+			zap.L().Error("[Recovery from panic]", fields...)
+			c.AbortWithStatus(http.StatusInternalServerError)
+		}()
+		c.Next()
+	}
+}
+
+// brokenPipe 判断panic是否由客户端断开连接引起
+func brokenPipe(r interface{}) bool {
+	err, ok := r.(error)
+	if !ok {
+		return false
+	}
+	var ne *net.OpError
+	if !errors.As(err, &ne) {
+		return false
+	}
+	var se *os.SyscallError
+	if !errors.As(ne, &se) {
+		return false
+	}
+	msg := strings.ToLower(se.Error())
+	return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
+}
+
+// errorFromPanic 将panic的值转换为error
+func errorFromPanic(r interface{}) error {
+	if err, ok := r.(error); ok {
+		return err
+	}
+	return errors.New("panic")
+}
+
+// NewGinEngine 根据配置初始化Logger(替换zap的全局Logger)，返回已安装GinLogger和GinRecovery的gin.Engine，不修改传入的config
+func NewGinEngine(config *PzlogConfig) *gin.Engine {
+	if config == nil {
+		config = NewDefaultConfig()
+	} else {
+		config = MergeConfig(config, nil)
+	}
+	config.ReplaceGlobals = true
+	GetLogger(config)
+	engine := gin.New()
+	engine.Use(GinLogger(), GinRecovery(true))
+	return engine
+}
@@ -0,0 +1,119 @@
+package pzlog
+
+import (
+	"encoding/json"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"regexp"
+	"strings"
+)
+
+const redactedValue = "***"
+
+// defaultRedactKeys 默认需要脱敏的字段名(不区分大小写)
+var defaultRedactKeys = []string{"password", "passwd", "secret", "token", "access_token", "refresh_token", "authorization", "api_key", "apikey"}
+
+// redactKeySet 将字段名列表转换为小写集合，列表为空时使用默认字段名
+func redactKeySet(keys []string) map[string]bool {
+	if len(keys) == 0 {
+		keys = defaultRedactKeys
+	}
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[strings.ToLower(k)] = true
+	}
+	return set
+}
+
+// redactJSON 将json中敏感字段的值替换为***，不是合法json时返回false
+func redactJSON(data []byte, keys map[string]bool) ([]byte, bool) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, false
+	}
+	out, err := json.Marshal(redactValue(v, keys))
+	if err != nil {
+		return nil, false
+	}
+	return out, true
+}
+
+// jsonPairPattern 匹配json中的"key": value，value为字符串(可能被截断)或其他非结构的值
+var jsonPairPattern = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"(\s*:\s*)("(?:[^"\\]|\\.)*"?|[^\s,{}\[\]]+)`)
+
+// redactJSONPrefix 替换不完整的json(例如被截断的请求体)中敏感字段的值，用于无法解析的json
+func redactJSONPrefix(data []byte, keys map[string]bool) []byte {
+	return jsonPairPattern.ReplaceAllFunc(data, func(pair []byte) []byte {
+		m := jsonPairPattern.FindSubmatch(pair)
+		if !keys[strings.ToLower(string(m[1]))] {
+			return pair
+		}
+		out := make([]byte, 0, len(m[1])+len(m[2])+len(redactedValue)+4)
+		out = append(out, '"')
+		out = append(out, m[1]...)
+		out = append(out, '"')
+		out = append(out, m[2]...)
+		return append(append(append(out, '"'), redactedValue...), '"')
+	})
+}
+
+// redactMap 复制map并将敏感字段的值替换为***
+func redactMap(m map[string]interface{}, keys map[string]bool) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if keys[strings.ToLower(k)] {
+			out[k] = redactedValue
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+func redactValue(v interface{}, keys map[string]bool) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			if keys[strings.ToLower(k)] {
+				val[k] = redactedValue
+				continue
+			}
+			val[k] = redactValue(item, keys)
+		}
+	case []interface{}:
+		for i, item := range val {
+			val[i] = redactValue(item, keys)
+		}
+	}
+	return v
+}
+
+// compileRedactPatterns 编译消息脱敏的正则表达式，无效的表达式被忽略
+func compileRedactPatterns(patterns []string) []*regexp.Regexp {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			continue
+		}
+		res = append(res, re)
+	}
+	return res
+}
+
+// redactMessageEncoder 在编码前将日志消息中匹配正则表达式的内容替换为***
+type redactMessageEncoder struct {
+	zapcore.Encoder
+	patterns []*regexp.Regexp
+}
+
+func (e *redactMessageEncoder) Clone() zapcore.Encoder {
+	return &redactMessageEncoder{Encoder: e.Encoder.Clone(), patterns: e.patterns}
+}
+
+func (e *redactMessageEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	for _, re := range e.patterns {
+		entry.Message = re.ReplaceAllLiteralString(entry.Message, redactedValue)
+	}
+	return e.Encoder.EncodeEntry(entry, fields)
+}
@@ -0,0 +1,29 @@
+package pzlog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactMessage(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.RedactMessage = []string{`token=\w+`, `\b\d{16}\b`}
+	GetLogger(config).Info("login ok token=abc123 card 4111111111111111 user=bob")
+
+	entries := out.Entries(t)
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	if msg := entries[0]["msg"]; msg != "login ok *** card *** user=bob" {
+		t.Errorf("msg = %q", msg)
+	}
+}
+
+func TestRedactMessageInvalidPattern(t *testing.T) {
+	config := NewDefaultConfig()
+	config.Output = "none"
+	config.RedactMessage = []string{"token=("}
+	if _, err := GetLoggerE(config); err == nil || !strings.Contains(err.Error(), "redactmessage") {
+		t.Errorf("err = %v, want invalid redactmessage error", err)
+	}
+}
@@ -0,0 +1,286 @@
+package pzlog
+
+import (
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"io"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// currentSwap 最近一次GetLogger创建的可替换core，Reconfigure作用于它
+	currentSwap   *swapState
+	currentSwapMu sync.Mutex
+)
+
+// rootCore 某一代配置构建出的core
+type rootCore struct {
+	gen  uint64
+	core zapcore.Core
+	// sinks 该core的各输出，用于Health
+	sinks []*trackedSink
+	// outputs 该core按级别重定向的输出，用于SetLevelOutput
+	outputs *levelOutputs
+	// resources 该core打开的文件等资源，core被替换后关闭
+	resources *coreResources
+}
+
+// coreResources 构建core时打开的日志文件、启动的goroutine等需要关闭的资源
+type coreResources struct {
+	mu      sync.Mutex
+	closers []io.Closer
+	// inflight 正在写入该core的日志数，retired 该core已被替换，两者都满足时关闭资源
+	inflight atomic.Int64
+	retired  atomic.Bool
+}
+
+// acquire 开始一次写入，core已被替换时返回false，r为nil时总是成功
+func (r *coreResources) acquire() bool {
+	if r == nil {
+		return true
+	}
+	r.inflight.Add(1)
+	if r.retired.Load() {
+		r.release()
+		return false
+	}
+	return true
+}
+
+// release 结束一次写入，core已被替换且没有正在进行的写入时关闭资源
+func (r *coreResources) release() {
+	if r == nil {
+		return
+	}
+	if r.inflight.Add(-1) == 0 && r.retired.Load() {
+		_ = r.close()
+	}
+}
+
+// retire 标记core已被替换，没有正在进行的写入时立即关闭资源并返回关闭的错误，
+// 否则由最后一次写入结束时关闭
+func (r *coreResources) retire() error {
+	if r == nil {
+		return nil
+	}
+	r.retired.Store(true)
+	if r.inflight.Load() == 0 {
+		return r.close()
+	}
+	return nil
+}
+
+// add 记录需要关闭的资源，r为nil时忽略
+func (r *coreResources) add(c io.Closer) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.closers = append(r.closers, c)
+	r.mu.Unlock()
+}
+
+// close 按打开的相反顺序关闭所有资源，返回第一个错误
+func (r *coreResources) close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	closers := r.closers
+	r.closers = nil
+	r.mu.Unlock()
+	var err error
+	for i := len(closers) - 1; i >= 0; i-- {
+		if closeErr := closers[i].Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// swapState 同一个Logger及其派生Logger共享的可替换core
+type swapState struct {
+	root atomic.Pointer[rootCore]
+	// callerOnDemand、clock 创建Logger时使用的选项，Reconfigure不能修改
+	callerOnDemand bool
+	clock          zapcore.Clock
+}
+
+func newSwapState(root *rootCore) *swapState {
+	s := &swapState{}
+	s.root.Store(root)
+	return s
+}
+
+// swap 替换core，返回旧的rootCore
+func (s *swapState) swap(root *rootCore) *rootCore {
+	for {
+		old := s.root.Load()
+		next := *root
+		next.gen = old.gen + 1
+		if s.root.CompareAndSwap(old, &next) {
+			return old
+		}
+	}
+}
+
+// swapCore 可在运行时替换底层core的zapcore.Core，已有的*zap.Logger引用无需重建
+type swapCore struct {
+	state  *swapState
+	fields []zapcore.Field
+	cache  atomic.Pointer[rootCore]
+}
+
+func newSwapCore(state *swapState) *swapCore {
+	return &swapCore{state: state}
+}
+
+// current 返回当前代的core，并附加With添加的字段
+func (s *swapCore) current() *rootCore {
+	root := s.state.root.Load()
+	if len(s.fields) == 0 {
+		return root
+	}
+	if c := s.cache.Load(); c != nil && c.gen == root.gen {
+		return c
+	}
+	c := &rootCore{gen: root.gen, core: root.core.With(s.fields), resources: root.resources}
+	s.cache.Store(c)
+	return c
+}
+
+// acquire 返回当前代的core并开始一次写入，写入结束后需调用其resources.release
+func (s *swapCore) acquire() *rootCore {
+	for {
+		if root := s.current(); root.resources.acquire() {
+			return root
+		}
+	}
+}
+
+func (s *swapCore) Enabled(level zapcore.Level) bool {
+	return s.current().core.Enabled(level)
+}
+
+func (s *swapCore) With(fields []zapcore.Field) zapcore.Core {
+	all := make([]zapcore.Field, 0, len(s.fields)+len(fields))
+	all = append(all, s.fields...)
+	all = append(all, fields...)
+	return &swapCore{state: s.state, fields: all}
+}
+
+// Check 通过当前代core的Check，并在写入完成前保持该代core的资源打开，
+// 避免Reconfigure关闭正在写入的文件
+func (s *swapCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	root := s.acquire()
+	checked := root.core.Check(entry, nil)
+	if checked == nil {
+		root.resources.release()
+		return ce
+	}
+	p := &pinnedCore{checked: checked, res: root.resources}
+	ce = ce.AddCore(entry, p)
+	p.outer = ce
+	return ce
+}
+
+func (s *swapCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	root := s.acquire()
+	defer root.resources.release()
+	return root.core.Write(entry, fields)
+}
+
+func (s *swapCore) Sync() error {
+	return s.current().core.Sync()
+}
+
+// pinnedCore 已通过某一代core的Check的日志，写入完成后释放该代core的资源
+type pinnedCore struct {
+	checked *zapcore.CheckedEntry
+	// outer 包含pinnedCore的CheckedEntry，写入错误输出到它的ErrorOutput
+	outer *zapcore.CheckedEntry
+	res   *coreResources
+}
+
+func (p *pinnedCore) Enabled(zapcore.Level) bool { return true }
+
+func (p *pinnedCore) With([]zapcore.Field) zapcore.Core { return p }
+
+func (p *pinnedCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(entry, p)
+}
+
+// Write 使用Check之后补充了调用者、堆栈等信息的entry写入
+func (p *pinnedCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	defer p.res.release()
+	p.checked.Entry = entry
+	p.checked.ErrorOutput = p.outer.ErrorOutput
+	p.checked.Write(fields...)
+	return nil
+}
+
+func (p *pinnedCore) Sync() error { return nil }
+
+// reloadLogger 返回使用最近一次GetLogger创建的core的Logger，没有时返回zap.L()
+func reloadLogger() *zap.Logger {
+	currentSwapMu.Lock()
+	state := currentSwap
+	currentSwapMu.Unlock()
+	if state == nil {
+		return zap.L()
+	}
+	return zap.New(newSwapCore(state))
+}
+
+// Reconfigure 使用新的配置重建最近一次GetLogger创建的Logger的core，并原子替换，
+// 已有的*zap.Logger引用(包括With派生的)会继续使用新的配置输出，旧的core刷新后，在正在进行的写入结束时关闭其打开的文件。
+// CallerOnDemand和Clock是创建Logger时的选项，与GetLogger时不同会返回错误
+func Reconfigure(config *PzlogConfig) error {
+	if config == nil {
+		config = NewDefaultConfig()
+	}
+	setDefaultValue(config)
+	if err := validateConfig(config); err != nil {
+		return err
+	}
+	currentSwapMu.Lock()
+	state := currentSwap
+	currentSwapMu.Unlock()
+	if state == nil {
+		return errors.New("pzlog: no logger to reconfigure, call GetLogger first")
+	}
+	if config.CallerOnDemand != state.callerOnDemand {
+		return errors.New("pzlog: reconfigure cannot change callerondemand, create a new logger with GetLogger")
+	}
+	if !sameClock(config.Clock, state.clock) {
+		return errors.New("pzlog: reconfigure cannot change clock, create a new logger with GetLogger")
+	}
+	root, _ := newCore(config)
+	applyGlobals(config, root.resources)
+	old := state.swap(root)
+	_ = old.core.Sync()
+	if err := old.resources.retire(); err != nil {
+		return fmt.Errorf("pzlog: close previous core: %w", err)
+	}
+	if config.WriteManifest {
+		if err := writeManifest(config); err != nil {
+			return fmt.Errorf("pzlog: write manifest: %w", err)
+		}
+	}
+	return nil
+}
+
+// sameClock 判断两个时钟是否相同，无法比较的类型视为不同
+func sameClock(a, b zapcore.Clock) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if reflect.TypeOf(a) != reflect.TypeOf(b) || !reflect.TypeOf(a).Comparable() {
+		return false
+	}
+	return a == b
+}
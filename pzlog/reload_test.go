@@ -0,0 +1,191 @@
+package pzlog
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestReconfigureChangesEncoder(t *testing.T) {
+	config, out := newCapturedConfig()
+	logger := GetLogger(config)
+	child := logger.With(zap.String("component", "api"))
+	logger.Info("before")
+
+	next, _ := newCapturedConfig()
+	next.Callback = out.write
+	next.Encoder = "console"
+	if err := Reconfigure(next); err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("after")
+	child.Info("child")
+
+	lines := out.Lines()
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3: %q", len(lines), lines)
+	}
+	if !strings.HasPrefix(lines[0], "{") {
+		t.Errorf("line before reconfigure should be json: %q", lines[0])
+	}
+	for _, line := range lines[1:] {
+		if strings.HasPrefix(line, "{") || !strings.Contains(line, "\tINFO\t") {
+			t.Errorf("line after reconfigure should be console: %q", line)
+		}
+	}
+	if !strings.Contains(lines[2], `{"component": "api"}`) {
+		t.Errorf("child logger lost its fields: %q", lines[2])
+	}
+}
+
+func TestReconfigureWithoutLogger(t *testing.T) {
+	currentSwapMu.Lock()
+	saved := currentSwap
+	currentSwap = nil
+	currentSwapMu.Unlock()
+	defer func() {
+		currentSwapMu.Lock()
+		currentSwap = saved
+		currentSwapMu.Unlock()
+	}()
+	if err := Reconfigure(nil); err == nil {
+		t.Error("expected error without a logger")
+	}
+}
+
+func TestReconfigureInvalidConfig(t *testing.T) {
+	config, _ := newCapturedConfig()
+	GetLogger(config)
+	next, _ := newCapturedConfig()
+	next.Encoder = "xml"
+	if err := Reconfigure(next); err == nil {
+		t.Error("expected error for invalid config")
+	}
+}
+
+func TestReconfigureClosesPreviousFiles(t *testing.T) {
+	if _, err := os.ReadDir("/proc/self/fd"); err != nil {
+		t.Skip("/proc/self/fd is not available")
+	}
+	dir := t.TempDir()
+	config := NewDefaultConfig()
+	config.Output = "file"
+	config.Filename = filepath.Join(dir, "app.log")
+	logger := GetLogger(config)
+	logger.Info("open")
+	defer func() { _ = Close() }()
+
+	countFDs := func() int {
+		entries, _ := os.ReadDir("/proc/self/fd")
+		return len(entries)
+	}
+	for i := 0; i < 5; i++ {
+		next := NewDefaultConfig()
+		next.Output = "file"
+		next.Filename = filepath.Join(dir, "app.log")
+		if err := Reconfigure(next); err != nil {
+			t.Fatal(err)
+		}
+		logger.Info("reloaded")
+	}
+	before := countFDs()
+	for i := 0; i < 20; i++ {
+		next := NewDefaultConfig()
+		next.Output = "file"
+		next.Filename = filepath.Join(dir, "app.log")
+		if err := Reconfigure(next); err != nil {
+			t.Fatal(err)
+		}
+		logger.Info("reloaded")
+	}
+	if after := countFDs(); after > before {
+		t.Errorf("open files grew from %d to %d after reloads", before, after)
+	}
+}
+
+func TestReconfigureWaitsForInflightWrites(t *testing.T) {
+	if _, err := os.ReadDir("/proc/self/fd"); err != nil {
+		t.Skip("/proc/self/fd is not available")
+	}
+	dir := t.TempDir()
+	newConfig := func() *PzlogConfig {
+		config := NewDefaultConfig()
+		config.Output = "file"
+		config.Filename = filepath.Join(dir, "app.log")
+		return config
+	}
+	logger := GetLogger(newConfig())
+	defer func() { _ = Close() }()
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					logger.Info("concurrent")
+				}
+			}
+		}()
+	}
+	for i := 0; i < 5000; i++ {
+		if err := Reconfigure(newConfig()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	// 当前代的core只打开一个文件，其余都应已关闭
+	fds, _ := os.ReadDir("/proc/self/fd")
+	open := 0
+	for _, fd := range fds {
+		if target, err := os.Readlink(filepath.Join("/proc/self/fd", fd.Name())); err == nil && strings.HasPrefix(target, dir) {
+			open++
+		}
+	}
+	if open > 1 {
+		t.Errorf("%d files open in the log directory, want at most 1", open)
+	}
+}
+
+type traceKey struct{}
+
+func TestReconfigureAppliesGlobals(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.ReplaceGlobals = true
+	GetLogger(config)
+
+	next, _ := newCapturedConfig()
+	next.Callback = out.write
+	next.ReplaceGlobals = true
+	next.TraceIDContextKey = traceKey{}
+	if err := Reconfigure(next); err != nil {
+		t.Fatal(err)
+	}
+	defer setTraceIDKey(nil, "")
+	FromContext(context.WithValue(context.Background(), traceKey{}, "abc")).Info("traced")
+	entries := out.Entries(t)
+	if got := entries[len(entries)-1]["trace_id"]; got != "abc" {
+		t.Errorf("trace_id = %v, want abc", got)
+	}
+}
+
+func TestReconfigureRejectsLoggerOptions(t *testing.T) {
+	config, _ := newCapturedConfig()
+	GetLogger(config)
+	next, _ := newCapturedConfig()
+	next.CallerOnDemand = true
+	if err := Reconfigure(next); err == nil || !strings.Contains(err.Error(), "callerondemand") {
+		t.Errorf("err = %v, want callerondemand error", err)
+	}
+}
@@ -0,0 +1,107 @@
+package pzlog
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"sync"
+)
+
+// requestBufferSize 每个请求最多缓存的日志条数，达到后立即写出，之后的日志不再缓存
+const requestBufferSize = 1024
+
+// WithRequestBuffer 返回保存了缓存Logger的context及flush函数。通过FromContext(返回的context)记录的日志先缓存在内存中，
+// 在ctx结束(取消或超时)或调用flush时按顺序写入原来的Logger(ctx中保存的Logger，没有时为zap.L())，之后的日志直接写入。
+// dpanic及以上级别的日志会先写出已缓存的日志再直接写入。请求正常完成时应调用flush，flush可以重复调用
+func WithRequestBuffer(ctx context.Context) (context.Context, func()) {
+	base, ok := ctx.Value(loggerKey{}).(*zap.Logger)
+	if !ok || base == nil {
+		base = zap.L()
+	}
+	buf := &requestBuffer{target: base.Core(), done: make(chan struct{})}
+	logger := base.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &requestBufferCore{Core: core, buf: buf}
+	}))
+	go func() {
+		select {
+		case <-ctx.Done():
+			buf.flush()
+		case <-buf.done:
+		}
+	}()
+	return WithLogger(ctx, logger), buf.flush
+}
+
+// requestBuffer 一个请求缓存的日志
+type requestBuffer struct {
+	target  zapcore.Core
+	mu      sync.Mutex
+	entries []bufferedEntry
+	flushed bool
+	done    chan struct{}
+}
+
+// flush 按顺序写出缓存的日志，之后的日志直接写入
+func (b *requestBuffer) flush() {
+	b.mu.Lock()
+	if b.flushed {
+		b.mu.Unlock()
+		return
+	}
+	b.flushed = true
+	entries := b.entries
+	b.entries = nil
+	close(b.done)
+	b.mu.Unlock()
+	for _, e := range entries {
+		if ce := b.target.Check(e.entry, nil); ce != nil {
+			ce.Write(e.fields...)
+		}
+	}
+}
+
+// requestBufferCore 将日志缓存到requestBuffer，flush之后直接写入原来的core
+type requestBufferCore struct {
+	zapcore.Core
+	buf    *requestBuffer
+	fields []zapcore.Field
+}
+
+func (c *requestBufferCore) With(fields []zapcore.Field) zapcore.Core {
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+	all = append(all, fields...)
+	return &requestBufferCore{Core: c.Core, buf: c.buf, fields: all}
+}
+
+func (c *requestBufferCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *requestBufferCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+	all = append(all, fields...)
+	if entry.Level >= zapcore.DPanicLevel {
+		// 之后可能panic或退出，先按顺序写出已缓存的日志，这条日志不经过缓存直接写入
+		c.buf.flush()
+	}
+	c.buf.mu.Lock()
+	if !c.buf.flushed {
+		c.buf.entries = append(c.buf.entries, bufferedEntry{entry: entry, fields: all})
+		full := len(c.buf.entries) >= requestBufferSize
+		c.buf.mu.Unlock()
+		if full {
+			c.buf.flush()
+		}
+		return nil
+	}
+	c.buf.mu.Unlock()
+	if ce := c.Core.Check(entry, nil); ce != nil {
+		ce.Write(all...)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package pzlog
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"testing"
+	"time"
+)
+
+func messages(logs *observer.ObservedLogs) string {
+	var msgs []string
+	for _, e := range logs.All() {
+		msgs = append(msgs, e.Message)
+	}
+	return fmt.Sprint(msgs)
+}
+
+func TestRequestBufferCancel(t *testing.T) {
+	logs := observeGlobals(t, zapcore.InfoLevel)
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx, flush := WithRequestBuffer(ctx)
+	logger := FromContext(ctx).With(zap.String("req", "r1"))
+	logger.Info("first")
+	logger.Info("second")
+	if n := logs.Len(); n != 0 {
+		t.Fatalf("%d entries written before the context is done", n)
+	}
+
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for logs.Len() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	logger.Info("after")
+	flush()
+
+	if got := messages(logs); got != "[first second after]" {
+		t.Fatalf("logged %s, want [first second after]", got)
+	}
+	if v := logs.All()[0].ContextMap()["req"]; v != "r1" {
+		t.Errorf("buffered entry req = %v, want the With field", v)
+	}
+}
+
+func TestRequestBufferFlush(t *testing.T) {
+	logs := observeGlobals(t, zapcore.InfoLevel)
+	ctx, flush := WithRequestBuffer(context.Background())
+	FromContext(ctx).Info("buffered")
+	FromContext(ctx).Debug("disabled")
+	flush()
+	flush()
+	FromContext(ctx).Info("direct")
+	if got := messages(logs); got != "[buffered direct]" {
+		t.Errorf("logged %s, want [buffered direct]", got)
+	}
+}
+
+func TestRequestBufferBypassDPanic(t *testing.T) {
+	logs := observeGlobals(t, zapcore.InfoLevel)
+	ctx, flush := WithRequestBuffer(context.Background())
+	defer flush()
+	logger := FromContext(ctx)
+	logger.Info("before")
+	logger.DPanic("dpanic")
+	if got := messages(logs); got != "[before dpanic]" {
+		t.Fatalf("after dpanic logged %s, want [before dpanic]", got)
+	}
+
+	ctx, flush = WithRequestBuffer(context.Background())
+	defer flush()
+	logger = FromContext(ctx)
+	logger.Info("buffered")
+	func() {
+		defer func() { _ = recover() }()
+		logger.Panic("panic")
+	}()
+	if got := messages(logs); got != "[before dpanic buffered panic]" {
+		t.Errorf("after panic logged %s, want the buffered entry before the panic", got)
+	}
+}
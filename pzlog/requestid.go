@@ -0,0 +1,41 @@
+package pzlog
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+)
+
+const (
+	// RequestIDKey gin上下文中保存请求ID的键
+	RequestIDKey = "request_id"
+
+	defaultRequestIDHeader = "X-Request-ID"
+)
+
+type requestIDCtxKey struct{}
+
+// WithRequestID 将请求ID保存到context，并作为request_id标签附加到FromContext返回的Logger。
+// GinConfig.RequestID为true时GinLogger会对请求context调用，处理函数中通过FromGinContext(c)或
+// FromContext(c.Request.Context())记录的日志与请求日志带有相同的request_id，可据此关联
+func WithRequestID(ctx context.Context, id string) context.Context {
+	ctx = context.WithValue(ctx, requestIDCtxKey{}, id)
+	return WithLabel(ctx, RequestIDKey, id)
+}
+
+// RequestIDFromContext 返回WithRequestID保存的请求ID，没有时返回空字符串，例如用于调用下游服务时传递请求ID
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDCtxKey{}).(string)
+	return id
+}
+
+// newUUIDv4 生成随机的UUIDv4字符串
+func newUUIDv4() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
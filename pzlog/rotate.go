@@ -0,0 +1,58 @@
+package pzlog
+
+import (
+	"sync"
+	"time"
+)
+
+// countRotateSyncer 每写入every条日志后切割lumberjack日志文件
+type countRotateSyncer struct {
+	mu     sync.Mutex
+	logger rotatingFile
+	every  int
+	n      int
+	// last 上次切割的时间
+	last time.Time
+}
+
+func newCountRotateSyncer(logger rotatingFile, every int) *countRotateSyncer {
+	return &countRotateSyncer{logger: logger, every: every}
+}
+
+func (w *countRotateSyncer) Write(p []byte) (int, error) {
+	// 丢弃的日志编码为空，不计入条数
+	if len(p) == 0 {
+		return 0, nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n, err := w.logger.Write(p)
+	if err != nil {
+		return n, err
+	}
+	w.n++
+	if w.n >= w.every {
+		w.n = 0
+		w.waitNextMillisecond()
+		err := w.logger.Rotate()
+		// 在Rotate之后记录，保证不早于备份文件名使用的时间
+		w.last = time.Now()
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// waitNextMillisecond lumberjack的备份文件名精确到毫秒，同一毫秒内两次切割会覆盖前一个备份，
+// 因此等待到上次切割之后的下一毫秒再切割，保证备份文件名唯一且仍能被MaxBackups、MaxAge识别
+func (w *countRotateSyncer) waitNextMillisecond() {
+	next := w.last.Truncate(time.Millisecond).Add(time.Millisecond)
+	if d := time.Until(next); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+func (w *countRotateSyncer) Sync() error {
+	return nil
+}
@@ -0,0 +1,46 @@
+package pzlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotateEvery(t *testing.T) {
+	dir := t.TempDir()
+	config := NewDefaultConfig()
+	config.Filename = filepath.Join(dir, "app.log")
+	config.RotateEvery = 2
+	logger := GetLogger(config)
+	defer func() { _ = Close() }()
+	// 连续切割通常发生在同一毫秒内，不能覆盖前一个备份
+	for _, msg := range []string{"one", "two", "three", "four", "five", "six", "seven"} {
+		logger.Info(msg)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ReadDir按文件名排序，备份文件名中的时间戳即切割顺序
+	var backups []string
+	for _, e := range entries {
+		if e.Name() != "app.log" {
+			backups = append(backups, readFile(t, filepath.Join(dir, e.Name())))
+		}
+	}
+	if len(backups) != 3 {
+		t.Fatalf("got %d backups, want 3", len(backups))
+	}
+	for i, want := range [][]string{{"one", "two"}, {"three", "four"}, {"five", "six"}} {
+		for _, msg := range want {
+			if !strings.Contains(backups[i], `"`+msg+`"`) {
+				t.Errorf("backup %d = %q, missing %s", i, backups[i], msg)
+			}
+		}
+	}
+	if current := readFile(t, config.Filename); !strings.Contains(current, `"seven"`) || strings.Contains(current, `"six"`) {
+		t.Errorf("current file = %q, want only seven", current)
+	}
+}
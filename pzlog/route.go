@@ -0,0 +1,116 @@
+package pzlog
+
+import (
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const defaultRouteField = "sink"
+
+// RouteConfig 按字段路由日志的配置，带有路由字段(例如 zap.String("sink", "audit"))的日志只写入对应的输出
+type RouteConfig struct {
+	// 路由字段名，默认sink
+	Field string `json:"field" yaml:"field"`
+
+	// 路由目标，键为路由字段的值
+	Sinks map[string]*RouteSink `json:"sinks" yaml:"sinks"`
+}
+
+// RouteSink 路由目标，默认写入文件，Writer不为nil时写入Writer
+type RouteSink struct {
+	lumberjack.Logger `yaml:",inline"`
+
+	Writer zapcore.WriteSyncer `json:"-" yaml:"-"`
+}
+
+// writeSyncer 创建路由目标的WriteSyncer，header不为nil时在输出或每个新文件的开头写入，打开的文件记录到res
+func (s *RouteSink) writeSyncer(header []byte, res *coreResources) zapcore.WriteSyncer {
+	if s.Writer != nil {
+		return withCSVHeader(s.Writer, header)
+	}
+	logger := &lumberjack.Logger{
+		Filename:   s.Filename,
+		MaxSize:    s.MaxSize,
+		MaxBackups: s.MaxBackups,
+		MaxAge:     s.MaxAge,
+		LocalTime:  s.LocalTime,
+		Compress:   s.Compress,
+	}
+	res.add(logger)
+	return zapcore.AddSync(withCSVHeaderFile(logger, header))
+}
+
+// routeCore 根据路由字段将日志分发到指定的输出，没有路由字段或目标不存在时写入主输出
+type routeCore struct {
+	zapcore.Core
+	field string
+	sinks map[string]zapcore.Core
+	// route With中添加的路由字段的值
+	route string
+}
+
+func newRouteCore(core zapcore.Core, config *RouteConfig, enc zapcore.Encoder, header []byte, level zapcore.LevelEnabler, res *coreResources) zapcore.Core {
+	field := config.Field
+	if field == "" {
+		field = defaultRouteField
+	}
+	sinks := make(map[string]zapcore.Core, len(config.Sinks))
+	for name, sink := range config.Sinks {
+		if sink == nil {
+			continue
+		}
+		sinks[name] = zapcore.NewCore(enc, sink.writeSyncer(header, res), level)
+	}
+	return &routeCore{Core: core, field: field, sinks: sinks}
+}
+
+func (c *routeCore) With(fields []zapcore.Field) zapcore.Core {
+	sinks := make(map[string]zapcore.Core, len(c.sinks))
+	for name, sink := range c.sinks {
+		sinks[name] = sink.With(fields)
+	}
+	route := c.route
+	if r, ok := c.routeOf(fields); ok {
+		route = r
+	}
+	return &routeCore{Core: c.Core.With(fields), field: c.field, sinks: sinks, route: route}
+}
+
+func (c *routeCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *routeCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	route := c.route
+	if r, ok := c.routeOf(fields); ok {
+		route = r
+	}
+	if sink, ok := c.sinks[route]; ok && route != "" {
+		return sink.Write(entry, fields)
+	}
+	return c.Core.Write(entry, fields)
+}
+
+func (c *routeCore) Sync() error {
+	err := c.Core.Sync()
+	for _, sink := range c.sinks {
+		if sinkErr := sink.Sync(); err == nil {
+			err = sinkErr
+		}
+	}
+	return err
+}
+
+// routeOf 返回字段中最后一个路由字段的值
+func (c *routeCore) routeOf(fields []zapcore.Field) (string, bool) {
+	route, ok := "", false
+	for _, f := range fields {
+		if f.Key == c.field && f.Type == zapcore.StringType {
+			route, ok = f.String, true
+		}
+	}
+	return route, ok
+}
@@ -0,0 +1,37 @@
+package pzlog
+
+import (
+	"bytes"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"strings"
+	"testing"
+)
+
+func TestRouteByField(t *testing.T) {
+	var audit bytes.Buffer
+	config, out := newCapturedConfig()
+	config.Route = &RouteConfig{Sinks: map[string]*RouteSink{
+		"audit": {Writer: zapcore.AddSync(&audit)},
+	}}
+	logger := GetLogger(config)
+	logger.Info("routed", zap.String("sink", "audit"))
+	logger.Info("unknown target", zap.String("sink", "missing"))
+	logger.Info("main")
+	logger.With(zap.String("sink", "audit")).Info("routed with")
+
+	if got := strings.Count(audit.String(), "\n"); got != 2 {
+		t.Errorf("audit sink got %d entries, want 2: %q", got, audit.String())
+	}
+	if !strings.Contains(audit.String(), `"msg":"routed"`) || !strings.Contains(audit.String(), `"msg":"routed with"`) {
+		t.Errorf("audit sink = %q", audit.String())
+	}
+	for _, line := range out.Lines() {
+		if strings.Contains(line, "routed") {
+			t.Errorf("routed entry written to the main output: %s", line)
+		}
+	}
+	if got := len(out.Lines()); got != 2 {
+		t.Errorf("main output got %d entries, want 2", got)
+	}
+}
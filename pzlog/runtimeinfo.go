@@ -0,0 +1,16 @@
+package pzlog
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"runtime"
+)
+
+// runtimeFields 返回Go版本及运行平台字段go_version、goos和goarch
+func runtimeFields() []zapcore.Field {
+	return []zapcore.Field{
+		zap.String("go_version", runtime.Version()),
+		zap.String("goos", runtime.GOOS),
+		zap.String("goarch", runtime.GOARCH),
+	}
+}
@@ -0,0 +1,322 @@
+package pzlog
+
+import (
+	"go.uber.org/zap/zapcore"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SamplingConfig 采样配置，每个Tick周期内相同级别和消息的日志先记录Initial条，之后每Thereafter条记录一条
+type SamplingConfig struct {
+	Tick time.Duration `json:"tick" yaml:"tick"`
+
+	Initial int `json:"initial" yaml:"initial"`
+
+	Thereafter int `json:"thereafter" yaml:"thereafter"`
+
+	// 按调用位置(文件:行号)而不是日志消息采样，每个调用位置独立计数
+	ByCaller bool `json:"bycaller" yaml:"bycaller"`
+
+	// 日志级别变化时(例如运行时调整为debug)清空采样计数，调整后的日志立即可见
+	ResetOnLevelChange bool `json:"resetonlevelchange" yaml:"resetonlevelchange"`
+
+	// 豁免采样的判断函数，返回true的日志(包括With添加的字段)总是记录，不参与采样
+	Exempt func(entry zapcore.Entry, fields []zapcore.Field) bool `json:"-" yaml:"-"`
+}
+
+// ExemptKey 返回豁免采样的判断函数，带有指定字段的日志不参与采样，布尔字段只在值为true时豁免
+func ExemptKey(key string) func(zapcore.Entry, []zapcore.Field) bool {
+	return func(_ zapcore.Entry, fields []zapcore.Field) bool {
+		for _, f := range fields {
+			if f.Key != key {
+				continue
+			}
+			if f.Type == zapcore.BoolType {
+				return f.Integer == 1
+			}
+			return true
+		}
+		return false
+	}
+}
+
+// droppedSamples 按级别统计被采样丢弃的日志条数，下标为 level - zapcore.DebugLevel
+var droppedSamples [zapcore.FatalLevel - zapcore.DebugLevel + 1]atomic.Uint64
+
+// DroppedSamples 返回指定级别被采样丢弃的日志条数
+func DroppedSamples(level zapcore.Level) uint64 {
+	if level < zapcore.DebugLevel || level > zapcore.FatalLevel {
+		return 0
+	}
+	return droppedSamples[level-zapcore.DebugLevel].Load()
+}
+
+// DroppedSamplesByLevel 返回各级别被采样丢弃的日志条数
+func DroppedSamplesByLevel() map[string]uint64 {
+	counts := make(map[string]uint64, len(droppedSamples))
+	for l := zapcore.DebugLevel; l <= zapcore.FatalLevel; l++ {
+		counts[l.String()] = DroppedSamples(l)
+	}
+	return counts
+}
+
+// samplingHook 统计被采样丢弃的日志
+func samplingHook(entry zapcore.Entry, dec zapcore.SamplingDecision) {
+	if dec&zapcore.LogDropped == 0 {
+		return
+	}
+	if entry.Level < zapcore.DebugLevel || entry.Level > zapcore.FatalLevel {
+		return
+	}
+	droppedSamples[entry.Level-zapcore.DebugLevel].Add(1)
+}
+
+// newSamplerCore 为core添加采样，按调用位置采样或级别变化时清空计数时的周期使用now计算
+func newSamplerCore(core zapcore.Core, config *SamplingConfig, now func() time.Time) zapcore.Core {
+	tick := config.Tick
+	if tick <= 0 {
+		tick = time.Second
+	}
+	if config.ByCaller || config.ResetOnLevelChange {
+		key := samplerKeyByMessage
+		if config.ByCaller {
+			key = samplerKeyByCaller
+		}
+		return &keyedSamplerCore{
+			Core:    core,
+			counts:  newSampleCounts(tick, config.ResetOnLevelChange),
+			key:     key,
+			initial: uint64(config.Initial),
+			after:   uint64(config.Thereafter),
+			exempt:  config.Exempt,
+			now:     now,
+		}
+	}
+	sampled := zapcore.NewSamplerWithOptions(core, tick, config.Initial, config.Thereafter,
+		zapcore.SamplerHook(samplingHook))
+	if config.Exempt == nil {
+		return sampled
+	}
+	return &exemptSamplerCore{Core: core, sampled: sampled, exempt: config.Exempt}
+}
+
+// exemptSamplerCore 在写入时根据字段判断是否豁免采样，豁免的日志直接写入，其余日志经过采样
+type exemptSamplerCore struct {
+	zapcore.Core
+	sampled zapcore.Core
+	exempt  func(zapcore.Entry, []zapcore.Field) bool
+	// fields With添加的字段，用于豁免判断
+	fields []zapcore.Field
+}
+
+func (c *exemptSamplerCore) With(fields []zapcore.Field) zapcore.Core {
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+	all = append(all, fields...)
+	return &exemptSamplerCore{
+		Core:    c.Core.With(fields),
+		sampled: c.sampled.With(fields),
+		exempt:  c.exempt,
+		fields:  all,
+	}
+}
+
+func (c *exemptSamplerCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *exemptSamplerCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	all := fields
+	if len(c.fields) > 0 {
+		all = make([]zapcore.Field, 0, len(c.fields)+len(fields))
+		all = append(all, c.fields...)
+		all = append(all, fields...)
+	}
+	if c.exempt(entry, all) {
+		return c.Core.Write(entry, fields)
+	}
+	if ce := c.sampled.Check(entry, nil); ce != nil {
+		ce.Write(fields...)
+	}
+	return nil
+}
+
+// sampleCounts 每个采样键在当前周期内的日志条数
+type sampleCounts struct {
+	mu     sync.Mutex
+	tick   time.Duration
+	start  time.Time
+	counts map[string]uint64
+	// level 上次计数时core的日志级别，resetOnLevel为true时级别变化会清空计数
+	level        zapcore.Level
+	resetOnLevel bool
+}
+
+func newSampleCounts(tick time.Duration, resetOnLevel bool) *sampleCounts {
+	return &sampleCounts{tick: tick, counts: map[string]uint64{}, resetOnLevel: resetOnLevel}
+}
+
+// inc 增加采样键的计数并返回增加后的值，进入新的周期或日志级别变化时清空计数
+func (c *sampleCounts) inc(key string, now time.Time, level zapcore.Level) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now.Sub(c.start) >= c.tick || (c.resetOnLevel && level != c.level) {
+		c.start = now
+		c.level = level
+		c.counts = make(map[string]uint64, len(c.counts))
+	}
+	c.counts[key]++
+	return c.counts[key]
+}
+
+// samplerKeyByMessage 按级别和消息采样，与zap的采样器一致
+func samplerKeyByMessage(entry zapcore.Entry) string {
+	return entry.Level.String() + "\x00" + entry.Message
+}
+
+// samplerKeyByCaller 按调用位置采样
+func samplerKeyByCaller(entry zapcore.Entry) string {
+	return entry.Caller.String()
+}
+
+// keyedSamplerCore 按采样键计数的core。zap在Check之后才填充调用位置，因此在写入时采样
+type keyedSamplerCore struct {
+	zapcore.Core
+	counts  *sampleCounts
+	key     func(zapcore.Entry) string
+	initial uint64
+	after   uint64
+	exempt  func(zapcore.Entry, []zapcore.Field) bool
+	now     func() time.Time
+	// fields With添加的字段，用于豁免判断
+	fields []zapcore.Field
+}
+
+func (c *keyedSamplerCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.Core = c.Core.With(fields)
+	if c.exempt != nil {
+		clone.fields = make([]zapcore.Field, 0, len(c.fields)+len(fields))
+		clone.fields = append(clone.fields, c.fields...)
+		clone.fields = append(clone.fields, fields...)
+	}
+	return &clone
+}
+
+func (c *keyedSamplerCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *keyedSamplerCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if c.exempt != nil {
+		all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+		all = append(all, c.fields...)
+		all = append(all, fields...)
+		if c.exempt(entry, all) {
+			return c.Core.Write(entry, fields)
+		}
+	}
+	var level zapcore.Level
+	if c.counts.resetOnLevel {
+		level = zapcore.LevelOf(c.Core)
+	}
+	n := c.counts.inc(c.key(entry), c.now(), level)
+	if n > c.initial && (c.after == 0 || (n-c.initial)%c.after != 0) {
+		samplingHook(entry, zapcore.LogDropped)
+		return nil
+	}
+	return c.Core.Write(entry, fields)
+}
+
+// Sampler 自定义采样逻辑，ShouldLog返回false的日志被丢弃，例如按时间段或功能开关采样。
+// ShouldLog在zap检查日志是否需要记录时调用，此时entry中还没有调用位置
+type Sampler interface {
+	ShouldLog(entry zapcore.Entry) bool
+}
+
+// SamplerFunc 将函数转换为Sampler
+type SamplerFunc func(entry zapcore.Entry) bool
+
+func (f SamplerFunc) ShouldLog(entry zapcore.Entry) bool {
+	return f(entry)
+}
+
+// customSamplerCore 由Sampler决定是否记录日志的core
+type customSamplerCore struct {
+	zapcore.Core
+	sampler Sampler
+}
+
+func (c *customSamplerCore) With(fields []zapcore.Field) zapcore.Core {
+	return &customSamplerCore{Core: c.Core.With(fields), sampler: c.sampler}
+}
+
+func (c *customSamplerCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(entry.Level) {
+		return ce
+	}
+	if !c.sampler.ShouldLog(entry) {
+		samplingHook(entry, zapcore.LogDropped)
+		return ce
+	}
+	return c.Core.Check(entry, ce)
+}
+
+// WarmupSamplingConfig 启动期采样配置，创建Logger后的Duration时间内按Initial、Thereafter更严格地采样，
+// 之后不再经过该采样(仍受Sampling等其他采样配置影响)，用于减少启动阶段大量重复的日志
+type WarmupSamplingConfig struct {
+	// 启动期时长
+	Duration time.Duration `json:"duration" yaml:"duration"`
+
+	// 采样周期，默认1秒
+	Tick time.Duration `json:"tick" yaml:"tick"`
+
+	Initial int `json:"initial" yaml:"initial"`
+
+	Thereafter int `json:"thereafter" yaml:"thereafter"`
+}
+
+// warmupSamplerCore 启动期内经过采样，启动期结束后直接写入。时间取自注入的时钟，zap的采样器使用日志的时间计算周期
+type warmupSamplerCore struct {
+	zapcore.Core
+	sampled zapcore.Core
+	end     time.Time
+	now     func() time.Time
+}
+
+func newWarmupSamplerCore(core zapcore.Core, config *WarmupSamplingConfig, now func() time.Time) zapcore.Core {
+	if config.Duration <= 0 {
+		return core
+	}
+	tick := config.Tick
+	if tick <= 0 {
+		tick = time.Second
+	}
+	return &warmupSamplerCore{
+		Core:    core,
+		sampled: zapcore.NewSamplerWithOptions(core, tick, config.Initial, config.Thereafter, zapcore.SamplerHook(samplingHook)),
+		end:     now().Add(config.Duration),
+		now:     now,
+	}
+}
+
+func (c *warmupSamplerCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.Core = c.Core.With(fields)
+	clone.sampled = c.sampled.With(fields)
+	return &clone
+}
+
+func (c *warmupSamplerCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.now().Before(c.end) {
+		return c.sampled.Check(entry, ce)
+	}
+	return c.Core.Check(entry, ce)
+}
@@ -0,0 +1,199 @@
+package pzlog
+
+import (
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSamplingDroppedCounter(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.Sampling = &SamplingConfig{Tick: time.Minute, Initial: 2, Thereafter: 0}
+	logger := GetLogger(config)
+	infoBefore := DroppedSamples(zapcore.InfoLevel)
+	warnBefore := DroppedSamples(zapcore.WarnLevel)
+	for i := 0; i < 10; i++ {
+		logger.Info("repeated")
+	}
+	logger.Warn("once")
+	if got := len(out.Lines()); got != 3 {
+		t.Errorf("got %d lines, want 3", got)
+	}
+	if got := DroppedSamples(zapcore.InfoLevel) - infoBefore; got != 8 {
+		t.Errorf("dropped info = %d, want 8", got)
+	}
+	if got := DroppedSamples(zapcore.WarnLevel) - warnBefore; got != 0 {
+		t.Errorf("dropped warn = %d, want 0", got)
+	}
+	if got := DroppedSamplesByLevel()["info"]; got != DroppedSamples(zapcore.InfoLevel) {
+		t.Errorf("DroppedSamplesByLevel()[info] = %d, want %d", got, DroppedSamples(zapcore.InfoLevel))
+	}
+}
+
+func TestDroppedSamplesOutOfRange(t *testing.T) {
+	if got := DroppedSamples(zapcore.Level(42)); got != 0 {
+		t.Errorf("DroppedSamples(42) = %d, want 0", got)
+	}
+}
+
+func TestSamplingExempt(t *testing.T) {
+	config, out := newCapturedConfig()
+	config.Sampling = &SamplingConfig{Tick: time.Minute, Initial: 1, Thereafter: 0, Exempt: ExemptKey("audit")}
+	logger := GetLogger(config)
+	auditLogger := logger.With(zap.Bool("audit", true))
+	for i := 0; i < 5; i++ {
+		logger.Info("repeated", zap.Bool("audit", true))
+		logger.Info("repeated", zap.Bool("audit", false))
+		auditLogger.Info("with field")
+		logger.Info("sampled")
+	}
+
+	counts := map[string]int{}
+	for _, e := range out.Entries(t) {
+		counts[e["msg"].(string)+" "+fmt.Sprint(e["audit"])]++
+	}
+	want := map[string]int{
+		"repeated true":   5,
+		"with field true": 5,
+		// 未豁免的日志每个消息只记录第一条
+		"repeated false": 1,
+		"sampled <nil>":  1,
+	}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("counts = %v, want %v", counts, want)
+	}
+}
+
+func TestExemptKey(t *testing.T) {
+	exempt := ExemptKey("audit")
+	tests := []struct {
+		fields []zapcore.Field
+		want   bool
+	}{
+		{nil, false},
+		{[]zapcore.Field{zap.Bool("audit", true)}, true},
+		{[]zapcore.Field{zap.Bool("audit", false)}, false},
+		{[]zapcore.Field{zap.String("audit", "login")}, true},
+		{[]zapcore.Field{zap.String("other", "x")}, false},
+	}
+	for _, tt := range tests {
+		if got := exempt(zapcore.Entry{}, tt.fields); got != tt.want {
+			t.Errorf("ExemptKey(%v) = %v, want %v", tt.fields, got, tt.want)
+		}
+	}
+}
+
+func TestSamplingByCaller(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	config, out := newCapturedConfig()
+	config.Clock = clock
+	config.Sampling = &SamplingConfig{Tick: time.Minute, Initial: 2, Thereafter: 0, ByCaller: true}
+	logger := GetLogger(config)
+	burst := func() {
+		for i := 0; i < 10; i++ {
+			logger.Info("same message", zap.String("site", "a"))
+			logger.Info("same message", zap.String("site", "b"))
+		}
+	}
+	burst()
+	logger.Info("other message", zap.String("site", "c"))
+	// 周期内的时间不清空计数
+	clock.Add(59 * time.Second)
+	burst()
+	// 进入下一个周期，每个调用位置重新计数
+	clock.Add(time.Second)
+	burst()
+
+	counts := map[string]int{}
+	for _, e := range out.Entries(t) {
+		counts[e["site"].(string)]++
+	}
+	want := map[string]int{"a": 4, "b": 4, "c": 1}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("counts = %v, want %v", counts, want)
+	}
+}
+
+func TestSamplingResetOnLevelChange(t *testing.T) {
+	for _, reset := range []bool{false, true} {
+		config, out := newCapturedConfig()
+		config.Sampling = &SamplingConfig{Tick: time.Minute, Initial: 1, Thereafter: 0, ResetOnLevelChange: reset}
+		core, level, err := BuildCore(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		logger := zap.New(core)
+		for i := 0; i < 5; i++ {
+			logger.Info("noisy")
+		}
+		level.SetLevel(zapcore.DebugLevel)
+		logger.Info("noisy")
+		logger.Debug("fresh debug")
+		logger.Debug("fresh debug")
+
+		counts := map[string]int{}
+		for _, e := range out.Entries(t) {
+			counts[e["msg"].(string)]++
+		}
+		want := map[string]int{"noisy": 1, "fresh debug": 1}
+		if reset {
+			want["noisy"] = 2
+		}
+		if !reflect.DeepEqual(counts, want) {
+			t.Errorf("ResetOnLevelChange %v: counts = %v, want %v", reset, counts, want)
+		}
+	}
+}
+
+func TestCustomSampler(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	config, out := newCapturedConfig()
+	config.Clock = clock
+	config.Sampler = SamplerFunc(func(entry zapcore.Entry) bool {
+		return entry.Time.Second()%2 == 0
+	})
+	logger := GetLogger(config)
+	for i := 0; i < 4; i++ {
+		logger.Info(fmt.Sprintf("second %d", i))
+		clock.Add(time.Second)
+	}
+
+	var msgs []string
+	for _, e := range out.Entries(t) {
+		msgs = append(msgs, e["msg"].(string))
+	}
+	if want := []string{"second 0", "second 2"}; !reflect.DeepEqual(msgs, want) {
+		t.Errorf("logged %q, want %q", msgs, want)
+	}
+}
+
+func TestWarmupSampling(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	config, out := newCapturedConfig()
+	config.Clock = clock
+	config.WarmupSampling = &WarmupSamplingConfig{Duration: 10 * time.Second, Initial: 2, Thereafter: 5}
+	logger := GetLogger(config)
+
+	burst := func() int {
+		before := len(out.Lines())
+		for i := 0; i < 12; i++ {
+			logger.Info("booting")
+		}
+		return len(out.Lines()) - before
+	}
+	// 启动期内每秒前2条和之后每5条中的1条
+	if got := burst(); got != 4 {
+		t.Errorf("during warmup: logged %d of 12, want 4", got)
+	}
+	clock.Add(9 * time.Second)
+	if got := burst(); got != 4 {
+		t.Errorf("end of warmup: logged %d of 12, want 4", got)
+	}
+	clock.Add(time.Second)
+	if got := burst(); got != 12 {
+		t.Errorf("after warmup: logged %d of 12, want all", got)
+	}
+}
@@ -0,0 +1,97 @@
+package pzlog
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// encoderSchema 描述日志的字段名、时间格式和级别编码，供日志解析工具读取
+type encoderSchema struct {
+	Encoder string            `json:"encoder"`
+	Keys    map[string]string `json:"keys"`
+	// ExtraKeys 由配置选项额外输出的字段，例如level_num、uptime_ms
+	ExtraKeys        []string `json:"extra_keys"`
+	TimeFormat       string   `json:"time_format"`
+	TimeZone         string   `json:"time_zone"`
+	LevelEncoding    string   `json:"level_encoding"`
+	DurationEncoding string   `json:"duration_encoding"`
+}
+
+// EncoderSchema 返回配置实际使用的Encoder的json描述，包括各字段名(含EventKey、TraceIDField等自定义的字段名)、时间格式和级别编码，
+// 日志解析工具可据此解析日志，无需硬编码字段名
+func EncoderSchema(config *PzlogConfig) ([]byte, error) {
+	if config == nil {
+		config = NewDefaultConfig()
+	}
+	setDefaultValue(config)
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+	return json.Marshal(newEncoderSchema(config))
+}
+
+func newEncoderSchema(config *PzlogConfig) encoderSchema {
+	s := encoderSchema{
+		Encoder: config.Encoder,
+		Keys: map[string]string{
+			"time":       "ts",
+			"level":      "level",
+			"name":       "logger",
+			"caller":     "caller_line",
+			"message":    "msg",
+			"stacktrace": "stacktrace",
+		},
+		ExtraKeys:        []string{},
+		TimeFormat:       config.TimeFormat,
+		TimeZone:         "Local",
+		LevelEncoding:    "capital",
+		DurationEncoding: config.DurationEncoding,
+	}
+	if config.location != nil {
+		s.TimeZone = config.location.String()
+	}
+	if s.DurationEncoding == "" {
+		s.DurationEncoding = DurationSeconds
+	}
+	switch config.Encoder {
+	case "console":
+		if config.ConsoleLevelFormat == ConsoleLevelShort {
+			s.LevelEncoding = ConsoleLevelShort
+		}
+	case "console-oneline":
+		delete(s.Keys, "caller")
+		s.TimeFormat = "15:04:05"
+		s.LevelEncoding = "abbreviated"
+		s.DurationEncoding = DurationString
+	}
+	if config.SplitCaller {
+		s.Keys["caller_file"] = "caller_file"
+	}
+	s.Keys["event"] = defaultEventKey
+	if config.EventKey != "" {
+		s.Keys["event"] = config.EventKey
+	}
+	if config.TraceIDContextKey != nil {
+		s.Keys["trace_id"] = defaultTraceIDField
+		if config.TraceIDField != "" {
+			s.Keys["trace_id"] = config.TraceIDField
+		}
+	}
+	if config.LowercaseKeys {
+		for name, key := range s.Keys {
+			s.Keys[name] = strings.ToLower(key)
+		}
+	}
+	extra := func(enabled bool, key string) {
+		if enabled {
+			s.ExtraKeys = append(s.ExtraKeys, key)
+		}
+	}
+	extra(config.LevelNumber, "level_num")
+	extra(config.LevelVerbose, "level_verbose")
+	extra(config.IsErrorField, "is_error")
+	extra(config.Uptime, "uptime_ms")
+	extra(config.GoroutineID, "goid")
+	extra(config.FormatVersion > 0, "log_format_version")
+	return s
+}
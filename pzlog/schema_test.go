@@ -0,0 +1,69 @@
+package pzlog
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestEncoderSchema(t *testing.T) {
+	type traceKey struct{}
+	config := NewDefaultConfig()
+	config.Encoder = "console"
+	config.ConsoleLevelFormat = ConsoleLevelShort
+	config.TimeFormat = "2006-01-02T15:04:05"
+	config.TimeZone = "UTC"
+	config.DurationEncoding = DurationMillis
+	config.EventKey = "EventName"
+	config.TraceIDContextKey = traceKey{}
+	config.TraceIDField = "TraceID"
+	config.LowercaseKeys = true
+	config.SplitCaller = true
+	config.LevelNumber = true
+	config.GoroutineID = true
+
+	data, err := EncoderSchema(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema encoderSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("invalid schema %s: %v", data, err)
+	}
+	want := encoderSchema{
+		Encoder: "console",
+		Keys: map[string]string{
+			"time":        "ts",
+			"level":       "level",
+			"name":        "logger",
+			"caller":      "caller_line",
+			"caller_file": "caller_file",
+			"message":     "msg",
+			"stacktrace":  "stacktrace",
+			"event":       "eventname",
+			"trace_id":    "traceid",
+		},
+		ExtraKeys:        []string{"level_num", "goid"},
+		TimeFormat:       "2006-01-02T15:04:05",
+		TimeZone:         "UTC",
+		LevelEncoding:    ConsoleLevelShort,
+		DurationEncoding: DurationMillis,
+	}
+	if !reflect.DeepEqual(schema, want) {
+		t.Errorf("schema = %+v\nwant %+v", schema, want)
+	}
+
+	data, err = EncoderSchema(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema.Keys["event"] != "event" || schema.LevelEncoding != "capital" || schema.DurationEncoding != DurationSeconds {
+		t.Errorf("default schema = %+v", schema)
+	}
+	if _, err := EncoderSchema(&PzlogConfig{Encoder: "xml"}); err == nil {
+		t.Error("invalid config accepted")
+	}
+}
@@ -0,0 +1,33 @@
+package pzlog
+
+import (
+	"go.uber.org/zap"
+	"time"
+)
+
+// Scope 记录一次操作的开始和结束，立即以info级别记录start，返回的函数记录end及耗时和结果，
+// 通常配合具名返回值err使用：
+//
+//	func work() (err error) {
+//		defer pzlog.Scope("work", zap.Int("id", 1))(&err)
+//		...
+//	}
+//
+// 传入的err非nil且指向的错误不为nil时以error级别记录end，并带上error字段
+func Scope(name string, fields ...zap.Field) func(*error) {
+	logger := zap.L().WithOptions(zap.AddCallerSkip(1)).With(zap.String("scope", name))
+	logger.Info("start", fields...)
+	start := time.Now()
+	return func(errp *error) {
+		fs := make([]zap.Field, 0, len(fields)+3)
+		fs = append(fs, fields...)
+		fs = append(fs, zap.Float64("elapsed_ms", durationMs(time.Since(start))))
+		if errp != nil && *errp != nil {
+			fs = append(fs, zap.String("outcome", "error"), zap.Error(*errp))
+			logger.Error("end", fs...)
+			return
+		}
+		fs = append(fs, zap.String("outcome", "ok"))
+		logger.Info("end", fs...)
+	}
+}
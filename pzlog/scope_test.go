@@ -0,0 +1,57 @@
+package pzlog
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"strings"
+	"testing"
+	"time"
+)
+
+func scopedWork(fail bool) (err error) {
+	defer Scope("work", zap.Int("id", 1))(&err)
+	time.Sleep(5 * time.Millisecond)
+	if fail {
+		return errors.New("disk full")
+	}
+	return nil
+}
+
+func TestScope(t *testing.T) {
+	tests := []struct {
+		fail    bool
+		level   zapcore.Level
+		outcome string
+	}{
+		{false, zapcore.InfoLevel, "ok"},
+		{true, zapcore.ErrorLevel, "error"},
+	}
+	for _, tt := range tests {
+		logs := observeGlobals(t, zapcore.InfoLevel)
+		_ = scopedWork(tt.fail)
+
+		entries := logs.All()
+		if len(entries) != 2 || entries[0].Message != "start" || entries[1].Message != "end" {
+			t.Fatalf("entries = %v, want start and end", entries)
+		}
+		start, end := entries[0].ContextMap(), entries[1].ContextMap()
+		if start["scope"] != "work" || start["id"] != int64(1) || end["scope"] != "work" || end["id"] != int64(1) {
+			t.Errorf("start = %v, end = %v", start, end)
+		}
+		if ms, _ := end["elapsed_ms"].(float64); ms < 5 {
+			t.Errorf("elapsed_ms = %v, want at least 5", end["elapsed_ms"])
+		}
+		if entries[1].Level != tt.level || end["outcome"] != tt.outcome {
+			t.Errorf("end level = %v, outcome = %v", entries[1].Level, end["outcome"])
+		}
+		if tt.fail && end["error"] != "disk full" {
+			t.Errorf("error = %v, want disk full", end["error"])
+		}
+		for _, e := range entries {
+			if !strings.HasSuffix(e.Caller.File, "scope_test.go") {
+				t.Errorf("%s caller = %s, want scope_test.go", e.Message, e.Caller.File)
+			}
+		}
+	}
+}
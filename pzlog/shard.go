@@ -0,0 +1,41 @@
+package pzlog
+
+import (
+	"go.uber.org/zap/zapcore"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// shardFilename 返回第i个分片的文件名，例如app.log的第0个分片为app.0.log
+func shardFilename(filename string, i int) string {
+	ext := filepath.Ext(filename)
+	return strings.TrimSuffix(filename, ext) + "." + strconv.Itoa(i) + ext
+}
+
+// shardWriteSyncer 将日志轮流写入多个WriteSyncer
+type shardWriteSyncer struct {
+	shards []zapcore.WriteSyncer
+	next   atomic.Uint64
+}
+
+func newShardWriteSyncer(shards []zapcore.WriteSyncer) *shardWriteSyncer {
+	return &shardWriteSyncer{shards: shards}
+}
+
+func (w *shardWriteSyncer) Write(p []byte) (int, error) {
+	i := (w.next.Add(1) - 1) % uint64(len(w.shards))
+	return w.shards[i].Write(p)
+}
+
+// Sync 刷新所有分片，返回第一个错误
+func (w *shardWriteSyncer) Sync() error {
+	var err error
+	for _, s := range w.shards {
+		if e := s.Sync(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
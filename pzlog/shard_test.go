@@ -0,0 +1,37 @@
+package pzlog
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestShards(t *testing.T) {
+	if got := shardFilename("/var/log/app.log", 2); got != "/var/log/app.2.log" {
+		t.Errorf("shardFilename = %q, want /var/log/app.2.log", got)
+	}
+
+	dir := t.TempDir()
+	config := NewDefaultConfig()
+	config.Filename = filepath.Join(dir, "app.log")
+	config.Shards = 3
+	logger := GetLogger(config)
+	defer func() { _ = Close() }()
+	for i := 0; i < 9; i++ {
+		logger.Info(fmt.Sprintf("entry %d", i))
+	}
+
+	for i := 0; i < 3; i++ {
+		lines := strings.Split(strings.TrimSpace(readFile(t, shardFilename(config.Filename, i))), "\n")
+		if len(lines) != 3 {
+			t.Errorf("shard %d got %d entries, want 3", i, len(lines))
+			continue
+		}
+		for j, line := range lines {
+			if want := fmt.Sprintf(`"entry %d"`, i+3*j); !strings.Contains(line, want) {
+				t.Errorf("shard %d line %d = %q, want %s", i, j, line, want)
+			}
+		}
+	}
+}
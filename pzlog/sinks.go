@@ -0,0 +1,196 @@
+package pzlog
+
+import (
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// SinkConfig 独立的日志输出，可分别指定编码格式、输出位置和日志级别
+type SinkConfig struct {
+	// Output为file时的日志文件配置，Filename为空时使用PzlogConfig.Filename
+	lumberjack.Logger `yaml:",inline"`
+
+	// 输出名称，用于Health，默认为Output
+	Name string `json:"name" yaml:"name"`
+
+	// 日志格式，json、console、console-oneline、msgpack、loki、csv或者es-bulk(Elasticsearch _bulk接口的NDJSON)，为空时使用PzlogConfig.Encoder
+	Encoder string `json:"encoder" yaml:"encoder"`
+
+	// 日志输出位置，file、stdout、stderr或者none，默认file
+	Output string `json:"output" yaml:"output"`
+
+	// 日志级别，为空时使用PzlogConfig.LogLevel，并随其AtomicLevel调整，运行时可通过SetSinkLevel调整
+	Level string `json:"level" yaml:"level"`
+
+	// 不为nil时写入Writer而不是Output
+	Writer zapcore.WriteSyncer `json:"-" yaml:"-"`
+}
+
+// validate 检查输出配置
+func (s *SinkConfig) validate() error {
+	switch s.Encoder {
+	case "", "json", "console", "console-oneline", "msgpack", "loki", "csv", "es-bulk":
+	default:
+		return fmt.Errorf("unknown encoder %q, must be json, console, console-oneline, msgpack, loki, csv or es-bulk", s.Encoder)
+	}
+	switch s.Output {
+	case "", "file", "stdout", "stderr", "none":
+	default:
+		return fmt.Errorf("unknown output %q, must be file, stdout, stderr or none", s.Output)
+	}
+	if s.Level != "" {
+		if _, ok := parseLevel(s.Level); !ok {
+			return fmt.Errorf("unknown level %q", s.Level)
+		}
+	}
+	return nil
+}
+
+// output 返回输出位置，默认file
+func (s *SinkConfig) output() string {
+	if s.Writer != nil {
+		return "writer"
+	}
+	if s.Output == "" {
+		return "file"
+	}
+	return s.Output
+}
+
+// writeSyncer 创建输出的WriteSyncer，返回写入的文件名(不是文件时为空)，打开的文件记录到res。
+// 文件与主输出一样按RotateEvery、RotateCron、日期模板和Shards切割和分片，header不为nil时在输出或每个新文件的开头写入
+func (s *SinkConfig) writeSyncer(config *PzlogConfig, header []byte, res *coreResources) (zapcore.WriteSyncer, string) {
+	switch s.output() {
+	case "writer":
+		return withCSVHeader(s.Writer, header), ""
+	case "stdout":
+		return withCSVHeader(zapcore.Lock(os.Stdout), header), ""
+	case "stderr":
+		return withCSVHeader(zapcore.Lock(os.Stderr), header), ""
+	case "none":
+		return zapcore.AddSync(io.Discard), ""
+	}
+	filename := s.Filename
+	if filename == "" {
+		filename = config.Filename
+	}
+	return getFilesWriteSyncer(config, &s.Logger, filename, header, res), filename
+}
+
+// newSinksCore 为每个输出创建core并合并，未指定级别的输出使用level
+func newSinksCore(config *PzlogConfig, level zap.AtomicLevel, res *coreResources) (zapcore.Core, []*trackedSink) {
+	cores := make([]zapcore.Core, 0, len(config.Sinks))
+	tracked := make([]*trackedSink, 0, len(config.Sinks))
+	for i := range config.Sinks {
+		sink := &config.Sinks[i]
+		types := sink.Encoder
+		if types == "" {
+			types = config.Encoder
+		}
+		enc := newEncoder(config, types)
+		ws, filename := sink.writeSyncer(config, csvHeader(config, types), res)
+		if config.HashChain && filename != "" {
+			ws = newHashChainSyncer(ws, lastChainHash(filename))
+		}
+		if config.MaxLineBytes > 0 {
+			ws = newLineCapSyncer(ws, config.MaxLineBytes)
+		}
+		if config.OnWriteError != nil {
+			ws = newWriteErrorSyncer(ws, config.OnWriteError, time.Now)
+		}
+		name := sink.Name
+		if name == "" {
+			name = sink.output()
+		}
+		ts := newTrackedSink(name, filename, ws)
+		tracked = append(tracked, ts)
+		var out zapcore.WriteSyncer = ts
+		if config.Async {
+			ts.async = newAsyncWriteSyncer(ts, enc, config.AsyncBufferSize, config.BackpressureStrategy, config.AsyncDropReportInterval)
+			res.add(ts.async)
+			out = ts.async
+		}
+		ts.level = newSinkLevel(level)
+		if l, ok := parseLevel(sink.Level); ok {
+			ts.level.setLevel(l)
+		}
+		cores = append(cores, &sinkCore{Core: zapcore.NewCore(enc, out, ts.level)})
+	}
+	return zapcore.NewTee(cores...), tracked
+}
+
+// sinkCore 写入时检查级别的core。zapcore.NewTee在写入时不检查各core的级别，
+// 外层core直接调用Write时由sinkCore过滤级别不满足的日志
+type sinkCore struct {
+	zapcore.Core
+}
+
+func (c *sinkCore) With(fields []zapcore.Field) zapcore.Core {
+	return &sinkCore{Core: c.Core.With(fields)}
+}
+
+func (c *sinkCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *sinkCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if !c.Enabled(entry.Level) {
+		return nil
+	}
+	return c.Core.Write(entry, fields)
+}
+
+// sinkLevel 输出的日志级别，通过SetSinkLevel设置后使用设置的级别，否则跟随全局的AtomicLevel
+type sinkLevel struct {
+	global zapcore.LevelEnabler
+	level  atomic.Int32
+	set    atomic.Bool
+}
+
+func newSinkLevel(global zapcore.LevelEnabler) *sinkLevel {
+	return &sinkLevel{global: global}
+}
+
+func (l *sinkLevel) Enabled(level zapcore.Level) bool {
+	if l.set.Load() {
+		return level >= zapcore.Level(l.level.Load())
+	}
+	return l.global.Enabled(level)
+}
+
+func (l *sinkLevel) setLevel(level zapcore.Level) {
+	l.level.Store(int32(level))
+	l.set.Store(true)
+}
+
+// SetSinkLevel 在运行时调整最近一次GetLogger或Reconfigure创建的指定输出的日志级别，不影响其他输出。
+// 输出名称同SinkHealth.Name，例如Sinks中的Name，或者PrintConsole时的file和console
+func SetSinkLevel(name string, level zapcore.Level) error {
+	currentSwapMu.Lock()
+	state := currentSwap
+	currentSwapMu.Unlock()
+	if state == nil {
+		return errors.New("pzlog: no logger, call GetLogger first")
+	}
+	found := false
+	for _, s := range state.root.Load().sinks {
+		if s.name == name && s.level != nil {
+			s.level.setLevel(level)
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("pzlog: no sink named %q", name)
+	}
+	return nil
+}
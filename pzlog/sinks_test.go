@@ -0,0 +1,153 @@
+package pzlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSinks(t *testing.T) {
+	dir := t.TempDir()
+	var console bytes.Buffer
+	config := NewDefaultConfig()
+	config.LogLevel = "debug"
+	config.Sinks = []SinkConfig{
+		{Name: "console", Encoder: "console", Level: "warn", Writer: zapcore.AddSync(&console)},
+		{Name: "json", Encoder: "json"},
+		{Name: "errors", Encoder: "console-oneline", Level: "error"},
+	}
+	config.Sinks[1].Filename = filepath.Join(dir, "all.log")
+	config.Sinks[2].Filename = filepath.Join(dir, "errors.log")
+	logger := GetLogger(config)
+	defer func() { _ = Close() }()
+	logger.Debug("debug")
+	logger.Warn("warn")
+	logger.Error("error")
+
+	if lines := strings.Split(strings.TrimSpace(console.String()), "\n"); len(lines) != 2 ||
+		!strings.Contains(lines[0], "WARN\t") || !strings.Contains(lines[1], "ERROR\t") {
+		t.Errorf("console = %q, want warn and error in console format", console.String())
+	}
+	lines := strings.Split(strings.TrimSpace(readFile(t, config.Sinks[1].Filename)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("json sink got %d lines, want 3", len(lines))
+	}
+	for i, msg := range []string{"debug", "warn", "error"} {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(lines[i]), &entry); err != nil || entry["msg"] != msg {
+			t.Errorf("json line %d = %q, want msg %s", i, lines[i], msg)
+		}
+	}
+	if got := readFile(t, config.Sinks[2].Filename); strings.Count(got, "\n") != 1 || !strings.Contains(got, "error") || strings.HasPrefix(got, "{") {
+		t.Errorf("errors sink = %q, want only the error entry", got)
+	}
+}
+
+func TestSinkFileOptions(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 10, 0, 0, 0, time.Local)}
+	config := NewDefaultConfig()
+	config.Clock = clock
+	config.HashChain = true
+	config.RotateEvery = 2
+	config.Sinks = []SinkConfig{{Name: "dated"}}
+	config.Sinks[0].Filename = filepath.Join(dir, "app-%Y%m%d.log")
+	logger := GetLogger(config)
+	defer func() { _ = Close() }()
+	logger.Info("one")
+	logger.Info("two")
+	logger.Info("three")
+	clock.Add(24 * time.Hour)
+	logger.Info("next day")
+
+	first := readFile(t, filepath.Join(dir, "app-20240101.log"))
+	if !strings.Contains(first, `"three"`) || strings.Contains(first, `"one"`) {
+		t.Errorf("app-20240101.log = %q, want only three after RotateEvery", first)
+	}
+	if !strings.Contains(first, `"hash":"`) {
+		t.Errorf("app-20240101.log = %q, want hash chain fields", first)
+	}
+	if next := readFile(t, filepath.Join(dir, "app-20240102.log")); !strings.Contains(next, `"next day"`) {
+		t.Errorf("app-20240102.log = %q, want next day", next)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("got %d files, want two dated files and one backup", len(entries))
+	}
+
+	config = NewDefaultConfig()
+	config.MaxTotalSize = 10
+	config.Sinks = []SinkConfig{{Output: "stdout"}}
+	if _, err := GetLoggerE(config); err == nil || !strings.Contains(err.Error(), "maxtotalsize") {
+		t.Errorf("err = %v, want maxtotalsize not supported with sinks", err)
+	}
+}
+
+func TestSetSinkLevel(t *testing.T) {
+	var file, stdout bytes.Buffer
+	config := NewDefaultConfig()
+	config.Sinks = []SinkConfig{
+		{Name: "file", Writer: zapcore.AddSync(&file)},
+		{Name: "stdout", Writer: zapcore.AddSync(&stdout)},
+	}
+	logger := GetLogger(config)
+	logger.Debug("hidden")
+	if err := SetSinkLevel("file", zapcore.DebugLevel); err != nil {
+		t.Fatal(err)
+	}
+	logger.Debug("debug")
+	logger.Info("info")
+
+	if got := file.String(); strings.Contains(got, "hidden") || !strings.Contains(got, `"debug"`) || !strings.Contains(got, `"info"`) {
+		t.Errorf("file = %q, want debug and info after SetSinkLevel", got)
+	}
+	if got := stdout.String(); strings.Contains(got, `"debug"`) || !strings.Contains(got, `"info"`) {
+		t.Errorf("stdout = %q, want only info", got)
+	}
+	if err := SetSinkLevel("missing", zapcore.DebugLevel); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("err = %v, want no sink named missing", err)
+	}
+}
+
+func TestJSONSidecar(t *testing.T) {
+	dir := t.TempDir()
+	config := NewDefaultConfig()
+	config.Encoder = "console"
+	config.Filename = filepath.Join(dir, "app.log")
+	config.JSONSidecar = filepath.Join(dir, "app.json")
+	logger := GetLogger(config)
+	defer func() { _ = Close() }()
+	logger.Debug("dropped")
+	logger.Info("first", zap.String("user", "u1"))
+	logger.Warn("second")
+
+	main := strings.Split(strings.TrimSpace(readFile(t, config.Filename)), "\n")
+	sidecar := strings.Split(strings.TrimSpace(readFile(t, config.JSONSidecar)), "\n")
+	if len(main) != 2 || len(sidecar) != 2 {
+		t.Fatalf("got %d console and %d json lines, want 2 each", len(main), len(sidecar))
+	}
+	for i, msg := range []string{"first", "second"} {
+		if strings.HasPrefix(main[i], "{") || !strings.Contains(main[i], "\t"+msg) {
+			t.Errorf("console line %q, want a human-readable %s entry", main[i], msg)
+		}
+		var e map[string]interface{}
+		if err := json.Unmarshal([]byte(sidecar[i]), &e); err != nil {
+			t.Fatalf("sidecar line %q is not json: %v", sidecar[i], err)
+		}
+		if e["msg"] != msg {
+			t.Errorf("sidecar msg = %v, want %s", e["msg"], msg)
+		}
+	}
+	if !strings.Contains(sidecar[0], `"user":"u1"`) {
+		t.Errorf("sidecar line %q misses the fields", sidecar[0])
+	}
+}
@@ -0,0 +1,114 @@
+package pzlog
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+	"math"
+	"time"
+)
+
+// SlowLogConfig 慢操作日志配置，慢操作日志会在写入主日志的同时写入单独的文件
+type SlowLogConfig struct {
+	lumberjack.Logger
+
+	// elapsed_ms超过该阈值的日志也视为慢操作，为0时只根据slow字段判断
+	Threshold time.Duration `json:"threshold" yaml:"threshold"`
+}
+
+// LogSlowOp 记录一次操作(数据库、RPC等)的耗时，超过阈值时以warn级别记录并标记slow
+func LogSlowOp(name string, threshold, elapsed time.Duration, fields ...zap.Field) {
+	fs := make([]zap.Field, 0, len(fields)+4)
+	fs = append(fs,
+		zap.String("op", name),
+		zap.Float64("elapsed_ms", durationMs(elapsed)),
+		zap.Float64("threshold_ms", durationMs(threshold)),
+	)
+	logger := zap.L().WithOptions(zap.AddCallerSkip(1))
+	if elapsed > threshold {
+		fs = append(fs, zap.Bool("slow", true))
+		fs = append(fs, fields...)
+		logger.Warn("slow operation", fs...)
+		return
+	}
+	fs = append(fs, fields...)
+	logger.Debug("operation", fs...)
+}
+
+// durationMs 将时长转换为毫秒
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// newSlowLogCore 创建写入慢操作日志文件的core，header不为nil时在每个新文件的开头写入，打开的文件记录到res
+func newSlowLogCore(config *SlowLogConfig, enc zapcore.Encoder, header []byte, level zapcore.LevelEnabler, res *coreResources) zapcore.Core {
+	filename := config.Filename
+	if filename == "" {
+		filename = "./logs/slow.log"
+	}
+	logger := &lumberjack.Logger{
+		Filename:   filename,
+		MaxSize:    config.MaxSize,
+		MaxBackups: config.MaxBackups,
+		MaxAge:     config.MaxAge,
+		LocalTime:  config.LocalTime,
+		Compress:   config.Compress,
+	}
+	res.add(logger)
+	return zapcore.NewCore(enc, zapcore.AddSync(withCSVHeaderFile(logger, header)), level)
+}
+
+// slowRouteCore 将慢操作日志同时写入主日志和慢操作日志
+type slowRouteCore struct {
+	zapcore.Core
+	slow      zapcore.Core
+	threshold time.Duration
+}
+
+func newSlowRouteCore(core, slow zapcore.Core, threshold time.Duration) zapcore.Core {
+	return &slowRouteCore{Core: core, slow: slow, threshold: threshold}
+}
+
+func (c *slowRouteCore) With(fields []zapcore.Field) zapcore.Core {
+	return &slowRouteCore{Core: c.Core.With(fields), slow: c.slow.With(fields), threshold: c.threshold}
+}
+
+func (c *slowRouteCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *slowRouteCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	err := c.Core.Write(entry, fields)
+	if c.isSlow(fields) {
+		if slowErr := c.slow.Write(entry, fields); err == nil {
+			err = slowErr
+		}
+	}
+	return err
+}
+
+func (c *slowRouteCore) Sync() error {
+	err := c.Core.Sync()
+	if slowErr := c.slow.Sync(); err == nil {
+		err = slowErr
+	}
+	return err
+}
+
+// isSlow 根据slow字段或elapsed_ms字段判断是否为慢操作
+func (c *slowRouteCore) isSlow(fields []zapcore.Field) bool {
+	for _, f := range fields {
+		switch {
+		case f.Key == "slow" && f.Type == zapcore.BoolType && f.Integer == 1:
+			return true
+		case f.Key == "elapsed_ms" && f.Type == zapcore.Float64Type && c.threshold > 0:
+			if math.Float64frombits(uint64(f.Integer)) > durationMs(c.threshold) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package pzlog
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// observeGlobals 使用observer替换zap的全局Logger，测试结束时恢复
+func observeGlobals(t *testing.T, level zapcore.Level) *observer.ObservedLogs {
+	t.Helper()
+	core, logs := observer.New(level)
+	restore := zap.ReplaceGlobals(zap.New(core, zap.AddCaller()))
+	t.Cleanup(restore)
+	return logs
+}
+
+func TestLogSlowOpLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		level   zapcore.Level
+		msg     string
+		slow    bool
+	}{
+		{"below threshold", 50 * time.Millisecond, zapcore.DebugLevel, "operation", false},
+		{"at threshold", 100 * time.Millisecond, zapcore.DebugLevel, "operation", false},
+		{"above threshold", 150 * time.Millisecond, zapcore.WarnLevel, "slow operation", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := observeGlobals(t, zapcore.DebugLevel)
+			LogSlowOp("db.query", 100*time.Millisecond, tt.elapsed, zap.String("table", "users"))
+			want := map[string]interface{}{
+				"op":           "db.query",
+				"elapsed_ms":   float64(tt.elapsed) / float64(time.Millisecond),
+				"threshold_ms": float64(100),
+				"table":        "users",
+			}
+			if tt.slow {
+				want["slow"] = true
+			}
+			AssertLogged(t, logs, tt.level, tt.msg, want)
+			if _, ok := logs.All()[0].ContextMap()["slow"]; ok != tt.slow {
+				t.Errorf("slow field present = %v, want %v", ok, tt.slow)
+			}
+		})
+	}
+}
+
+func TestLogSlowOpCaller(t *testing.T) {
+	logs := observeGlobals(t, zapcore.DebugLevel)
+	LogSlowOp("rpc", time.Millisecond, time.Second)
+	if file := filepath.Base(logs.All()[0].Caller.File); file != "slowop_test.go" {
+		t.Errorf("caller file = %s, want slowop_test.go", file)
+	}
+}
+
+func TestSlowLogFile(t *testing.T) {
+	dir := t.TempDir()
+	config := NewDefaultConfig()
+	config.Filename = filepath.Join(dir, "app.log")
+	config.SlowLog = &SlowLogConfig{Threshold: 100 * time.Millisecond}
+	config.SlowLog.Filename = filepath.Join(dir, "slow.log")
+	logger := GetLogger(config)
+	defer func() { _ = Close() }()
+
+	logger.Warn("slow operation", zap.Bool("slow", true), zap.String("op", "flagged"))
+	logger.Info("query", zap.Float64("elapsed_ms", 250), zap.String("op", "threshold"))
+	logger.Info("query", zap.Float64("elapsed_ms", 5), zap.String("op", "fast"))
+	_ = logger.Sync()
+
+	main := readFile(t, config.Filename)
+	slow := readFile(t, config.SlowLog.Filename)
+	for _, op := range []string{"flagged", "threshold", "fast"} {
+		if !strings.Contains(main, `"op":"`+op+`"`) {
+			t.Errorf("main log missing %s entry", op)
+		}
+	}
+	for _, op := range []string{"flagged", "threshold"} {
+		if !strings.Contains(slow, `"op":"`+op+`"`) {
+			t.Errorf("slow log missing %s entry", op)
+		}
+	}
+	if strings.Contains(slow, `"op":"fast"`) {
+		t.Error("fast entry written to slow log")
+	}
+}
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,130 @@
+package pzlog
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sweepInterval 检查日志目录总大小的间隔
+var sweepInterval = time.Minute
+
+// backupTimeFormat lumberjack备份文件名中的时间格式
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// dirSweeper 定期检查日志文件及其备份的总大小，超过上限时从最旧的备份开始删除
+type dirSweeper struct {
+	// filenames 正在写入的日志文件，配置了Shards时为所有分片
+	filenames []string
+	maxBytes  int64
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// startDirSweeper 按配置为主输出的日志文件启动清理器，并记录到res，随Logger的core一起关闭。
+// 输出不是文件、配置了Sinks或者文件名包含日期模板时不启动。
+// 只由GetLogger和Reconfigure调用，BuildCore、NewAuditLogger等单独创建core时不启动清理器
+func startDirSweeper(config *PzlogConfig, res *coreResources) {
+	if config.MaxTotalSize <= 0 || config.Output != "file" || len(config.Sinks) > 0 || isDatedFilename(config.Filename) {
+		return
+	}
+	filenames := []string{config.Filename}
+	if config.Shards > 1 {
+		filenames = make([]string, config.Shards)
+		for i := range filenames {
+			filenames[i] = shardFilename(config.Filename, i)
+		}
+	}
+	s := &dirSweeper{
+		filenames: filenames,
+		maxBytes:  int64(config.MaxTotalSize) * 1024 * 1024,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go s.run(sweepInterval)
+	res.add(s)
+}
+
+func (s *dirSweeper) run(interval time.Duration) {
+	defer close(s.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		_ = s.sweep()
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close 停止清理器
+func (s *dirSweeper) Close() error {
+	close(s.stop)
+	<-s.done
+	return nil
+}
+
+// sweep 删除最旧的备份直到总大小不超过上限，正在写入的日志文件不会被删除，
+// 只统计正在写入的日志文件和lumberjack按时间命名的备份，同目录下的其他文件不受影响
+func (s *dirSweeper) sweep() error {
+	dir := filepath.Dir(s.filenames[0])
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	live := make(map[string]bool, len(s.filenames))
+	for _, filename := range s.filenames {
+		live[filepath.Base(filename)] = true
+	}
+	var total int64
+	var backups []os.FileInfo
+	for _, e := range entries {
+		name := e.Name()
+		backup := s.isBackup(name)
+		if !live[name] && !backup {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		total += info.Size()
+		if backup {
+			backups = append(backups, info)
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].ModTime().Before(backups[j].ModTime())
+	})
+	for _, info := range backups {
+		if total <= s.maxBytes {
+			break
+		}
+		if err := os.Remove(filepath.Join(dir, info.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		total -= info.Size()
+	}
+	return nil
+}
+
+// isBackup 判断name是否为某个日志文件的lumberjack备份，例如app.log的备份app-2006-01-02T15-04-05.000.log(.gz)
+func (s *dirSweeper) isBackup(name string) bool {
+	name = strings.TrimSuffix(name, ".gz")
+	for _, filename := range s.filenames {
+		base := filepath.Base(filename)
+		ext := filepath.Ext(base)
+		prefix := strings.TrimSuffix(base, ext) + "-"
+		if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) || len(name) < len(prefix)+len(ext) {
+			continue
+		}
+		if _, err := time.Parse(backupTimeFormat, name[len(prefix):len(name)-len(ext)]); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+package pzlog
+
+import (
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDirSweeper(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	const mb = 1024 * 1024
+	write := func(name string, size int, age time.Duration) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+		mtime := time.Now().Add(-age)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("app.log", mb, 0)
+	write("app-2024-01-01T00-00-00.000.log", mb, 3*time.Hour)
+	write("app-2024-01-02T00-00-00.000.log.gz", mb, 2*time.Hour)
+	write("app-2024-01-03T00-00-00.000.log", mb, time.Hour)
+	write("other.log", 5*mb, 4*time.Hour)
+	// 其他Logger正在写入的文件与备份前缀相同，但不是lumberjack的备份
+	write("app-audit.log", 5*mb, 5*time.Hour)
+
+	s := &dirSweeper{filenames: []string{filename}, maxBytes: 2 * mb}
+	if err := s.sweep(); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{
+		"app.log":                            true,
+		"app-2024-01-01T00-00-00.000.log":    false,
+		"app-2024-01-02T00-00-00.000.log.gz": false,
+		"app-2024-01-03T00-00-00.000.log":    true,
+		"other.log":                          true,
+		"app-audit.log":                      true,
+	} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if exists := err == nil; exists != want {
+			t.Errorf("%s exists = %v, want %v", name, exists, want)
+		}
+	}
+}
+
+func TestDirSweeperShards(t *testing.T) {
+	dir := t.TempDir()
+	const mb = 1024 * 1024
+	write := func(name string, size int, age time.Duration) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+		mtime := time.Now().Add(-age)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("app.0.log", mb, 0)
+	write("app.1.log", mb, 0)
+	write("app.0-2024-01-01T00-00-00.000.log", mb, 2*time.Hour)
+	write("app.1-2024-01-01T00-00-01.000.log", mb, time.Hour)
+
+	config := NewDefaultConfig()
+	config.Output = "file"
+	config.Filename = filepath.Join(dir, "app.log")
+	config.Shards = 2
+	config.MaxTotalSize = 3
+	res := &coreResources{}
+	startDirSweeper(config, res)
+	defer func() { _ = res.close() }()
+	if len(res.closers) != 1 {
+		t.Fatalf("got %d resources, want the sweeper", len(res.closers))
+	}
+	if err := res.closers[0].(*dirSweeper).sweep(); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{
+		"app.0.log":                         true,
+		"app.1.log":                         true,
+		"app.0-2024-01-01T00-00-00.000.log": false,
+		"app.1-2024-01-01T00-00-01.000.log": true,
+	} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if exists := err == nil; exists != want {
+			t.Errorf("%s exists = %v, want %v", name, exists, want)
+		}
+	}
+}
+
+func TestMaxTotalSizeDatedFilename(t *testing.T) {
+	config := NewDefaultConfig()
+	config.Output = "file"
+	config.Filename = filepath.Join(t.TempDir(), "app-%Y-%m-%d.log")
+	config.MaxTotalSize = 10
+	if _, err := GetLoggerE(config); err == nil {
+		t.Error("expected error for maxtotalsize with a dated filename")
+	}
+}
+
+// loggerSweeper 返回logger当前的core拥有的清理器
+func loggerSweeper(t *testing.T, logger *zap.Logger) *dirSweeper {
+	t.Helper()
+	res := logger.Core().(*swapCore).state.root.Load().resources
+	res.mu.Lock()
+	defer res.mu.Unlock()
+	for _, c := range res.closers {
+		if s, ok := c.(*dirSweeper); ok {
+			return s
+		}
+	}
+	return nil
+}
+
+// sweeperRunning 判断清理器是否仍在运行
+func sweeperRunning(s *dirSweeper) bool {
+	select {
+	case <-s.done:
+		return false
+	default:
+		return true
+	}
+}
+
+func TestDirSweeperOwnedByLogger(t *testing.T) {
+	dir := t.TempDir()
+	config := NewDefaultConfig()
+	config.Filename = filepath.Join(dir, "app.log")
+	config.MaxTotalSize = 10
+	logger := GetLogger(config)
+	s := loggerSweeper(t, logger)
+	if s == nil || s.filenames[0] != config.Filename {
+		t.Fatalf("sweeper = %+v, want one for %s", s, config.Filename)
+	}
+
+	// 单独创建core或为其他组件创建Logger不影响正在运行的清理器
+	other := NewDefaultConfig()
+	other.Filename = filepath.Join(dir, "core.log")
+	if _, _, err := BuildCore(other); err != nil {
+		t.Fatal(err)
+	}
+	audit := NewDefaultConfig()
+	audit.Filename = filepath.Join(dir, "audit.log")
+	audit.MaxTotalSize = 1
+	if _, err := NewAuditLogger(audit); err != nil {
+		t.Fatal(err)
+	}
+	stdout := NewDefaultConfig()
+	stdout.Output = "stdout"
+	GetLogger(stdout)
+	if !sweeperRunning(s) {
+		t.Error("creating another logger stopped the first logger's sweeper")
+	}
+	_ = logger.Core().(*swapCore).state.root.Load().resources.close()
+}
+
+func TestDirSweeperClosedWithCore(t *testing.T) {
+	dir := t.TempDir()
+	config := NewDefaultConfig()
+	config.Filename = filepath.Join(dir, "app.log")
+	config.MaxTotalSize = 10
+	logger := GetLogger(config)
+	defer func() { _ = Close() }()
+	s := loggerSweeper(t, logger)
+
+	next := NewDefaultConfig()
+	next.Filename = config.Filename
+	if err := Reconfigure(next); err != nil {
+		t.Fatal(err)
+	}
+	if sweeperRunning(s) {
+		t.Error("sweeper still running after Reconfigure replaced its core")
+	}
+	if loggerSweeper(t, logger) != nil {
+		t.Error("Reconfigure without MaxTotalSize started a sweeper")
+	}
+}
@@ -0,0 +1,15 @@
+package pzlog
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"time"
+)
+
+// processStart 包初始化时记录的时间，包含单调时钟读数，不受系统时间调整影响
+var processStart = time.Now()
+
+// uptimeFields 返回进程启动以来的毫秒数字段uptime_ms
+func uptimeFields(zapcore.Entry) []zapcore.Field {
+	return []zapcore.Field{zap.Int64("uptime_ms", time.Since(processStart).Milliseconds())}
+}
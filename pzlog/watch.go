@@ -0,0 +1,87 @@
+package pzlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// watchInterval 检查配置文件变化的间隔
+var watchInterval = time.Second
+
+// LoadConfigFile 从json或yaml文件读取配置，根据扩展名判断格式，.yaml和.yml为yaml，其余为json。
+// 文件中没有的字段使用NewDefaultConfig中的默认值
+func LoadConfigFile(path string) (*PzlogConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseConfig(path, data)
+}
+
+func parseConfig(path string, data []byte) (*PzlogConfig, error) {
+	config := NewDefaultConfig()
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("pzlog: parse %s: %w", path, err)
+		}
+	default:
+		if err := json.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("pzlog: parse %s: %w", path, err)
+		}
+	}
+	return config, nil
+}
+
+// WatchConfig 读取配置文件并应用到最近一次GetLogger创建的Logger，之后定期检查文件内容，
+// 变化时通过Reconfigure生效。变化后的配置无效时记录警告并忽略，继续使用上一次有效的配置。
+// 返回的stop函数用于停止监听。
+func WatchConfig(path string) (stop func(), err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config, err := parseConfig(path, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := Reconfigure(config); err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(watchInterval)
+		defer ticker.Stop()
+		last := data
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			data, err := os.ReadFile(path)
+			if err != nil || bytes.Equal(data, last) {
+				continue
+			}
+			last = data
+			config, err := parseConfig(path, data)
+			if err == nil {
+				err = Reconfigure(config)
+			}
+			if err != nil {
+				reloadLogger().Warn("pzlog: ignore invalid config reload", zap.String("path", path), zap.Error(err))
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }, nil
+}
@@ -0,0 +1,83 @@
+package pzlog
+
+import (
+	"go.uber.org/zap/zapcore"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigFileDefaults(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"pzlog.json": `{"loglevel":"debug","maxsize":5}`,
+		"pzlog.yaml": "loglevel: debug\nmaxsize: 5\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		config, err := LoadConfigFile(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if config.LogLevel != "debug" {
+			t.Errorf("%s: LogLevel = %q", name, config.LogLevel)
+		}
+		// 文件中没有的字段使用默认值
+		if config.Filename != "./logs/pzlog.log" || config.MaxBackups != 10 || config.MaxAge != 30 {
+			t.Errorf("%s: defaults not applied: %q, %d, %d", name, config.Filename, config.MaxBackups, config.MaxAge)
+		}
+	}
+}
+
+func TestWatchConfig(t *testing.T) {
+	prev := watchInterval
+	watchInterval = 5 * time.Millisecond
+	defer func() { watchInterval = prev }()
+
+	config, _ := newCapturedConfig()
+	logger := GetLogger(config)
+	path := filepath.Join(t.TempDir(), "pzlog.json")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`{"output":"none","loglevel":"warn"}`)
+	stop, err := WatchConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+	if logger.Core().Enabled(zapcore.InfoLevel) {
+		t.Fatal("initial config not applied")
+	}
+
+	waitEnabled := func(level zapcore.Level) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for !logger.Core().Enabled(level) {
+			if time.Now().After(deadline) {
+				t.Fatalf("level %v not enabled after reload", level)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	write(`{"output":"none","loglevel":"debug"}`)
+	waitEnabled(zapcore.DebugLevel)
+
+	// 无效的配置被忽略，保留上一次有效的配置
+	write(`{"output":"none","loglevel":"error","encoder":"xml"}`)
+	time.Sleep(50 * time.Millisecond)
+	if !logger.Core().Enabled(zapcore.DebugLevel) {
+		t.Error("invalid config was applied")
+	}
+	write(`{"output":"none","loglevel":"info"`)
+	time.Sleep(50 * time.Millisecond)
+	if !logger.Core().Enabled(zapcore.DebugLevel) {
+		t.Error("unparsable config was applied")
+	}
+}
@@ -0,0 +1,48 @@
+package pzlog
+
+import (
+	"go.uber.org/zap/zapcore"
+	"sync"
+	"time"
+)
+
+// writeErrorInterval 相同的写入错误在该时间内只通知一次
+const writeErrorInterval = time.Minute
+
+// writeErrorSyncer 写入失败时调用回调函数，相同的错误在writeErrorInterval内只通知一次，避免告警风暴
+type writeErrorSyncer struct {
+	zapcore.WriteSyncer
+	fn  func(error)
+	now func() time.Time
+
+	mu       sync.Mutex
+	lastMsg  string
+	lastTime time.Time
+}
+
+func newWriteErrorSyncer(ws zapcore.WriteSyncer, fn func(error), now func() time.Time) *writeErrorSyncer {
+	return &writeErrorSyncer{WriteSyncer: ws, fn: fn, now: now}
+}
+
+func (w *writeErrorSyncer) Write(p []byte) (int, error) {
+	n, err := w.WriteSyncer.Write(p)
+	if err != nil {
+		w.notify(err)
+	}
+	return n, err
+}
+
+// notify 判断错误是否需要通知，需要时调用回调函数
+func (w *writeErrorSyncer) notify(err error) {
+	msg := err.Error()
+	now := w.now()
+	w.mu.Lock()
+	if msg == w.lastMsg && now.Sub(w.lastTime) < writeErrorInterval {
+		w.mu.Unlock()
+		return
+	}
+	w.lastMsg = msg
+	w.lastTime = now
+	w.mu.Unlock()
+	w.fn(err)
+}
@@ -0,0 +1,69 @@
+package pzlog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// failingWriter 写入总是返回err
+type failingWriter struct {
+	err error
+}
+
+func (w *failingWriter) Write([]byte) (int, error) { return 0, w.err }
+
+func (w *failingWriter) Sync() error { return nil }
+
+func TestWriteErrorDedup(t *testing.T) {
+	fw := &failingWriter{err: errors.New("no space left on device")}
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	var notified []string
+	w := newWriteErrorSyncer(fw, func(err error) { notified = append(notified, err.Error()) }, clock.Now)
+
+	for i := 0; i < 10; i++ {
+		if _, err := w.Write([]byte("x")); err == nil {
+			t.Fatal("write error not returned")
+		}
+		clock.Add(time.Second)
+	}
+	if len(notified) != 1 {
+		t.Fatalf("notified %d times within the interval, want 1", len(notified))
+	}
+
+	fw.err = errors.New("input/output error")
+	_, _ = w.Write([]byte("x"))
+	if len(notified) != 2 || notified[1] != "input/output error" {
+		t.Errorf("different error not notified: %v", notified)
+	}
+
+	fw.err = errors.New("no space left on device")
+	_, _ = w.Write([]byte("x"))
+	clock.Add(writeErrorInterval)
+	_, _ = w.Write([]byte("x"))
+	if len(notified) != 4 {
+		t.Errorf("notified %d times, want 4 after the interval passed", len(notified))
+	}
+}
+
+func TestOnWriteErrorConfig(t *testing.T) {
+	// 父路径是普通文件，lumberjack无法创建日志文件
+	parent := filepath.Join(t.TempDir(), "not-a-dir")
+	if err := os.WriteFile(parent, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	var notified int
+	config := NewDefaultConfig()
+	config.Filename = filepath.Join(parent, "app.log")
+	config.OnWriteError = func(error) { notified++ }
+	logger := GetLogger(config)
+	defer func() { _ = Close() }()
+	for i := 0; i < 5; i++ {
+		logger.Info("lost")
+	}
+	if notified != 1 {
+		t.Errorf("OnWriteError called %d times, want 1", notified)
+	}
+}